@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	cliwizard "docker-wizard/internal/cli"
+	"docker-wizard/internal/generator"
 	"docker-wizard/internal/tui"
 )
 
@@ -23,8 +24,20 @@ type AutomationOptions struct {
 	Language string
 	Write    bool
 	DryRun   bool
+	Diff     bool
+	Set      []string
+	Profiles []string
+	App      AppSettings
+	// ComposeOverride splits local development settings into
+	// docker-compose.override.yml.
+	ComposeOverride bool
+	// ExcludeApps names monorepo apps left out of the generated files.
+	ExcludeApps []string
 }
 
+// AppSettings configures the generated app service in batch mode.
+type AppSettings = generator.AppSettings
+
 type Options struct {
 	Mode       Mode
 	Automation AutomationOptions
@@ -72,10 +85,16 @@ func RunWithOptions(options Options) error {
 		return cliwizard.RunInteractive(root)
 	case ModeBatch:
 		return cliwizard.RunNonInteractive(root, cliwizard.NonInteractiveOptions{
-			Services: options.Automation.Services,
-			Language: options.Automation.Language,
-			Write:    options.Automation.Write,
-			DryRun:   options.Automation.DryRun,
+			Services:        options.Automation.Services,
+			Language:        options.Automation.Language,
+			Write:           options.Automation.Write,
+			DryRun:          options.Automation.DryRun,
+			Diff:            options.Automation.Diff,
+			ComposeOverride: options.Automation.ComposeOverride,
+			Set:             options.Automation.Set,
+			Profiles:        options.Automation.Profiles,
+			App:             options.Automation.App,
+			ExcludeApps:     options.Automation.ExcludeApps,
 		})
 	default:
 		return fmt.Errorf("unsupported mode: %s", mode)
@@ -92,6 +111,16 @@ func RunAdd(options AddOptions) error {
 	return cliwizard.RunAdd(root, options)
 }
 
+type RemoveOptions = cliwizard.RemoveOptions
+
+func RunRemove(options RemoveOptions) error {
+	root, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get working directory: %w", err)
+	}
+	return cliwizard.RunRemove(root, options)
+}
+
 func RunList() error {
 	root, err := os.Getwd()
 	if err != nil {
@@ -13,6 +13,7 @@ import (
 type AddOptions struct {
 	Services []string
 	Write    bool
+	Diff     bool
 }
 
 func RunAdd(root string, options AddOptions) error {
@@ -42,9 +43,11 @@ func RunAdd(root string, options AddOptions) error {
 
 	// parse existing compose file to detect already-present services
 	composePath := filepath.Join(root, generator.ComposeFileName)
+	var existingCompose string
 	var existing map[string]bool
 	if data, err := os.ReadFile(composePath); err == nil {
-		existing, err = generator.ExistingComposeServices(string(data))
+		existingCompose = string(data)
+		existing, err = generator.ExistingComposeServices(existingCompose)
 		if err != nil {
 			return fmt.Errorf("parse existing compose: %w", err)
 		}
@@ -70,8 +73,12 @@ func RunAdd(root string, options AddOptions) error {
 		return nil
 	}
 
+	selection, err := addSelection(root, toAdd)
+	if err != nil {
+		return err
+	}
 	// generate compose fragment (handles dependency expansion internally)
-	composeContent, expanded, err := generator.ComposeFragment(root, toAdd)
+	composeContent, expanded, err := generator.ComposeFragment(root, selection, existingCompose)
 	if err != nil {
 		return err
 	}
@@ -89,7 +96,21 @@ func RunAdd(root string, options AddOptions) error {
 			return err
 		}
 		fmt.Printf("docker-compose.yml: %s (dry-run)\n", previewStatusLabel(preview.Status))
-		if preview.Content != "" {
+		support, err := generator.PreviewSupportFiles(root, composeContent)
+		if err != nil {
+			return err
+		}
+		if support.Env.Path != "" {
+			fmt.Printf(".env: %s (dry-run)\n", envPreviewStatusLabel(support.Env.Status))
+			fmt.Printf(".env.example: %s (dry-run)\n", envPreviewStatusLabel(support.EnvExample.Status))
+		}
+		for _, secret := range support.Secrets {
+			fmt.Printf("%s: %s (dry-run)\n", secretFileLabel(secret.Path), previewStatusLabel(secret.Status))
+		}
+		printConflicts(generator.ConflictWarnings(generator.ComposeFileName, preview.Conflicts))
+		if options.Diff {
+			fmt.Print(preview.Diff())
+		} else if preview.Content != "" {
 			fmt.Println("---")
 			fmt.Print(preview.Content)
 			fmt.Println("---")
@@ -99,15 +120,66 @@ func RunAdd(root string, options AddOptions) error {
 	}
 
 	// write mode
-	status, backup, err := generator.WriteComposeFile(root, composeContent)
+	if options.Diff {
+		preview, err := generator.PreviewComposeFile(root, composeContent)
+		if err != nil {
+			return err
+		}
+		fmt.Print(preview.Diff())
+	}
+	// support files first, so .env can read the compose file before the merge
+	supportOutput, err := generator.WriteSupportFiles(root, composeContent)
+	if err != nil {
+		return err
+	}
+	status, backup, conflicts, err := generator.WriteComposeFile(root, composeContent)
 	if err != nil {
 		return err
 	}
 
 	fmt.Printf("docker-compose.yml: %s\n", status)
+	if supportOutput.EnvPath != "" {
+		fmt.Printf(".env: %s\n", supportOutput.EnvStatus)
+		fmt.Printf(".env.example: %s\n", supportOutput.EnvExampleStatus)
+	}
+	for _, secret := range supportOutput.Secrets {
+		fmt.Printf("%s: %s\n", secretFileLabel(secret.Path), secret.Status)
+	}
+	if supportOutput.GitignorePath != "" {
+		fmt.Printf(".gitignore: %s\n", supportOutput.GitignoreStatus)
+	}
+	if supportOutput.DockerignorePath != "" {
+		fmt.Printf(".dockerignore: %s\n", supportOutput.DockerignoreStatus)
+	}
+	printConflicts(generator.ConflictWarnings(generator.ComposeFileName, conflicts))
 	if backup != "" {
 		fmt.Printf("backup: %s\n", filepath.Base(backup))
 	}
 
 	return nil
 }
+
+// addSelection selects the services to add with the overrides and app
+// settings of the project config, and the apps of a monorepo, so the
+// fragment matches what a full run generates.
+func addSelection(root string, services []string) (generator.ComposeSelection, error) {
+	project, _, err := generator.LoadProjectConfig(root)
+	if err != nil {
+		return generator.ComposeSelection{}, err
+	}
+	selection := generator.ComposeSelection{Services: services, App: project.App, Overrides: project.Overrides}
+	apps, err := detectApps(root, project, nil)
+	if err != nil {
+		return generator.ComposeSelection{}, err
+	}
+	for _, app := range apps {
+		selection.Apps = append(selection.Apps, generator.AppService{App: generator.AppSettings{Name: app.Name}})
+	}
+	return selection, nil
+}
+
+func printConflicts(warnings []string) {
+	for _, warning := range warnings {
+		fmt.Printf("conflict: %s\n", warning)
+	}
+}
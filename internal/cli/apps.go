@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"docker-wizard/internal/generator"
+)
+
+// detectApps returns the apps of a monorepo without the excluded ones, or
+// none for a single-app project. Excluding every app is an error rather than
+// a fallback to building the root.
+func detectApps(root string, project generator.ProjectConfig, excluded []string) ([]generator.App, error) {
+	detected, err := generator.DetectApps(root, project.Workspaces)
+	if err != nil {
+		return nil, err
+	}
+	excluded = append(append([]string(nil), project.ExcludeApps...), excluded...)
+	apps := generator.IncludedApps(detected, excluded)
+	if len(detected) > 0 && len(apps) == 0 {
+		return nil, fmt.Errorf("all %d apps are excluded", len(detected))
+	}
+	return apps, nil
+}
+
+func printApps(apps []generator.App) {
+	fmt.Println("- apps:")
+	for _, app := range apps {
+		fmt.Printf("  - %s\n", appLabel(app))
+	}
+}
+
+// appLabel describes an app, for example "web (apps/web): Node 20, port 3000".
+func appLabel(app generator.App) string {
+	label := fmt.Sprintf("%s (%s): %s", app.Name, app.Dir, languageLabelWithVersion(app.Details))
+	if app.Details.AppPort != "" {
+		label += ", port " + app.Details.AppPort
+	}
+	return label
+}
+
+// backupLabels names the backups a write made, relative to the project
+// root.
+func backupLabels(output generator.Output) []string {
+	var labels []string
+	for _, path := range []string{output.ComposeBackupPath, output.ComposeOverrideBackupPath, output.DockerfileBackupPath} {
+		if path != "" {
+			labels = append(labels, filepath.Base(path))
+		}
+	}
+	for _, app := range output.Apps {
+		if app.DockerfileBackupPath != "" {
+			labels = append(labels, generator.AppFileName(app.Dir, generator.DockerfileFileName)+strings.TrimPrefix(app.DockerfileBackupPath, app.DockerfilePath))
+		}
+	}
+	return labels
+}
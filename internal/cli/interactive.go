@@ -28,20 +28,38 @@ func RunInteractive(root string) error {
 
 	reader := bufio.NewReader(os.Stdin)
 
+	project, _, err := generator.LoadProjectConfig(root)
+	if err != nil {
+		return err
+	}
+
 	fmt.Println("Docker Wizard (CLI interactive mode)")
 	fmt.Println()
 
-	details, err := generator.DetectLanguage(root)
+	apps, err := detectApps(root, project, nil)
 	if err != nil {
 		return err
 	}
 
-	overrideType, overrideLang, err := promptLanguage(reader, details)
-	if err != nil {
-		return err
+	var details generator.LanguageDetails
+	var overrideType generator.Language
+	var overrideLang bool
+	if len(apps) > 0 {
+		printApps(apps)
+	} else {
+		if details, err = generator.DetectLanguage(root); err != nil {
+			return err
+		}
+		if overrideType, overrideLang, err = promptLanguage(reader, details); err != nil {
+			return err
+		}
+		if overrideLang {
+			details = generator.OverrideLanguage(root, details, overrideType)
+		}
 	}
-	if overrideLang {
-		details.Type = overrideType
+	app := project.App
+	if app.Port == "" {
+		app.Port = details.AppPort
 	}
 
 	services, err := generator.SelectableServices(root)
@@ -55,35 +73,79 @@ func RunInteractive(root string) error {
 	}
 	selectedIDs := orderedSelectedIDs(services, selected)
 
-	selection := generator.ComposeSelection{Services: selectedIDs}
+	selection := generator.ComposeSelection{
+		Services:      selectedIDs,
+		Overrides:     project.Overrides,
+		SplitOverride: project.ComposeOverride,
+	}
+	var dockerfileContent string
+	var appFiles []generator.AppFile
+	if len(apps) > 0 {
+		if appFiles, selection.Apps, err = generator.RenderApps(root, apps, app); err != nil {
+			return err
+		}
+	} else {
+		selection.App = app
+		if dockerfileContent, err = generator.DockerfileWithApp(root, details, app); err != nil {
+			return err
+		}
+		if selection.Watch, err = generator.WatchRules(root, details); err != nil {
+			return err
+		}
+		if selection.DevTarget, err = generator.MergedDevTarget(root, "", dockerfileContent); err != nil {
+			return err
+		}
+	}
 	warnings, err := generator.SelectionWarnings(root, selection)
 	if err != nil {
 		return err
 	}
 
-	dockerfileContent, err := generator.Dockerfile(root, details)
+	composeContent, overrideContent, err := generator.ComposeFiles(root, selection)
 	if err != nil {
 		return err
 	}
-	composeContent, err := generator.Compose(root, selection)
-	if err != nil {
-		return err
+	var preview generator.Preview
+	if len(appFiles) > 0 {
+		preview, err = generator.PreviewAppFiles(root, composeContent, overrideContent, appFiles)
+	} else {
+		preview, err = generator.PreviewFilesWithComposeOverride(root, composeContent, overrideContent, dockerfileContent)
 	}
-	preview, err := generator.PreviewFiles(root, composeContent, dockerfileContent)
 	if err != nil {
 		return err
 	}
+	warnings = append(warnings, preview.ConflictWarnings()...)
 
 	fmt.Println()
 	fmt.Println("Review")
-	fmt.Printf("- language: %s\n", languageLabelWithVersion(details))
+	if len(apps) == 0 {
+		fmt.Printf("- language: %s\n", languageLabelWithVersion(details))
+	}
 	printSelectedSummary(services, selected)
 
 	fmt.Println("- managed files:")
 	fmt.Printf("  - docker-compose.yml (%s)\n", previewStatusLabel(preview.Compose.Status))
-	fmt.Printf("  - Dockerfile (%s)\n", previewStatusLabel(preview.Dockerfile.Status))
-	if preview.Dockerignore.Status == generator.FileStatusNew {
-		fmt.Printf("  - .dockerignore (%s)\n", previewStatusLabel(preview.Dockerignore.Status))
+	if preview.ComposeOverride.Path != "" {
+		fmt.Printf("  - %s (%s)\n", generator.ComposeOverrideFileName, previewStatusLabel(preview.ComposeOverride.Status))
+	}
+	if len(preview.Apps) == 0 {
+		fmt.Printf("  - Dockerfile (%s)\n", previewStatusLabel(preview.Dockerfile.Status))
+		if preview.Dockerignore.Status == generator.FileStatusNew {
+			fmt.Printf("  - .dockerignore (%s)\n", previewStatusLabel(preview.Dockerignore.Status))
+		}
+	}
+	for _, app := range preview.Apps {
+		fmt.Printf("  - %s (%s)\n", generator.AppFileName(app.Dir, generator.DockerfileFileName), previewStatusLabel(app.Dockerfile.Status))
+		if app.Dockerignore.Status == generator.FileStatusNew {
+			fmt.Printf("  - %s (%s)\n", generator.AppFileName(app.Dir, generator.DockerignoreFileName), previewStatusLabel(app.Dockerignore.Status))
+		}
+	}
+	if preview.Env.Path != "" {
+		fmt.Printf("  - .env (%s)\n", envPreviewStatusLabel(preview.Env.Status))
+		fmt.Printf("  - .env.example (%s)\n", envPreviewStatusLabel(preview.EnvExample.Status))
+	}
+	for _, secret := range preview.Secrets {
+		fmt.Printf("  - %s (%s)\n", secretFileLabel(secret.Path), previewStatusLabel(secret.Status))
 	}
 
 	if len(warnings) > 0 {
@@ -103,7 +165,12 @@ func RunInteractive(root string) error {
 		return nil
 	}
 
-	output, err := generator.WriteFiles(root, composeContent, dockerfileContent)
+	var output generator.Output
+	if len(appFiles) > 0 {
+		output, err = generator.WriteAppFiles(root, composeContent, overrideContent, appFiles)
+	} else {
+		output, err = generator.WriteFilesWithComposeOverride(root, composeContent, overrideContent, dockerfileContent)
+	}
 	if err != nil {
 		return err
 	}
@@ -111,20 +178,53 @@ func RunInteractive(root string) error {
 	fmt.Println()
 	fmt.Println("Done")
 	fmt.Printf("- docker-compose.yml: %s\n", output.ComposeStatus)
-	fmt.Printf("- Dockerfile: %s\n", output.DockerfileStatus)
-	fmt.Printf("- .dockerignore: %s\n", output.DockerignoreStatus)
-	if output.ComposeBackupPath != "" || output.DockerfileBackupPath != "" {
+	if output.ComposeOverridePath != "" {
+		fmt.Printf("- %s: %s\n", generator.ComposeOverrideFileName, output.ComposeOverrideStatus)
+	}
+	if len(output.Apps) == 0 {
+		fmt.Printf("- Dockerfile: %s\n", output.DockerfileStatus)
+		fmt.Printf("- .dockerignore: %s\n", output.DockerignoreStatus)
+	}
+	for _, app := range output.Apps {
+		fmt.Printf("- %s: %s\n", generator.AppFileName(app.Dir, generator.DockerfileFileName), app.DockerfileStatus)
+		fmt.Printf("- %s: %s\n", generator.AppFileName(app.Dir, generator.DockerignoreFileName), app.DockerignoreStatus)
+	}
+	if output.EnvPath != "" {
+		fmt.Printf("- .env: %s\n", output.EnvStatus)
+		fmt.Printf("- .env.example: %s\n", output.EnvExampleStatus)
+	}
+	for _, secret := range output.Secrets {
+		fmt.Printf("- %s: %s\n", secretFileLabel(secret.Path), secret.Status)
+	}
+	if output.GitignorePath != "" {
+		fmt.Printf("- .gitignore: %s\n", output.GitignoreStatus)
+	}
+	if backups := backupLabels(output); len(backups) > 0 {
 		fmt.Println("- backups:")
-		if output.ComposeBackupPath != "" {
-			fmt.Printf("  - %s\n", filepath.Base(output.ComposeBackupPath))
-		}
-		if output.DockerfileBackupPath != "" {
-			fmt.Printf("  - %s\n", filepath.Base(output.DockerfileBackupPath))
+		for _, backup := range backups {
+			fmt.Printf("  - %s\n", backup)
 		}
 	}
 
 	fmt.Println("- next: docker compose up")
 
+	save, err := promptYesNo(reader, "\nSave this selection to the project config file? [y/N]: ")
+	if err != nil {
+		return err
+	}
+	if save {
+		project.Services = selectedIDs
+		project.Language = ""
+		if overrideLang {
+			project.Language = string(overrideType)
+		}
+		path, err := generator.SaveProjectConfig(root, project)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("- saved: %s\n", filepath.Base(path))
+	}
+
 	return nil
 }
 
@@ -133,11 +233,15 @@ func promptLanguage(reader *bufio.Reader, details generator.LanguageDetails) (ge
 		{Label: "Auto-detect", Auto: true},
 		{Label: "Go", Language: generator.LanguageGo},
 		{Label: "Node", Language: generator.LanguageNode},
+		{Label: "Bun", Language: generator.LanguageBun},
+		{Label: "Deno", Language: generator.LanguageDeno},
 		{Label: "Python", Language: generator.LanguagePython},
 		{Label: "Ruby", Language: generator.LanguageRuby},
 		{Label: "PHP", Language: generator.LanguagePHP},
 		{Label: "Java", Language: generator.LanguageJava},
 		{Label: ".NET", Language: generator.LanguageDotNet},
+		{Label: "Rust", Language: generator.LanguageRust},
+		{Label: "Elixir", Language: generator.LanguageElixir},
 	}
 
 	autoLabel := options[0].Label
@@ -148,6 +252,9 @@ func promptLanguage(reader *bufio.Reader, details generator.LanguageDetails) (ge
 	options[0].Label = autoLabel
 
 	fmt.Printf("Detected language: %s\n", languageLabelWithVersion(details))
+	if details.AppPort != "" {
+		fmt.Printf("Detected app port: %s\n", details.AppPort)
+	}
 	fmt.Println("Choose language:")
 	for i, option := range options {
 		fmt.Printf("  %d) %s\n", i, option.Label)
@@ -336,14 +443,38 @@ func previewStatusLabel(status generator.FileStatus) string {
 	}
 }
 
+// secretFileLabel names a secret file relative to the project root.
+func secretFileLabel(path string) string {
+	return filepath.Join(generator.SecretsDirName, filepath.Base(path))
+}
+
+// envPreviewStatusLabel describes the preview status of .env and
+// .env.example, which only ever get variables appended.
+func envPreviewStatusLabel(status generator.FileStatus) string {
+	if status == generator.FileStatusDifferent {
+		return "missing variables will be added"
+	}
+	return previewStatusLabel(status)
+}
+
+// languageLabelWithVersion describes the language, for example "Node 20", with
+// the detected framework appended, as in "Node 20, Next.js".
 func languageLabelWithVersion(details generator.LanguageDetails) string {
-	return utils.LanguageLabelWithVersion(string(details.Type), utils.LanguageVersions{
+	label := utils.LanguageLabelWithVersion(string(details.Type), utils.LanguageVersions{
 		Go:     details.GoVersion,
 		Node:   details.NodeVersion,
+		Bun:    details.BunVersion,
+		Deno:   details.DenoVersion,
 		Python: details.PythonVersion,
 		Ruby:   details.RubyVersion,
 		PHP:    details.PHPVersion,
 		Java:   details.JavaVersion,
 		DotNet: details.DotNetVersion,
+		Rust:   details.RustVersion,
+		Elixir: details.ElixirVersion,
 	})
+	if framework := details.ActiveFramework(); framework != "" {
+		label += ", " + utils.FrameworkLabel(string(framework))
+	}
+	return label
 }
@@ -14,6 +14,21 @@ type NonInteractiveOptions struct {
 	Language string
 	Write    bool
 	DryRun   bool
+	Diff     bool
+	// Set holds "service.field=value" overrides layered over the project
+	// config file.
+	Set []string
+	// Profiles holds "service=profile" assignments added to the profiles of
+	// the project config file.
+	Profiles []string
+	// App holds app service settings layered over the project config file.
+	App generator.AppSettings
+	// ComposeOverride splits local development settings into
+	// docker-compose.override.yml, like the project config key.
+	ComposeOverride bool
+	// ExcludeApps names monorepo apps left out of the generated files, in
+	// addition to the excludeApps of the project config file.
+	ExcludeApps []string
 }
 
 func RunNonInteractive(root string, options NonInteractiveOptions) error {
@@ -26,6 +41,23 @@ func RunNonInteractive(root string, options NonInteractiveOptions) error {
 
 	dryRun := options.DryRun || !options.Write
 
+	// flags take precedence over the project config file
+	project, projectPath, err := generator.LoadProjectConfig(root)
+	if err != nil {
+		return err
+	}
+	if len(options.Services) == 0 {
+		options.Services = project.Services
+	}
+	if strings.TrimSpace(options.Language) == "" {
+		options.Language = project.Language
+	}
+	overrides, err := layerOverrides(project.Overrides, options.Set, options.Profiles)
+	if err != nil {
+		return err
+	}
+	app := generator.MergeAppSettings(project.App, options.App)
+
 	overrideType, overrideLang, err := parseLanguageOption(options.Language)
 	if err != nil {
 		return err
@@ -36,36 +68,85 @@ func RunNonInteractive(root string, options NonInteractiveOptions) error {
 		return err
 	}
 
-	details, err := generator.DetectLanguage(root)
+	apps, err := detectApps(root, project, options.ExcludeApps)
 	if err != nil {
 		return err
 	}
-	if overrideLang {
-		details.Type = overrideType
+
+	selection := generator.ComposeSelection{
+		Services:      selectedServices,
+		Overrides:     overrides,
+		SplitOverride: options.ComposeOverride || project.ComposeOverride,
+	}
+	var details generator.LanguageDetails
+	var dockerfileContent string
+	var appFiles []generator.AppFile
+	if len(apps) > 0 {
+		if appFiles, selection.Apps, err = generator.RenderApps(root, apps, app); err != nil {
+			return err
+		}
+	} else {
+		if details, err = generator.DetectLanguage(root); err != nil {
+			return err
+		}
+		if overrideLang {
+			details = generator.OverrideLanguage(root, details, overrideType)
+		}
+		if app.Port == "" {
+			app.Port = details.AppPort
+		}
+		selection.App = app
+		if dockerfileContent, err = generator.DockerfileWithApp(root, details, app); err != nil {
+			return err
+		}
+		if selection.Watch, err = generator.WatchRules(root, details); err != nil {
+			return err
+		}
+		if selection.DevTarget, err = generator.MergedDevTarget(root, "", dockerfileContent); err != nil {
+			return err
+		}
 	}
 
-	selection := generator.ComposeSelection{Services: selectedServices}
 	warnings, err := generator.SelectionWarnings(root, selection)
 	if err != nil {
 		return err
 	}
-
-	dockerfileContent, err := generator.Dockerfile(root, details)
-	if err != nil {
-		return err
+	if len(apps) > 0 {
+		if overrideLang {
+			warnings = append(warnings, "--language is ignored for the apps of a monorepo; each app uses its detected language")
+		}
+		warnings = append(warnings, ignoredAppSettings(options.App, project.App, filepath.Base(projectPath))...)
 	}
-	composeContent, err := generator.Compose(root, selection)
+
+	composeContent, overrideContent, err := generator.ComposeFiles(root, selection)
 	if err != nil {
 		return err
 	}
 
-	preview, err := generator.PreviewFiles(root, composeContent, dockerfileContent)
+	var preview generator.Preview
+	if len(appFiles) > 0 {
+		preview, err = generator.PreviewAppFiles(root, composeContent, overrideContent, appFiles)
+	} else {
+		preview, err = generator.PreviewFilesWithComposeOverride(root, composeContent, overrideContent, dockerfileContent)
+	}
 	if err != nil {
 		return err
 	}
 
+	warnings = append(warnings, preview.ConflictWarnings()...)
+
 	fmt.Println("Docker Wizard (batch mode)")
-	fmt.Printf("- language: %s\n", languageLabelWithVersion(details))
+	if projectPath != "" {
+		fmt.Printf("- config: %s\n", filepath.Base(projectPath))
+	}
+	if len(apps) > 0 {
+		printApps(apps)
+	} else {
+		fmt.Printf("- language: %s\n", languageLabelWithVersion(details))
+		if details.AppPort != "" {
+			fmt.Printf("- detected app port: %s\n", details.AppPort)
+		}
+	}
 	fmt.Printf("- selected services: %s\n", serviceSelectionLabel(selectedServices))
 
 	if len(warnings) > 0 {
@@ -76,31 +157,72 @@ func RunNonInteractive(root string, options NonInteractiveOptions) error {
 		}
 	}
 
+	if options.Diff {
+		printPreviewDiffs(preview)
+	}
+
 	if dryRun {
 		fmt.Println("- managed files:")
 		fmt.Printf("  - docker-compose.yml (%s)\n", previewStatusLabel(preview.Compose.Status))
-		fmt.Printf("  - Dockerfile (%s)\n", previewStatusLabel(preview.Dockerfile.Status))
-		fmt.Printf("  - .dockerignore (%s)\n", previewStatusLabel(preview.Dockerignore.Status))
+		if preview.ComposeOverride.Path != "" {
+			fmt.Printf("  - %s (%s)\n", generator.ComposeOverrideFileName, previewStatusLabel(preview.ComposeOverride.Status))
+		}
+		if len(preview.Apps) == 0 {
+			fmt.Printf("  - Dockerfile (%s)\n", previewStatusLabel(preview.Dockerfile.Status))
+			fmt.Printf("  - .dockerignore (%s)\n", previewStatusLabel(preview.Dockerignore.Status))
+		}
+		for _, app := range preview.Apps {
+			fmt.Printf("  - %s (%s)\n", generator.AppFileName(app.Dir, generator.DockerfileFileName), previewStatusLabel(app.Dockerfile.Status))
+			fmt.Printf("  - %s (%s)\n", generator.AppFileName(app.Dir, generator.DockerignoreFileName), previewStatusLabel(app.Dockerignore.Status))
+		}
+		if preview.Env.Path != "" {
+			fmt.Printf("  - .env (%s)\n", envPreviewStatusLabel(preview.Env.Status))
+			fmt.Printf("  - .env.example (%s)\n", envPreviewStatusLabel(preview.EnvExample.Status))
+		}
+		for _, secret := range preview.Secrets {
+			fmt.Printf("  - %s (%s)\n", secretFileLabel(secret.Path), previewStatusLabel(secret.Status))
+		}
 		fmt.Println("- result: dry-run (no files were written)")
 		return nil
 	}
 
-	output, err := generator.WriteFiles(root, composeContent, dockerfileContent)
+	var output generator.Output
+	if len(appFiles) > 0 {
+		output, err = generator.WriteAppFiles(root, composeContent, overrideContent, appFiles)
+	} else {
+		output, err = generator.WriteFilesWithComposeOverride(root, composeContent, overrideContent, dockerfileContent)
+	}
 	if err != nil {
 		return err
 	}
 
 	fmt.Println("- result:")
 	fmt.Printf("  - docker-compose.yml: %s\n", output.ComposeStatus)
-	fmt.Printf("  - Dockerfile: %s\n", output.DockerfileStatus)
-	fmt.Printf("  - .dockerignore: %s\n", output.DockerignoreStatus)
-	if output.ComposeBackupPath != "" || output.DockerfileBackupPath != "" {
+	if output.ComposeOverridePath != "" {
+		fmt.Printf("  - %s: %s\n", generator.ComposeOverrideFileName, output.ComposeOverrideStatus)
+	}
+	if len(output.Apps) == 0 {
+		fmt.Printf("  - Dockerfile: %s\n", output.DockerfileStatus)
+		fmt.Printf("  - .dockerignore: %s\n", output.DockerignoreStatus)
+	}
+	for _, app := range output.Apps {
+		fmt.Printf("  - %s: %s\n", generator.AppFileName(app.Dir, generator.DockerfileFileName), app.DockerfileStatus)
+		fmt.Printf("  - %s: %s\n", generator.AppFileName(app.Dir, generator.DockerignoreFileName), app.DockerignoreStatus)
+	}
+	if output.EnvPath != "" {
+		fmt.Printf("  - .env: %s\n", output.EnvStatus)
+		fmt.Printf("  - .env.example: %s\n", output.EnvExampleStatus)
+	}
+	for _, secret := range output.Secrets {
+		fmt.Printf("  - %s: %s\n", secretFileLabel(secret.Path), secret.Status)
+	}
+	if output.GitignorePath != "" {
+		fmt.Printf("  - .gitignore: %s\n", output.GitignoreStatus)
+	}
+	if backups := backupLabels(output); len(backups) > 0 {
 		fmt.Println("  - backups:")
-		if output.ComposeBackupPath != "" {
-			fmt.Printf("    - %s\n", filepath.Base(output.ComposeBackupPath))
-		}
-		if output.DockerfileBackupPath != "" {
-			fmt.Printf("    - %s\n", filepath.Base(output.DockerfileBackupPath))
+		for _, backup := range backups {
+			fmt.Printf("    - %s\n", backup)
 		}
 	}
 	fmt.Println("- next: docker compose up")
@@ -108,6 +230,76 @@ func RunNonInteractive(root string, options NonInteractiveOptions) error {
 	return nil
 }
 
+// printPreviewDiffs prints a unified diff for every managed file that would
+// change. .env is left out so existing secrets are not printed; .env.example
+// shows the same variables.
+func printPreviewDiffs(preview generator.Preview) {
+	diffs := []string{preview.Compose.Diff(), preview.ComposeOverride.Diff(), preview.Dockerfile.Diff(), preview.Dockerignore.Diff()}
+	for _, app := range preview.Apps {
+		diffs = append(diffs,
+			app.Dockerfile.DiffAs(generator.AppFileName(app.Dir, generator.DockerfileFileName)),
+			app.Dockerignore.DiffAs(generator.AppFileName(app.Dir, generator.DockerignoreFileName)))
+	}
+	diffs = append(diffs, preview.EnvExample.Diff())
+	printed := false
+	for _, diff := range diffs {
+		if diff == "" {
+			continue
+		}
+		if !printed {
+			fmt.Println("- diff:")
+			printed = true
+		}
+		fmt.Print(diff)
+	}
+	if !printed {
+		fmt.Println("- diff: no changes")
+	}
+}
+
+// ignoredAppSettings warns about the app name, port and command set by flags
+// or by the project config file, which only fit a single app and are ignored
+// for the apps of a monorepo.
+func ignoredAppSettings(flags generator.AppSettings, config generator.AppSettings, configName string) []string {
+	settings := []struct {
+		flag, key, flagValue, configValue, instead string
+	}{
+		{"--app-name", "app.name", flags.Name, config.Name, "each app is named after its directory"},
+		{"--app-port", "app.port", flags.Port, config.Port, "each app uses its detected port"},
+		{"--app-command", "app.command", flags.Command, config.Command, "each app uses its detected start command"},
+	}
+	var warnings []string
+	for _, setting := range settings {
+		switch {
+		case setting.flagValue != "":
+			warnings = append(warnings, fmt.Sprintf("%s is ignored for the apps of a monorepo; %s", setting.flag, setting.instead))
+		case setting.configValue != "":
+			warnings = append(warnings, fmt.Sprintf("%s in %s is ignored for the apps of a monorepo; %s", setting.key, configName, setting.instead))
+		}
+	}
+	return warnings
+}
+
+// layerOverrides applies --set and --profile assignments on top of a copy of
+// the project config overrides.
+func layerOverrides(base map[string]generator.ServiceOverride, assignments []string, profiles []string) (map[string]generator.ServiceOverride, error) {
+	overrides := make(map[string]generator.ServiceOverride, len(base))
+	for id, override := range base {
+		overrides[id] = override
+	}
+	for _, assignment := range assignments {
+		if err := generator.SetOverride(overrides, assignment); err != nil {
+			return nil, fmt.Errorf("--set: %w", err)
+		}
+	}
+	for _, assignment := range profiles {
+		if err := generator.SetProfile(overrides, assignment); err != nil {
+			return nil, fmt.Errorf("--profile: %w", err)
+		}
+	}
+	return overrides, nil
+}
+
 func parseLanguageOption(value string) (generator.Language, bool, error) {
 	switch strings.ToLower(strings.TrimSpace(value)) {
 	case "", "auto":
@@ -116,6 +308,10 @@ func parseLanguageOption(value string) (generator.Language, bool, error) {
 		return generator.LanguageGo, true, nil
 	case "node":
 		return generator.LanguageNode, true, nil
+	case "bun":
+		return generator.LanguageBun, true, nil
+	case "deno":
+		return generator.LanguageDeno, true, nil
 	case "python":
 		return generator.LanguagePython, true, nil
 	case "ruby":
@@ -126,10 +322,14 @@ func parseLanguageOption(value string) (generator.Language, bool, error) {
 		return generator.LanguageJava, true, nil
 	case "dotnet", ".net":
 		return generator.LanguageDotNet, true, nil
+	case "rust":
+		return generator.LanguageRust, true, nil
+	case "elixir":
+		return generator.LanguageElixir, true, nil
 	case "unknown":
 		return generator.LanguageUnknown, true, nil
 	default:
-		return "", false, fmt.Errorf("invalid language %q (expected auto, go, node, python, ruby, php, java, dotnet)", value)
+		return "", false, fmt.Errorf("invalid language %q (expected auto, go, node, bun, deno, python, ruby, php, java, dotnet, rust, elixir)", value)
 	}
 }
 
@@ -3,6 +3,7 @@ package cli
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"docker-wizard/internal/generator"
@@ -20,7 +21,8 @@ func TestParseLanguageOption(t *testing.T) {
 		{name: "auto explicit", input: "auto", wantSet: false},
 		{name: "go", input: "go", want: generator.LanguageGo, wantSet: true},
 		{name: "dotnet alias", input: ".NET", want: generator.LanguageDotNet, wantSet: true},
-		{name: "invalid", input: "rust", wantError: true},
+		{name: "rust", input: "Rust", want: generator.LanguageRust, wantSet: true},
+		{name: "invalid", input: "cobol", wantError: true},
 	}
 
 	for _, tt := range tests {
@@ -89,6 +91,52 @@ func TestResolveServices(t *testing.T) {
 	})
 }
 
+func TestLayerOverrides(t *testing.T) {
+	base := map[string]generator.ServiceOverride{
+		"mysql": {Image: "mysql:8.4", Ports: []string{"3307:3306"}},
+	}
+
+	got, err := layerOverrides(base, []string{"mysql.ports=3308:3306", "redis.image=redis:7.2"}, []string{"redis=debug"})
+	if err != nil {
+		t.Fatalf("layer overrides: %v", err)
+	}
+	if got["mysql"].Image != "mysql:8.4" || got["mysql"].Ports[0] != "3308:3306" {
+		t.Fatalf("expected --set to replace only ports, got %+v", got["mysql"])
+	}
+	if got["redis"].Image != "redis:7.2" || strings.Join(got["redis"].Profiles, ",") != "debug" {
+		t.Fatalf("expected redis override, got %+v", got["redis"])
+	}
+	if base["mysql"].Ports[0] != "3307:3306" {
+		t.Fatalf("expected project overrides to be left untouched, got %+v", base["mysql"])
+	}
+
+	if _, err := layerOverrides(base, []string{"mysql.tag=8"}, nil); err == nil {
+		t.Fatal("expected error for unknown field")
+	}
+	if _, err := layerOverrides(base, nil, []string{"mysql"}); err == nil {
+		t.Fatal("expected error for a profile without name")
+	}
+}
+
+func TestIgnoredAppSettings(t *testing.T) {
+	flags := generator.AppSettings{Name: "api", Command: "npm run serve", Env: []string{"DEBUG=1"}}
+	config := generator.AppSettings{Name: "web", Port: "3000"}
+
+	got := ignoredAppSettings(flags, config, ".docker-wizard.yml")
+	want := []string{
+		"--app-name is ignored for the apps of a monorepo; each app is named after its directory",
+		"app.port in .docker-wizard.yml is ignored for the apps of a monorepo; each app uses its detected port",
+		"--app-command is ignored for the apps of a monorepo; each app uses its detected start command",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("expected warnings:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+
+	if got := ignoredAppSettings(generator.AppSettings{Env: []string{"DEBUG=1"}, Target: "runtime"}, generator.AppSettings{}, ""); len(got) != 0 {
+		t.Fatalf("expected no warnings for settings that apply to every app, got %v", got)
+	}
+}
+
 func writeServicesCatalog(t *testing.T, root string) {
 	t.Helper()
 	configDir := filepath.Join(root, "config")
@@ -129,3 +177,42 @@ func writeServicesCatalog(t *testing.T, root string) {
 		t.Fatalf("write services catalog: %v", err)
 	}
 }
+
+func TestRunNonInteractiveSkipsDevTargetMissingFromMergedDockerfile(t *testing.T) {
+	root := t.TempDir()
+	writeServicesCatalog(t, root)
+	catalogData, err := os.ReadFile(filepath.Join("..", "..", "config", "dockerfiles.json"))
+	if err != nil {
+		t.Fatalf("read default dockerfile catalog: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "config", "dockerfiles.json"), catalogData, 0o644); err != nil {
+		t.Fatalf("write dockerfile catalog: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/app\n\ngo 1.22\n"), 0o644); err != nil {
+		t.Fatalf("write go.mod: %v", err)
+	}
+	// a hand-written Dockerfile without a dev stage and no recorded state
+	handWritten := "FROM golang:1.22\nWORKDIR /app\nCOPY . .\nCMD [\"go\", \"run\", \".\"]\n"
+	if err := os.WriteFile(filepath.Join(root, "Dockerfile"), []byte(handWritten), 0o644); err != nil {
+		t.Fatalf("write Dockerfile: %v", err)
+	}
+
+	if err := RunNonInteractive(root, NonInteractiveOptions{Services: []string{"redis"}, Write: true, ComposeOverride: true}); err != nil {
+		t.Fatalf("RunNonInteractive: %v", err)
+	}
+
+	dockerfile, err := os.ReadFile(filepath.Join(root, "Dockerfile"))
+	if err != nil {
+		t.Fatalf("read Dockerfile: %v", err)
+	}
+	if strings.Contains(string(dockerfile), " AS dev") {
+		t.Fatalf("expected no dev stage to be inserted, got:\n%s", dockerfile)
+	}
+	override, err := os.ReadFile(filepath.Join(root, "docker-compose.override.yml"))
+	if err != nil {
+		t.Fatalf("read override: %v", err)
+	}
+	if strings.Contains(string(override), "target:") {
+		t.Fatalf("expected no build target the Dockerfile lacks, got:\n%s", override)
+	}
+}
@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"docker-wizard/internal/generator"
+)
+
+type RemoveOptions struct {
+	Services []string
+	Write    bool
+}
+
+func RunRemove(root string, options RemoveOptions) error {
+	if root == "" {
+		return fmt.Errorf("root directory is required")
+	}
+	if len(options.Services) == 0 {
+		return fmt.Errorf("at least one service ID is required")
+	}
+
+	// validate all service IDs against catalog
+	serviceMap, _, err := generator.CatalogMap(root)
+	if err != nil {
+		return err
+	}
+
+	var unknown []string
+	for _, id := range options.Services {
+		if _, ok := serviceMap[id]; !ok {
+			unknown = append(unknown, id)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown services: %s", strings.Join(unknown, ", "))
+	}
+
+	composePath := filepath.Join(root, generator.ComposeFileName)
+	data, err := os.ReadFile(composePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%s not found", generator.ComposeFileName)
+		}
+		return fmt.Errorf("read %s: %w", generator.ComposeFileName, err)
+	}
+
+	// work out dependent services and volumes that become unused
+	plan, err := generator.PlanRemoval(root, string(data), options.Services)
+	if err != nil {
+		return fmt.Errorf("parse existing compose: %w", err)
+	}
+
+	if len(plan.Missing) > 0 {
+		fmt.Printf("skipping (not present): %s\n", strings.Join(plan.Missing, ", "))
+	}
+	if len(plan.Names) == 0 {
+		fmt.Println("nothing to remove — none of the requested services exist")
+		return nil
+	}
+
+	fmt.Printf("removing services: %s\n", strings.Join(plan.Services, ", "))
+	if len(plan.Dependencies) > 0 {
+		fmt.Printf("auto-removing dependencies: %s\n", strings.Join(plan.Dependencies, ", "))
+	}
+	if len(plan.Volumes) > 0 {
+		fmt.Printf("removing volumes: %s\n", strings.Join(plan.Volumes, ", "))
+	}
+	if len(plan.Secrets) > 0 {
+		fmt.Printf("removing secrets: %s\n", strings.Join(plan.Secrets, ", "))
+	}
+	printServiceRemovals("variables", plan.Env)
+	printServiceRemovals("secrets", plan.ServiceSecrets)
+
+	if !options.Write {
+		// dry-run: preview only
+		preview, err := generator.PreviewComposeRemoval(root, plan)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("docker-compose.yml: %s (dry-run)\n", previewStatusLabel(preview.Status))
+		if preview.Content != "" {
+			fmt.Println("---")
+			fmt.Print(preview.Content)
+			fmt.Println("---")
+		}
+		fmt.Println("pass --write to apply changes")
+		return nil
+	}
+
+	// write mode
+	status, backup, err := generator.WriteComposeRemoval(root, plan)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("docker-compose.yml: %s\n", status)
+	if backup != "" {
+		fmt.Printf("backup: %s\n", filepath.Base(backup))
+	}
+
+	return nil
+}
+
+// printServiceRemovals prints what is removed from each remaining service,
+// sorted by service name.
+func printServiceRemovals(kind string, removals map[string][]string) {
+	names := make([]string, 0, len(removals))
+	for name := range removals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("removing %s %s: %s\n", name, kind, strings.Join(removals[name], ", "))
+	}
+}
@@ -0,0 +1,185 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunRemove(t *testing.T) {
+	t.Run("dry-run leaves compose untouched", func(t *testing.T) {
+		root := t.TempDir()
+		writeRemoveCatalog(t, root)
+		if err := RunAdd(root, AddOptions{Services: []string{"mysql", "analytics"}, Write: true}); err != nil {
+			t.Fatalf("RunAdd: %v", err)
+		}
+		composePath := filepath.Join(root, "docker-compose.yml")
+		before, err := os.ReadFile(composePath)
+		if err != nil {
+			t.Fatalf("read docker-compose.yml: %v", err)
+		}
+
+		if err := RunRemove(root, RemoveOptions{Services: []string{"mysql"}}); err != nil {
+			t.Fatalf("RunRemove: %v", err)
+		}
+
+		after, err := os.ReadFile(composePath)
+		if err != nil {
+			t.Fatalf("read docker-compose.yml: %v", err)
+		}
+		if string(before) != string(after) {
+			t.Fatal("dry-run should not modify docker-compose.yml")
+		}
+	})
+
+	t.Run("removes service, dependencies and unused volumes", func(t *testing.T) {
+		root := t.TempDir()
+		writeRemoveCatalog(t, root)
+		if err := RunAdd(root, AddOptions{Services: []string{"mysql", "analytics"}, Write: true}); err != nil {
+			t.Fatalf("RunAdd: %v", err)
+		}
+
+		if err := RunRemove(root, RemoveOptions{Services: []string{"analytics"}, Write: true}); err != nil {
+			t.Fatalf("RunRemove: %v", err)
+		}
+
+		data, err := os.ReadFile(filepath.Join(root, "docker-compose.yml"))
+		if err != nil {
+			t.Fatalf("read docker-compose.yml: %v", err)
+		}
+		content := string(data)
+		for _, removed := range []string{"  analytics:", "  analytics-db:", "analytics-data"} {
+			if strings.Contains(content, removed) {
+				t.Fatalf("expected %q to be removed, got:\n%s", removed, content)
+			}
+		}
+		for _, kept := range []string{"  mysql:", "  mysql-data:"} {
+			if !strings.Contains(content, kept) {
+				t.Fatalf("expected %q to be kept, got:\n%s", kept, content)
+			}
+		}
+		if _, err := os.Stat(filepath.Join(root, "docker-compose.yml.bak")); err != nil {
+			t.Fatalf("expected backup file: %v", err)
+		}
+
+		// re-adding must not be treated as a user deletion of the service
+		if err := RunAdd(root, AddOptions{Services: []string{"analytics"}, Write: true}); err != nil {
+			t.Fatalf("RunAdd: %v", err)
+		}
+		data, err = os.ReadFile(filepath.Join(root, "docker-compose.yml"))
+		if err != nil {
+			t.Fatalf("read docker-compose.yml: %v", err)
+		}
+		if !strings.Contains(string(data), "  analytics-db:") {
+			t.Fatalf("expected analytics-db to be added back, got:\n%s", data)
+		}
+	})
+
+	t.Run("keeps dependencies other services still use", func(t *testing.T) {
+		root := t.TempDir()
+		writeRemoveCatalog(t, root)
+		composePath := filepath.Join(root, "docker-compose.yml")
+		existing := `services:
+  analytics:
+    image: analytics:latest
+    depends_on:
+      - analytics-db
+  analytics-db:
+    image: clickhouse:24
+    volumes:
+      - analytics-data:/var/lib/clickhouse
+  reports:
+    image: reports:latest
+    depends_on:
+      - analytics
+      - analytics-db
+volumes:
+  analytics-data:
+`
+		if err := os.WriteFile(composePath, []byte(existing), 0o644); err != nil {
+			t.Fatalf("write existing compose: %v", err)
+		}
+
+		if err := RunRemove(root, RemoveOptions{Services: []string{"analytics"}, Write: true}); err != nil {
+			t.Fatalf("RunRemove: %v", err)
+		}
+
+		data, err := os.ReadFile(composePath)
+		if err != nil {
+			t.Fatalf("read docker-compose.yml: %v", err)
+		}
+		content := string(data)
+		if strings.Contains(content, "  analytics:") || strings.Contains(content, "- analytics\n") {
+			t.Fatalf("expected analytics and its depends_on entry to be removed, got:\n%s", content)
+		}
+		if !strings.Contains(content, "  analytics-db:") || !strings.Contains(content, "analytics-data:") {
+			t.Fatalf("expected analytics-db and its volume to be kept, got:\n%s", content)
+		}
+	})
+
+	t.Run("missing compose returns error", func(t *testing.T) {
+		root := t.TempDir()
+		writeRemoveCatalog(t, root)
+
+		if err := RunRemove(root, RemoveOptions{Services: []string{"mysql"}}); err == nil {
+			t.Fatal("expected error when docker-compose.yml is missing")
+		}
+	})
+
+	t.Run("unknown service returns error", func(t *testing.T) {
+		root := t.TempDir()
+		writeRemoveCatalog(t, root)
+
+		if err := RunRemove(root, RemoveOptions{Services: []string{"unknown"}}); err == nil {
+			t.Fatal("expected error for unknown service")
+		}
+	})
+}
+
+func writeRemoveCatalog(t *testing.T, root string) {
+	t.Helper()
+	configDir := filepath.Join(root, "config")
+	if err := os.Mkdir(configDir, 0o755); err != nil {
+		t.Fatalf("create config directory: %v", err)
+	}
+
+	content := `{
+  "services": [
+    {
+      "id": "mysql",
+      "label": "MySQL",
+      "category": "database",
+      "image": "mysql:8.0",
+      "volumeMounts": ["mysql-data:/var/lib/mysql"],
+      "namedVolumes": ["mysql-data"],
+      "selectable": true,
+      "order": 10
+    },
+    {
+      "id": "analytics",
+      "label": "Analytics",
+      "category": "analytics",
+      "image": "analytics:latest",
+      "dependsOn": ["analytics-db"],
+      "requires": ["analytics-db"],
+      "selectable": true,
+      "order": 20
+    },
+    {
+      "id": "analytics-db",
+      "label": "Analytics DB",
+      "category": "analytics",
+      "image": "clickhouse:24",
+      "volumeMounts": ["analytics-data:/var/lib/clickhouse"],
+      "namedVolumes": ["analytics-data"],
+      "selectable": false,
+      "order": 30
+    }
+  ]
+}`
+
+	if err := os.WriteFile(filepath.Join(configDir, "services.json"), []byte(content), 0o644); err != nil {
+		t.Fatalf("write services catalog: %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package generator
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"docker-wizard/internal/generator/dockerfile"
+)
+
+// IncludedApps returns the apps whose service name is not in excluded.
+func IncludedApps(apps []App, excluded []string) []App {
+	skip := make(map[string]bool, len(excluded))
+	for _, name := range excluded {
+		skip[name] = true
+	}
+	included := make([]App, 0, len(apps))
+	for _, app := range apps {
+		if !skip[app.Name] {
+			included = append(included, app)
+		}
+	}
+	return included
+}
+
+// RenderApps renders the Dockerfile of every app of a monorepo and describes
+// the compose service that builds it. Each app is named after its directory
+// and listens on its detected port; the env, target and platforms of base
+// apply to all of them, while its name, port and command only fit a single
+// app and are ignored.
+func RenderApps(root string, apps []App, base AppSettings) ([]AppFile, []AppService, error) {
+	files := make([]AppFile, 0, len(apps))
+	services := make([]AppService, 0, len(apps))
+	for _, app := range apps {
+		settings := AppSettings{
+			Name:      app.Name,
+			Port:      app.Details.AppPort,
+			Env:       append([]string(nil), base.Env...),
+			Target:    base.Target,
+			Platforms: base.Platforms,
+		}
+		content, err := dockerfile.DockerfileWithApp(root, app.Details, settings)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", app.Dir, err)
+		}
+		watch, err := dockerfile.AppWatchRules(root, filepath.Join(root, filepath.FromSlash(app.Dir)), app.Details)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", app.Dir, err)
+		}
+		devTarget, err := MergedDevTarget(root, app.Dir, content)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", app.Dir, err)
+		}
+		files = append(files, AppFile{Dir: app.Dir, Dockerfile: content})
+		services = append(services, AppService{
+			App:       settings,
+			Context:   app.Dir,
+			Watch:     watch,
+			DevTarget: devTarget,
+		})
+	}
+	return files, services, nil
+}
@@ -0,0 +1,64 @@
+package catalog
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// appEnvData is the data AppEnv templates are rendered with: the compose
+// service name as Host, the container side of the first port as Port, the
+// service environment by key, so that credentials follow overrides, and the
+// mount paths of the service secrets by name.
+type appEnvData struct {
+	Host    string
+	Port    string
+	Env     map[string]string
+	Secrets map[string]string
+}
+
+func parseAppEnv(entry string) (*template.Template, error) {
+	return template.New("appEnv").Option("missingkey=error").Parse(entry)
+}
+
+// ExportedEnv renders the AppEnv templates of the service into the
+// KEY=value entries the app service receives.
+func (s ServiceSpec) ExportedEnv() ([]string, error) {
+	if len(s.AppEnv) == 0 {
+		return nil, nil
+	}
+
+	data := appEnvData{Host: s.Name, Env: map[string]string{}, Secrets: map[string]string{}}
+	for _, secret := range s.Secrets {
+		data.Secrets[secret.Name] = SecretMountPath(secret.Name)
+	}
+	if len(s.Ports) > 0 {
+		data.Port = containerPort(s.Ports[0])
+	} else if len(s.Expose) > 0 {
+		data.Port = s.Expose[0]
+	}
+	for _, entry := range s.Env {
+		key, value, _ := strings.Cut(entry, "=")
+		data.Env[strings.TrimSpace(key)] = value
+	}
+
+	exported := make([]string, 0, len(s.AppEnv))
+	for _, entry := range s.AppEnv {
+		tmpl, err := parseAppEnv(entry)
+		if err != nil {
+			return nil, fmt.Errorf("service %s appEnv: %w", s.ID, err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("service %s appEnv: %w", s.ID, err)
+		}
+		exported = append(exported, buf.String())
+	}
+	return exported, nil
+}
+
+func containerPort(port string) string {
+	parts := strings.Split(port, ":")
+	return parts[len(parts)-1]
+}
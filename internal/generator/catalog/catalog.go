@@ -60,6 +60,9 @@ func readCatalogData(root string) ([]byte, error) {
 	return data, nil
 }
 
+// secretNamePattern keeps secret names usable as compose keys and file names.
+var secretNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]*$`)
+
 func normalizeCatalog(catalog *ServiceCatalog) error {
 	ids := make(map[string]bool, len(catalog.Services))
 	for i := range catalog.Services {
@@ -83,6 +86,32 @@ func normalizeCatalog(catalog *ServiceCatalog) error {
 		if svc.Category != "" && !validCategory(svc.Category) {
 			return fmt.Errorf("service %s has invalid category: %s", svc.ID, svc.Category)
 		}
+		if svc.Healthcheck != nil && len(svc.Healthcheck.Test) == 0 {
+			return fmt.Errorf("service %s healthcheck has no test", svc.ID)
+		}
+		for _, secret := range svc.Secrets {
+			if !secretNamePattern.MatchString(secret.Name) {
+				return fmt.Errorf("service %s has invalid secret name %q", svc.ID, secret.Name)
+			}
+		}
+		for _, profile := range svc.Profiles {
+			if !profileNamePattern.MatchString(profile) {
+				return fmt.Errorf("service %s has invalid profile %q", svc.ID, profile)
+			}
+		}
+		for _, platform := range svc.Platforms {
+			if !platformPattern.MatchString(platform) {
+				return fmt.Errorf("service %s has invalid platform %q", svc.ID, platform)
+			}
+		}
+		for _, entry := range svc.AppEnv {
+			if !strings.Contains(entry, "=") {
+				return fmt.Errorf("service %s appEnv entry %q is not KEY=value", svc.ID, entry)
+			}
+			if _, err := parseAppEnv(entry); err != nil {
+				return fmt.Errorf("service %s appEnv: %w", svc.ID, err)
+			}
+		}
 	}
 
 	for _, svc := range catalog.Services {
@@ -0,0 +1,258 @@
+package catalog
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ServiceOverride changes a catalog service for one project without editing
+// the catalog. Empty fields keep the catalog value. Ports replaces the
+// catalog ports and publishes them on the host, even for services the catalog
+// keeps internal. Env sets variables by key and keeps the others. Profiles
+// replaces the catalog profiles.
+type ServiceOverride struct {
+	Image    string   `json:"image,omitempty" yaml:"image,omitempty"`
+	Ports    []string `json:"ports,omitempty" yaml:"ports,omitempty"`
+	Env      []string `json:"env,omitempty" yaml:"env,omitempty"`
+	Profiles []string `json:"profiles,omitempty" yaml:"profiles,omitempty"`
+}
+
+// AppSettings configures the generated app service. Empty fields keep the
+// defaults of the compose and Dockerfile generators. Port is either the
+// container port, published on the same host port, or "host:container".
+// Platforms are the os/arch pairs compose builds the app image for.
+type AppSettings struct {
+	Name      string   `json:"name,omitempty" yaml:"name,omitempty"`
+	Port      string   `json:"port,omitempty" yaml:"port,omitempty"`
+	Command   string   `json:"command,omitempty" yaml:"command,omitempty"`
+	Env       []string `json:"env,omitempty" yaml:"env,omitempty"`
+	Target    string   `json:"target,omitempty" yaml:"target,omitempty"`
+	Platforms []string `json:"platforms,omitempty" yaml:"platforms,omitempty"`
+}
+
+var (
+	appNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]*$`)
+	portPattern    = regexp.MustCompile(`^([0-9]{1,5}:)?[0-9]{1,5}$`)
+	// profileNamePattern is the compose profile name syntax.
+	profileNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+	// platformPattern is the os/arch[/variant] syntax of an image platform.
+	platformPattern = regexp.MustCompile(`^[a-z0-9]+/[a-z0-9_]+(/[a-z0-9]+)?$`)
+)
+
+// Validate checks the app service name, port and platforms.
+func (a AppSettings) Validate() error {
+	if a.Name != "" && !appNamePattern.MatchString(a.Name) {
+		return fmt.Errorf("invalid app service name %q (expected lowercase letters, digits, '-', '_' or '.')", a.Name)
+	}
+	if a.Port != "" && !portPattern.MatchString(a.Port) {
+		return fmt.Errorf("invalid app port %q (expected port or host:container)", a.Port)
+	}
+	return ValidatePlatforms(a.Platforms)
+}
+
+// ValidatePorts checks ports given as port or host:container, the syntax of
+// the app port, for override ports from --set, the project config file and
+// the TUI.
+func ValidatePorts(ports []string) error {
+	for _, port := range ports {
+		if !portPattern.MatchString(port) {
+			return fmt.Errorf("invalid port %q (expected port or host:container)", port)
+		}
+	}
+	return nil
+}
+
+// ValidatePlatforms checks os/arch platform names such as linux/arm64.
+func ValidatePlatforms(platforms []string) error {
+	for _, platform := range platforms {
+		if !platformPattern.MatchString(platform) {
+			return fmt.Errorf("invalid platform %q (expected os/arch, for example linux/arm64)", platform)
+		}
+	}
+	return nil
+}
+
+// MergeAppSettings returns base with the non-empty fields of top applied.
+// Env entries are merged by key; platforms replace those of base.
+func MergeAppSettings(base AppSettings, top AppSettings) AppSettings {
+	merged := base
+	if top.Name != "" {
+		merged.Name = top.Name
+	}
+	if top.Port != "" {
+		merged.Port = top.Port
+	}
+	if top.Command != "" {
+		merged.Command = top.Command
+	}
+	if len(top.Env) > 0 {
+		merged.Env = MergeEnv(base.Env, top.Env)
+	}
+	if top.Target != "" {
+		merged.Target = top.Target
+	}
+	if len(top.Platforms) > 0 {
+		merged.Platforms = append([]string(nil), top.Platforms...)
+	}
+	return merged
+}
+
+// ApplyOverride returns spec with the override applied.
+func ApplyOverride(spec ServiceSpec, override ServiceOverride) ServiceSpec {
+	if override.Image != "" {
+		// the recorded platforms describe the catalog image
+		if override.Image != spec.Image {
+			spec.Platforms = nil
+		}
+		spec.Image = override.Image
+	}
+	if len(override.Ports) > 0 {
+		spec.Ports = append([]string(nil), override.Ports...)
+		spec.Public = true
+	}
+	if len(override.Env) > 0 {
+		spec.Env = MergeEnv(spec.Env, override.Env)
+	}
+	if len(override.Profiles) > 0 {
+		spec.Profiles = append([]string(nil), override.Profiles...)
+	}
+	return spec
+}
+
+// ApplyOverrides applies overrides to the services of a catalog map in place.
+// An override for a service missing from the catalog is an error.
+func ApplyOverrides(services map[string]ServiceSpec, overrides map[string]ServiceOverride) error {
+	ids := make([]string, 0, len(overrides))
+	for id := range overrides {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		spec, ok := services[id]
+		if !ok {
+			return fmt.Errorf("override for unknown service: %s", id)
+		}
+		services[id] = ApplyOverride(spec, overrides[id])
+	}
+	return nil
+}
+
+// SetOverride applies one "service.field=value" assignment, as given to the
+// batch --set flag, to overrides. The fields are image, ports (comma
+// separated, replacing earlier ports) and env (one KEY=value per assignment,
+// added to earlier env entries).
+func SetOverride(overrides map[string]ServiceOverride, assignment string) error {
+	target, value, ok := strings.Cut(assignment, "=")
+	id, field, hasField := strings.Cut(strings.TrimSpace(target), ".")
+	id = strings.ToLower(strings.TrimSpace(id))
+	field = strings.ToLower(strings.TrimSpace(field))
+	value = strings.TrimSpace(value)
+	if !ok || !hasField || id == "" || field == "" || value == "" {
+		return fmt.Errorf("invalid override %q (expected service.field=value)", assignment)
+	}
+
+	override := overrides[id]
+	switch field {
+	case "image":
+		override.Image = value
+	case "ports", "port":
+		ports := splitList(value)
+		if err := ValidatePorts(ports); err != nil {
+			return err
+		}
+		override.Ports = ports
+	case "env":
+		if !strings.Contains(value, "=") {
+			return fmt.Errorf("invalid override %q (expected %s.env=KEY=value)", assignment, id)
+		}
+		override.Env = MergeEnv(override.Env, []string{value})
+	default:
+		return fmt.Errorf("unknown override field %q (expected image, ports, env)", field)
+	}
+	overrides[id] = override
+	return nil
+}
+
+// SetProfile applies one "service=profile" assignment, as given to the batch
+// --profile flag, to overrides. The profile is added to the profiles earlier
+// assignments gave the service; several profiles can be comma separated.
+func SetProfile(overrides map[string]ServiceOverride, assignment string) error {
+	id, value, ok := strings.Cut(assignment, "=")
+	id = strings.ToLower(strings.TrimSpace(id))
+	profiles := splitList(value)
+	if !ok || id == "" || len(profiles) == 0 {
+		return fmt.Errorf("invalid profile %q (expected service=profile)", assignment)
+	}
+
+	if err := ValidateProfiles(profiles); err != nil {
+		return err
+	}
+
+	override := overrides[id]
+	for _, profile := range profiles {
+		if !containsString(override.Profiles, profile) {
+			override.Profiles = append(override.Profiles, profile)
+		}
+	}
+	overrides[id] = override
+	return nil
+}
+
+// ValidateProfiles checks profile names, for profiles entered in the TUI.
+func ValidateProfiles(profiles []string) error {
+	for _, profile := range profiles {
+		if !profileNamePattern.MatchString(profile) {
+			return fmt.Errorf("invalid profile name %q (expected letters, digits, '-', '_' or '.')", profile)
+		}
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func splitList(value string) []string {
+	parts := strings.Split(value, ",")
+	items := make([]string, 0, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if part != "" {
+			items = append(items, part)
+		}
+	}
+	return items
+}
+
+// MergeEnv returns base with the overrides entries applied: an entry
+// replaces the base entry with the same key or is appended.
+func MergeEnv(base []string, overrides []string) []string {
+	merged := append([]string(nil), base...)
+	for _, entry := range overrides {
+		key := envKey(entry)
+		replaced := false
+		for i, existing := range merged {
+			if envKey(existing) == key {
+				merged[i] = entry
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, entry)
+		}
+	}
+	return merged
+}
+
+func envKey(entry string) string {
+	key, _, _ := strings.Cut(entry, "=")
+	return strings.TrimSpace(key)
+}
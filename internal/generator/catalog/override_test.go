@@ -0,0 +1,95 @@
+package catalog
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestSetOverride_Fields verifies that --set assignments layer on top of
+// overrides loaded from the project config file.
+func TestSetOverride_Fields(t *testing.T) {
+	overrides := map[string]ServiceOverride{
+		"postgres": {Image: "postgres:16", Env: []string{"POSTGRES_DB=app"}},
+	}
+	assignments := []string{
+		"postgres.ports=5544:5432, 5545:5433",
+		"Postgres.env=POSTGRES_DB=shop",
+		"postgres.env=TZ=UTC",
+		"redis.image=redis:7.2",
+	}
+	for _, assignment := range assignments {
+		if err := SetOverride(overrides, assignment); err != nil {
+			t.Fatalf("SetOverride(%q): %v", assignment, err)
+		}
+	}
+
+	postgres := overrides["postgres"]
+	if postgres.Image != "postgres:16" {
+		t.Errorf("expected image from config to be kept, got %q", postgres.Image)
+	}
+	if strings.Join(postgres.Ports, " ") != "5544:5432 5545:5433" {
+		t.Errorf("unexpected ports: %v", postgres.Ports)
+	}
+	if strings.Join(postgres.Env, " ") != "POSTGRES_DB=shop TZ=UTC" {
+		t.Errorf("unexpected env: %v", postgres.Env)
+	}
+	if overrides["redis"].Image != "redis:7.2" {
+		t.Errorf("unexpected redis override: %+v", overrides["redis"])
+	}
+}
+
+func TestSetOverride_Invalid(t *testing.T) {
+	cases := []string{
+		"postgres",
+		"postgres=5544",
+		"postgres.ports=",
+		"postgres.ports=abc",
+		"postgres.ports=5432,54:32:1",
+		"postgres.volumes=data:/data",
+		"postgres.env=TZ",
+	}
+	for _, assignment := range cases {
+		if err := SetOverride(map[string]ServiceOverride{}, assignment); err == nil {
+			t.Errorf("SetOverride(%q): expected error", assignment)
+		}
+	}
+}
+
+func TestSetProfile(t *testing.T) {
+	overrides := map[string]ServiceOverride{
+		"metabase": {Image: "metabase/metabase:v0.50", Profiles: []string{"debug"}},
+	}
+	for _, assignment := range []string{"Metabase=debug,ui", "traefik=proxy"} {
+		if err := SetProfile(overrides, assignment); err != nil {
+			t.Fatalf("SetProfile(%q): %v", assignment, err)
+		}
+	}
+	if got := strings.Join(overrides["metabase"].Profiles, " "); got != "debug ui" {
+		t.Errorf("unexpected metabase profiles: %v", got)
+	}
+	if overrides["metabase"].Image != "metabase/metabase:v0.50" {
+		t.Errorf("expected image to be kept, got %+v", overrides["metabase"])
+	}
+	if got := strings.Join(overrides["traefik"].Profiles, " "); got != "proxy" {
+		t.Errorf("unexpected traefik profiles: %v", got)
+	}
+
+	for _, assignment := range []string{"metabase", "metabase=", "=debug", "metabase=-debug", "metabase=de bug"} {
+		if err := SetProfile(map[string]ServiceOverride{}, assignment); err == nil {
+			t.Errorf("SetProfile(%q): expected error", assignment)
+		}
+	}
+}
+
+func TestApplyOverrideDropsPlatformsOfReplacedImage(t *testing.T) {
+	spec := ServiceSpec{ID: "metabase", Image: "metabase/metabase:latest", Platforms: []string{"linux/amd64"}}
+
+	kept := ApplyOverride(spec, ServiceOverride{Image: "metabase/metabase:latest", Ports: []string{"3001:3000"}})
+	if len(kept.Platforms) != 1 {
+		t.Errorf("expected platforms of the catalog image to be kept, got %v", kept.Platforms)
+	}
+	replaced := ApplyOverride(spec, ServiceOverride{Image: "example/metabase:arm"})
+	if len(replaced.Platforms) != 0 {
+		t.Errorf("expected platforms to be dropped for another image, got %v", replaced.Platforms)
+	}
+}
@@ -1,21 +1,72 @@
 package catalog
 
 type ServiceSpec struct {
-	ID           string   `json:"id"`
-	Name         string   `json:"name"`
-	Label        string   `json:"label"`
-	Description  string   `json:"description"`
-	Category     string   `json:"category"`
-	Image        string   `json:"image"`
-	Ports        []string `json:"ports"`
-	Expose       []string `json:"expose"`
-	Env          []string `json:"env"`
-	VolumeMounts []string `json:"volumeMounts"`
-	NamedVolumes []string `json:"namedVolumes"`
-	DependsOn    []string `json:"dependsOn"`
-	Command      []string `json:"command"`
-	Public       bool     `json:"public"`
-	Selectable   bool     `json:"selectable"`
-	Order        int      `json:"order"`
-	Requires     []string `json:"requires"`
+	ID           string           `json:"id"`
+	Name         string           `json:"name"`
+	Label        string           `json:"label"`
+	Description  string           `json:"description"`
+	Category     string           `json:"category"`
+	Image        string           `json:"image"`
+	Ports        []string         `json:"ports"`
+	Expose       []string         `json:"expose"`
+	Env          []string         `json:"env"`
+	VolumeMounts []string         `json:"volumeMounts"`
+	NamedVolumes []string         `json:"namedVolumes"`
+	DependsOn    []string         `json:"dependsOn"`
+	Command      []string         `json:"command"`
+	Healthcheck  *HealthcheckSpec `json:"healthcheck"`
+	Public       bool             `json:"public"`
+	Selectable   bool             `json:"selectable"`
+	Order        int              `json:"order"`
+	Requires     []string         `json:"requires"`
+	// AppEnv holds KEY=value templates the app service receives when this
+	// service is selected, for example connection strings. See ExportedEnv.
+	AppEnv []string `json:"appEnv,omitempty"`
+	// Secrets are compose secrets the service reads its credentials from.
+	Secrets []SecretSpec `json:"secrets,omitempty"`
+	// Profiles are the compose profiles the service starts with. Services
+	// without profiles start on every docker compose up.
+	Profiles []string `json:"profiles,omitempty"`
+	// Dev holds local development settings, which only a split compose
+	// output writes, to docker-compose.override.yml.
+	Dev *DevSpec `json:"dev,omitempty"`
+	// Platforms are the os/arch pairs the image is published for. Empty
+	// means unknown; a single entry means the image has no multi-arch
+	// manifest and runs emulated on other architectures.
+	Platforms []string `json:"platforms,omitempty"`
+}
+
+// DevSpec is what a service gets in local development on top of its
+// production-like definition. Publish moves the ports out of the base file:
+// the base file only exposes them and the override file publishes them on
+// the host. Env and Volumes are added in the override file only, for debug
+// settings and bind-mounted source.
+type DevSpec struct {
+	Publish bool     `json:"publish,omitempty"`
+	Env     []string `json:"env,omitempty"`
+	Volumes []string `json:"volumes,omitempty"`
+}
+
+// SecretSpec is a compose secret backed by a file with a random value under
+// secrets/, mounted at /run/secrets/<name>. Env names the variable that gets
+// the mount path, for images that read a credential from the file it points
+// to (for example POSTGRES_PASSWORD_FILE).
+type SecretSpec struct {
+	Name string `json:"name"`
+	Env  string `json:"env,omitempty"`
+}
+
+// SecretMountPath is where compose mounts the secret inside containers.
+func SecretMountPath(name string) string {
+	return "/run/secrets/" + name
+}
+
+// HealthcheckSpec mirrors the compose healthcheck block. Services that declare
+// one are waited on with condition: service_healthy by their dependents.
+type HealthcheckSpec struct {
+	Test        []string `json:"test"`
+	Interval    string   `json:"interval"`
+	Timeout     string   `json:"timeout"`
+	Retries     int      `json:"retries"`
+	StartPeriod string   `json:"startPeriod"`
 }
@@ -0,0 +1,30 @@
+package catalog
+
+import "fmt"
+
+// WatchRule is one entry of the compose develop.watch section of the app
+// service. Path is relative to the project root; Target is the path inside
+// the container and is required by the sync actions.
+type WatchRule struct {
+	Action string   `json:"action"`
+	Path   string   `json:"path"`
+	Target string   `json:"target,omitempty"`
+	Ignore []string `json:"ignore,omitempty"`
+}
+
+// Validate checks the action and the paths it needs.
+func (r WatchRule) Validate() error {
+	switch r.Action {
+	case "sync", "sync+restart":
+		if r.Target == "" {
+			return fmt.Errorf("watch rule %s %s needs a target", r.Action, r.Path)
+		}
+	case "rebuild":
+	default:
+		return fmt.Errorf("watch rule for %s has invalid action %q", r.Path, r.Action)
+	}
+	if r.Path == "" {
+		return fmt.Errorf("watch rule %s needs a path", r.Action)
+	}
+	return nil
+}
@@ -2,73 +2,272 @@ package compose
 
 import (
 	"fmt"
+	"path"
 	"sort"
+	"strconv"
 	"strings"
 
 	"docker-wizard/internal/generator/catalog"
-
-	"gopkg.in/yaml.v3"
 )
 
+// ComposeSelection is the input of the compose generator: the selected
+// catalog services, the app service settings and per-service overrides.
+// Watch is the develop.watch section of the app service. SplitOverride moves
+// local development settings into docker-compose.override.yml; see
+// ComposeFiles. DevTarget is the Dockerfile stage the override builds when
+// the app has no target, usually "dev"; empty when the Dockerfile has none.
+// Apps lists the app services of a monorepo; when set they replace the app
+// service described by App, Watch and DevTarget.
 type ComposeSelection struct {
-	Services []string
+	Services      []string
+	App           catalog.AppSettings
+	Overrides     map[string]catalog.ServiceOverride
+	Watch         []catalog.WatchRule
+	SplitOverride bool
+	DevTarget     string
+	Apps          []AppService
+}
+
+// AppService is an app service built from the Dockerfile in Context, the
+// app directory relative to the project root. The paths of its watch rules
+// are relative to Context too.
+type AppService struct {
+	App       catalog.AppSettings
+	Context   string
+	Watch     []catalog.WatchRule
+	DevTarget string
+}
+
+// appServices returns the app services of the selection: the apps of a
+// monorepo, or the single app built from the project root.
+func (s ComposeSelection) appServices() []AppService {
+	if len(s.Apps) > 0 {
+		return s.Apps
+	}
+	return []AppService{{App: s.App, Context: ".", Watch: s.Watch, DevTarget: s.DevTarget}}
 }
 
 func Compose(root string, selection ComposeSelection) (string, error) {
+	doc, err := ComposeDocument(root, selection)
+	if err != nil {
+		return "", err
+	}
+	return doc.Marshal()
+}
+
+// ComposeFiles generates the compose file and the override file. The
+// override is "" unless the selection asks for one and a service has dev
+// settings.
+func ComposeFiles(root string, selection ComposeSelection) (string, string, error) {
+	doc, override, err := ComposeDocuments(root, selection)
+	if err != nil {
+		return "", "", err
+	}
+	content, err := doc.Marshal()
+	if err != nil {
+		return "", "", err
+	}
+	if len(override.Services) == 0 {
+		return content, "", nil
+	}
+	overrideContent, err := override.Marshal()
+	if err != nil {
+		return "", "", err
+	}
+	return content, overrideContent, nil
+}
+
+// ComposeDocument builds the typed compose document for the app service plus
+// the selected catalog services and everything they require.
+func ComposeDocument(root string, selection ComposeSelection) (Document, error) {
+	doc, _, err := ComposeDocuments(root, selection)
+	return doc, err
+}
+
+// ComposeDocuments builds the compose document and, when the selection asks
+// for an override file, the override document holding the dev settings. The
+// override document has no services when there is nothing to split.
+func ComposeDocuments(root string, selection ComposeSelection) (Document, Document, error) {
 	if selection.Services == nil {
 		selection.Services = []string{}
 	}
 
 	serviceMap, ordered, err := catalog.CatalogMap(root)
 	if err != nil {
-		return "", err
+		return Document{}, Document{}, err
+	}
+	if err := catalog.ApplyOverrides(serviceMap, selection.Overrides); err != nil {
+		return Document{}, Document{}, err
 	}
 
 	selected := make(map[string]bool, len(selection.Services))
 	for _, id := range selection.Services {
 		if _, ok := serviceMap[id]; !ok {
-			return "", fmt.Errorf("unknown service: %s", id)
+			return Document{}, Document{}, fmt.Errorf("unknown service: %s", id)
 		}
 		selected[id] = true
 	}
 
 	if err := ExpandRequiredServices(selected, serviceMap); err != nil {
-		return "", err
+		return Document{}, Document{}, err
 	}
 
-	services := []catalog.ServiceSpec{AppServiceSpec()}
-	var volumes []string
-
+	apps := selection.appServices()
+	specs := make([]catalog.ServiceSpec, 0, len(apps)+len(ordered))
+	appNames := make([]string, 0, len(apps))
+	for _, app := range apps {
+		if err := app.App.Validate(); err != nil {
+			return Document{}, Document{}, err
+		}
+		spec := AppServiceSpec(app.App)
+		if sliceContains(appNames, spec.Name) {
+			return Document{}, Document{}, fmt.Errorf("app service name %q is used by more than one app", spec.Name)
+		}
+		specs = append(specs, spec)
+		appNames = append(appNames, spec.Name)
+	}
+	publishOnFreeHostPorts(specs, apps)
 	for _, spec := range ordered {
 		if !selected[spec.ID] {
 			continue
 		}
-		services = append(services, filterDepends(spec, selected))
-		volumes = append(volumes, spec.NamedVolumes...)
+		if sliceContains(appNames, spec.Name) {
+			return Document{}, Document{}, fmt.Errorf("app service name %q is already used by %s", spec.Name, spec.ID)
+		}
+		spec = externalizeSecrets(serviceMap[spec.ID], selection.Overrides[spec.ID])
+		specs = append(specs, filterDepends(spec, selected, appNames))
 	}
 
-	volumes = uniqueStrings(volumes)
+	for i := range apps {
+		exported, depends, secrets, err := appExports(specs[len(apps):], appNames[i], nil)
+		if err != nil {
+			return Document{}, Document{}, err
+		}
+		specs[i].Env = catalog.MergeEnv(exported, specs[i].Env)
+		specs[i].DependsOn = depends
+		for _, name := range secrets {
+			specs[i].Secrets = append(specs[i].Secrets, catalog.SecretSpec{Name: name})
+		}
+	}
 
-	builder := &strings.Builder{}
-	builder.WriteString("version: \"3.9\"\n")
-	builder.WriteString("services:\n")
+	doc := buildDocument(specs, healthcheckedServices(serviceMap))
+	for i, app := range apps {
+		build := doc.Services[i].Build
+		build.Context = app.Context
+		build.Target = app.App.Target
+		build.Platforms = app.App.Platforms
+		if selection.SplitOverride && build.Target == "" {
+			build.Target = app.DevTarget
+		}
+		doc.Services[i].Develop = developFromRules(app.Watch, app.Context)
+	}
+	if !selection.SplitOverride {
+		return doc, Document{}, nil
+	}
+	override := splitDevSettings(&doc, specs)
+	return doc, override, nil
+}
 
-	for _, svc := range services {
-		writeService(builder, svc)
+// publishOnFreeHostPorts publishes an app that listens on the same port as
+// an earlier app on the next free host port, so the apps of a monorepo that
+// share a framework default can run side by side. The port of every app is
+// reserved first, so a moved app never takes the port of a later one. Ports
+// with an explicit host part are left alone.
+func publishOnFreeHostPorts(specs []catalog.ServiceSpec, apps []AppService) {
+	used := map[int]bool{}
+	var moved []int
+	for i := range apps {
+		host, _, _ := strings.Cut(specs[i].Ports[0], ":")
+		port, err := strconv.Atoi(host)
+		if err != nil {
+			continue
+		}
+		if used[port] && !strings.Contains(apps[i].App.Port, ":") {
+			moved = append(moved, i)
+			continue
+		}
+		used[port] = true
 	}
+	for _, i := range moved {
+		host, container, _ := strings.Cut(specs[i].Ports[0], ":")
+		port, _ := strconv.Atoi(host)
+		for used[port] && port < 65535 {
+			port++
+		}
+		used[port] = true
+		specs[i].Ports[0] = strconv.Itoa(port) + ":" + container
+	}
+}
 
-	if len(volumes) > 0 {
-		sort.Strings(volumes)
-		builder.WriteString("volumes:\n")
-		for _, name := range volumes {
-			builder.WriteString("  " + name + ":\n")
+// appExports collects the variables the services export to the app, the
+// services the app therefore depends on and the secrets those variables
+// point into. When several services export the same variable, the first in
+// catalog order wins; validate warns about it. Variables in set are already
+// set on the app and are skipped. The app only depends on services whose
+// variables it got.
+func appExports(specs []catalog.ServiceSpec, appName string, set map[string]bool) ([]string, []string, []string, error) {
+	var env, depends, secrets []string
+	seen := map[string]bool{}
+	for key := range set {
+		seen[key] = true
+	}
+	for _, spec := range specs {
+		exported, err := spec.ExportedEnv()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		var used []string
+		for _, entry := range exported {
+			key, _, _ := strings.Cut(entry, "=")
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			used = append(used, entry)
+		}
+		if len(used) == 0 {
+			continue
+		}
+		env = append(env, used...)
+		secrets = append(secrets, usedSecrets(spec, used)...)
+		// a service that waits for the app cannot be waited on by it
+		if !sliceContains(spec.DependsOn, appName) {
+			depends = append(depends, spec.ID)
 		}
 	}
+	return env, depends, secrets, nil
+}
 
-	builder.WriteString("networks:\n")
-	builder.WriteString("  app-net:\n")
+// developFromRules builds the develop section of an app service. The rule
+// paths are relative to the build context, compose paths to the project, so
+// they are prefixed with the context of an app in a subdirectory.
+func developFromRules(rules []catalog.WatchRule, context string) *Develop {
+	if len(rules) == 0 {
+		return nil
+	}
+	develop := &Develop{}
+	for _, rule := range rules {
+		watchPath := rule.Path
+		if context != "" && context != "." {
+			watchPath = "./" + path.Join(context, rule.Path)
+		}
+		develop.Watch = append(develop.Watch, Watch{
+			Action: rule.Action,
+			Path:   watchPath,
+			Target: rule.Target,
+			Ignore: append([]string(nil), rule.Ignore...),
+		})
+	}
+	return develop
+}
 
-	return builder.String(), nil
+func sliceContains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
 }
 
 func ExpandRequiredServices(selected map[string]bool, services map[string]catalog.ServiceSpec) error {
@@ -92,83 +291,163 @@ func ExpandRequiredServices(selected map[string]bool, services map[string]catalo
 	return nil
 }
 
-func AppServiceSpec() catalog.ServiceSpec {
+const (
+	// DefaultAppName is the compose service name of the app unless configured.
+	DefaultAppName = "app"
+	// DefaultAppPort is the port the app service listens on unless configured.
+	DefaultAppPort = "8080"
+)
+
+// AppServiceSpec describes the app service built from the project Dockerfile.
+// Its ID stays "app" so catalog dependencies on the app keep resolving when
+// the service is renamed. A port without a host part is published on the
+// same host port.
+func AppServiceSpec(app catalog.AppSettings) catalog.ServiceSpec {
+	name := app.Name
+	if name == "" {
+		name = DefaultAppName
+	}
+	port := app.Port
+	if port == "" {
+		port = DefaultAppPort
+	}
+	if !strings.Contains(port, ":") {
+		port = port + ":" + port
+	}
 	return catalog.ServiceSpec{
 		ID:     "app",
-		Name:   "app",
-		Ports:  []string{"8080:8080"},
+		Name:   name,
+		Ports:  []string{port},
+		Env:    append([]string(nil), app.Env...),
 		Public: true,
 	}
 }
 
-func writeService(builder *strings.Builder, svc catalog.ServiceSpec) {
-	builder.WriteString("  " + svc.Name + ":\n")
-	if svc.ID == "app" {
-		builder.WriteString("    build:\n")
-		builder.WriteString("      context: .\n")
-		builder.WriteString("      dockerfile: Dockerfile\n")
+func buildDocument(specs []catalog.ServiceSpec, healthy map[string]bool) Document {
+	doc := Document{
+		Version:  "3.9",
+		Networks: []Resource{{Name: "app-net"}},
 	}
-	if svc.Image != "" {
-		builder.WriteString("    image: " + svc.Image + "\n")
-	}
-	ports := append([]string(nil), svc.Ports...)
-	if len(ports) > 0 && svc.Public {
-		sort.Strings(ports)
-		builder.WriteString("    ports:\n")
-		for _, port := range ports {
-			builder.WriteString("      - \"" + port + "\"\n")
+
+	var volumes, secrets []string
+	for _, spec := range specs {
+		doc.Services = append(doc.Services, serviceFromSpec(spec, healthy))
+		volumes = append(volumes, spec.NamedVolumes...)
+		for _, secret := range spec.Secrets {
+			secrets = append(secrets, secret.Name)
 		}
 	}
-	if !svc.Public {
-		expose := append([]string(nil), svc.Expose...)
+	doc.Secrets = secretResources(secrets)
+
+	volumes = uniqueStrings(volumes)
+	sort.Strings(volumes)
+	for _, name := range volumes {
+		doc.Volumes = append(doc.Volumes, Resource{Name: name})
+	}
+	return doc
+}
+
+func serviceFromSpec(spec catalog.ServiceSpec, healthy map[string]bool) Service {
+	svc := Service{
+		Name:     spec.Name,
+		Image:    spec.Image,
+		Profiles: append([]string(nil), spec.Profiles...),
+		Command:  append([]string(nil), spec.Command...),
+		Volumes:  append([]string(nil), spec.VolumeMounts...),
+		Networks: []string{"app-net"},
+	}
+	if spec.ID == "app" {
+		svc.Build = &Build{Context: ".", Dockerfile: "Dockerfile"}
+	}
+
+	ports := append([]string(nil), spec.Ports...)
+	sort.Strings(ports)
+	if spec.Public {
+		svc.Ports = ports
+	} else {
+		expose := append([]string(nil), spec.Expose...)
 		if len(expose) == 0 && len(ports) > 0 {
 			expose = portsToExpose(ports)
 		}
-		if len(expose) > 0 {
-			sort.Strings(expose)
-			builder.WriteString("    expose:\n")
-			for _, port := range expose {
-				builder.WriteString("      - \"" + port + "\"\n")
-			}
-		}
+		sort.Strings(expose)
+		svc.Expose = expose
 	}
-	if len(svc.Env) > 0 {
-		builder.WriteString("    environment:\n")
-		for _, env := range svc.Env {
-			builder.WriteString("      - " + env + "\n")
-		}
+
+	for _, env := range spec.Env {
+		svc.Environment.Vars = append(svc.Environment.Vars, parseEnvEntry(env))
 	}
-	if len(svc.Command) > 0 {
-		builder.WriteString("    command:\n")
-		for _, arg := range svc.Command {
-			builder.WriteString("      - " + arg + "\n")
+	for _, secret := range spec.Secrets {
+		svc.Secrets = append(svc.Secrets, secret.Name)
+		if secret.Env != "" {
+			svc.Environment.Vars = append(svc.Environment.Vars, EnvVar{Key: secret.Env, Value: catalog.SecretMountPath(secret.Name), HasValue: true})
 		}
 	}
-	if len(svc.VolumeMounts) > 0 {
-		builder.WriteString("    volumes:\n")
-		for _, mount := range svc.VolumeMounts {
-			builder.WriteString("      - " + mount + "\n")
+
+	if check := spec.Healthcheck; check != nil {
+		svc.Healthcheck = &Healthcheck{
+			Test:        append([]string(nil), check.Test...),
+			Interval:    check.Interval,
+			Timeout:     check.Timeout,
+			Retries:     check.Retries,
+			StartPeriod: check.StartPeriod,
 		}
 	}
-	if len(svc.DependsOn) > 0 {
-		depends := append([]string(nil), svc.DependsOn...)
+
+	if len(spec.DependsOn) > 0 {
+		depends := append([]string(nil), spec.DependsOn...)
 		sort.Strings(depends)
-		builder.WriteString("    depends_on:\n")
+		svc.DependsOn.LongForm = anyHealthy(depends, healthy)
 		for _, dep := range depends {
-			builder.WriteString("      - " + dep + "\n")
+			entry := Dependency{Name: dep}
+			if svc.DependsOn.LongForm {
+				entry.Condition = dependsCondition(dep, healthy)
+			}
+			svc.DependsOn.Entries = append(svc.DependsOn.Entries, entry)
 		}
 	}
-	builder.WriteString("    networks:\n")
-	builder.WriteString("      - app-net\n")
+	return svc
 }
 
-func filterDepends(spec catalog.ServiceSpec, selected map[string]bool) catalog.ServiceSpec {
+// healthcheckedServices returns the IDs of catalog services that declare a
+// healthcheck, so dependents can wait for them to become healthy.
+func healthcheckedServices(services map[string]catalog.ServiceSpec) map[string]bool {
+	healthy := make(map[string]bool, len(services))
+	for id, svc := range services {
+		if svc.Healthcheck != nil {
+			healthy[id] = true
+		}
+	}
+	return healthy
+}
+
+func anyHealthy(depends []string, healthy map[string]bool) bool {
+	for _, dep := range depends {
+		if healthy[dep] {
+			return true
+		}
+	}
+	return false
+}
+
+func dependsCondition(dep string, healthy map[string]bool) string {
+	if healthy[dep] {
+		return "service_healthy"
+	}
+	return "service_started"
+}
+
+// filterDepends drops dependencies on services that are not selected and
+// points dependencies on the app at the compose service names of the apps.
+func filterDepends(spec catalog.ServiceSpec, selected map[string]bool, appNames []string) catalog.ServiceSpec {
 	if len(spec.DependsOn) == 0 {
 		return spec
 	}
 	filtered := make([]string, 0, len(spec.DependsOn))
 	for _, dep := range spec.DependsOn {
-		if dep == "app" || selected[dep] {
+		switch {
+		case dep == "app":
+			filtered = append(filtered, appNames...)
+		case selected[dep]:
 			filtered = append(filtered, dep)
 		}
 	}
@@ -215,22 +494,48 @@ func uniqueStrings(values []string) []string {
 }
 
 // ComposeFragment generates compose YAML containing only the requested services
-// (no app service). It returns the YAML string and the list of service IDs that
-// were auto-expanded via dependency resolution.
-func ComposeFragment(root string, serviceIDs []string) (string, []string, error) {
-	if len(serviceIDs) == 0 {
-		return "", nil, fmt.Errorf("no services specified")
+// of the selection, for merging into the existing compose file. It returns the
+// YAML string and the list of service IDs that were auto-expanded via
+// dependency resolution.
+func ComposeFragment(root string, selection ComposeSelection, existing string) (string, []string, error) {
+	existingDoc, err := ParseDocument(existing)
+	if err != nil {
+		return "", nil, fmt.Errorf("parse compose file: %w", err)
+	}
+	doc, expanded, err := FragmentDocument(root, selection, existingDoc)
+	if err != nil {
+		return "", nil, err
+	}
+	content, err := doc.Marshal()
+	if err != nil {
+		return "", nil, err
+	}
+	return content, expanded, nil
+}
+
+// FragmentDocument is the typed form of ComposeFragment. The services get the
+// overrides of the selection, and their dependencies on the app point at the
+// app services of the selection. An app service already in existing gets the
+// variables, dependencies and secrets the new services export to it, so the
+// fragment holds a partial app service in that case; variables the app
+// already sets are left alone.
+func FragmentDocument(root string, selection ComposeSelection, existing Document) (Document, []string, error) {
+	if len(selection.Services) == 0 {
+		return Document{}, nil, fmt.Errorf("no services specified")
 	}
 
 	serviceMap, ordered, err := catalog.CatalogMap(root)
 	if err != nil {
-		return "", nil, err
+		return Document{}, nil, err
+	}
+	if err := catalog.ApplyOverrides(serviceMap, selection.Overrides); err != nil {
+		return Document{}, nil, err
 	}
 
-	selected := make(map[string]bool, len(serviceIDs))
-	for _, id := range serviceIDs {
+	selected := make(map[string]bool, len(selection.Services))
+	for _, id := range selection.Services {
 		if _, ok := serviceMap[id]; !ok {
-			return "", nil, fmt.Errorf("unknown service: %s", id)
+			return Document{}, nil, fmt.Errorf("unknown service: %s", id)
 		}
 		selected[id] = true
 	}
@@ -242,7 +547,7 @@ func ComposeFragment(root string, serviceIDs []string) (string, []string, error)
 	}
 
 	if err := ExpandRequiredServices(selected, serviceMap); err != nil {
-		return "", nil, err
+		return Document{}, nil, err
 	}
 
 	var expanded []string
@@ -253,53 +558,57 @@ func ComposeFragment(root string, serviceIDs []string) (string, []string, error)
 	}
 	sort.Strings(expanded)
 
-	var services []catalog.ServiceSpec
-	var volumes []string
-
+	var appNames []string
+	for _, app := range selection.appServices() {
+		appNames = append(appNames, AppServiceSpec(app.App).Name)
+	}
+	var specs []catalog.ServiceSpec
 	for _, spec := range ordered {
-		if !selected[spec.ID] {
-			continue
+		if selected[spec.ID] {
+			spec = externalizeSecrets(serviceMap[spec.ID], selection.Overrides[spec.ID])
+			specs = append(specs, filterDepends(spec, selected, appNames))
 		}
-		services = append(services, filterDepends(spec, selected))
-		volumes = append(volumes, spec.NamedVolumes...)
 	}
 
-	volumes = uniqueStrings(volumes)
-
-	builder := &strings.Builder{}
-	builder.WriteString("version: \"3.9\"\n")
-	builder.WriteString("services:\n")
-
-	for _, svc := range services {
-		writeService(builder, svc)
-	}
-
-	if len(volumes) > 0 {
-		sort.Strings(volumes)
-		builder.WriteString("volumes:\n")
-		for _, name := range volumes {
-			builder.WriteString("  " + name + ":\n")
+	healthy := healthcheckedServices(serviceMap)
+	doc := buildDocument(specs, healthy)
+	for _, name := range appNames {
+		app, ok := existing.Service(name)
+		if !ok {
+			continue
+		}
+		set := make(map[string]bool, len(app.Environment.Vars))
+		for _, entry := range app.Environment.Vars {
+			set[entry.Key] = true
+		}
+		exported, depends, secrets, err := appExports(specs, name, set)
+		if err != nil {
+			return Document{}, nil, err
 		}
+		if len(exported) == 0 {
+			continue
+		}
+		spec := catalog.ServiceSpec{Name: name, Env: exported, DependsOn: depends}
+		for _, secret := range secrets {
+			spec.Secrets = append(spec.Secrets, catalog.SecretSpec{Name: secret})
+		}
+		svc := serviceFromSpec(spec, healthy)
+		svc.Networks = nil
+		doc.Services = append(doc.Services, svc)
 	}
-
-	builder.WriteString("networks:\n")
-	builder.WriteString("  app-net:\n")
-
-	return builder.String(), expanded, nil
+	return doc, expanded, nil
 }
 
 // ExistingComposeServices parses compose YAML and returns the set of service
 // names defined under the top-level "services" key.
 func ExistingComposeServices(content string) (map[string]bool, error) {
-	var doc struct {
-		Services map[string]any `yaml:"services"`
-	}
-	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+	doc, err := ParseDocument(content)
+	if err != nil {
 		return nil, fmt.Errorf("parse compose file: %w", err)
 	}
 	names := make(map[string]bool, len(doc.Services))
-	for name := range doc.Services {
-		names[name] = true
+	for _, svc := range doc.Services {
+		names[svc.Name] = true
 	}
 	return names, nil
 }
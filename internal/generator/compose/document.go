@@ -0,0 +1,880 @@
+package compose
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Document is the typed model of a docker-compose file. Generation builds it
+// from the catalog, merge and validation operate on it, and it is marshalled
+// through yaml.v3 nodes so quoting and key order are always well-defined.
+type Document struct {
+	Version  string
+	Services []Service
+	Volumes  []Resource
+	Networks []Resource
+	Secrets  []Resource
+	Extra    Fields
+}
+
+// Resource is a top-level named volume, network or secret. Config is nil when
+// the resource is declared without options.
+type Resource struct {
+	Name   string
+	Config *yaml.Node
+}
+
+type Service struct {
+	Name        string
+	Build       *Build
+	Develop     *Develop
+	Image       string
+	Profiles    []string
+	Ports       []string
+	Expose      []string
+	Environment Environment
+	Command     []string
+	Entrypoint  []string
+	Volumes     []string
+	Secrets     []string
+	Healthcheck *Healthcheck
+	DependsOn   DependsOn
+	Networks    []string
+	Extra       Fields
+}
+
+type Build struct {
+	Context    string
+	Dockerfile string
+	Target     string
+	Platforms  []string
+	Extra      Fields
+}
+
+// Develop is the develop section compose watch reads.
+type Develop struct {
+	Watch []Watch
+	Extra Fields
+}
+
+// Watch is one develop.watch rule.
+type Watch struct {
+	Action string
+	Path   string
+	Target string
+	Ignore []string
+	Extra  Fields
+}
+
+type Healthcheck struct {
+	Test        []string
+	Interval    string
+	Timeout     string
+	Retries     int
+	StartPeriod string
+	Extra       Fields
+}
+
+// Environment keeps the variables of a service in file order together with
+// the syntax they were written in, so a map-form block stays a map.
+type Environment struct {
+	Vars    []EnvVar
+	MapForm bool
+}
+
+// EnvVar is one environment entry. HasValue is false for bare KEY entries,
+// which pass the value through from the host.
+type EnvVar struct {
+	Key      string
+	Value    string
+	HasValue bool
+}
+
+// DependsOn keeps dependencies in file order. LongForm selects the
+// `name: {condition: ...}` syntax over the plain list.
+type DependsOn struct {
+	Entries  []Dependency
+	LongForm bool
+}
+
+type Dependency struct {
+	Name      string
+	Condition string
+	Extra     Fields
+}
+
+// Field is a key whose value is kept as a raw YAML node, either because the
+// model has no typed field for it (x- extensions, labels, ...) or because its
+// shape is not one the typed field represents (for example long-syntax ports).
+type Field struct {
+	Key   string
+	Value *yaml.Node
+}
+
+type Fields []Field
+
+func (f Fields) Get(key string) (*yaml.Node, bool) {
+	for _, field := range f {
+		if field.Key == key {
+			return field.Value, true
+		}
+	}
+	return nil, false
+}
+
+func (f Fields) Has(key string) bool {
+	_, ok := f.Get(key)
+	return ok
+}
+
+var serviceKeyOrder = []string{
+	"build",
+	"develop",
+	"image",
+	"profiles",
+	"ports",
+	"expose",
+	"environment",
+	"command",
+	"entrypoint",
+	"volumes",
+	"secrets",
+	"healthcheck",
+	"depends_on",
+	"networks",
+}
+
+// Service returns a pointer to the named service so callers can edit it in
+// place.
+func (d *Document) Service(name string) (*Service, bool) {
+	for i := range d.Services {
+		if d.Services[i].Name == name {
+			return &d.Services[i], true
+		}
+	}
+	return nil, false
+}
+
+func (e Environment) Get(key string) (EnvVar, bool) {
+	for _, env := range e.Vars {
+		if env.Key == key {
+			return env, true
+		}
+	}
+	return EnvVar{}, false
+}
+
+func (d DependsOn) Names() []string {
+	names := make([]string, 0, len(d.Entries))
+	for _, dep := range d.Entries {
+		names = append(names, dep.Name)
+	}
+	return names
+}
+
+// ParseDocument parses compose YAML into the typed model. Keys the model does
+// not know, or whose value has an unexpected shape, are kept as raw fields so
+// that nothing is lost when the document is marshalled again.
+func ParseDocument(content string) (Document, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &root); err != nil {
+		return Document{}, err
+	}
+	if root.Kind == 0 || len(root.Content) == 0 {
+		return Document{}, nil
+	}
+	mapping := resolveAlias(root.Content[0])
+	if mapping.Kind == yaml.ScalarNode && mapping.Tag == "!!null" {
+		return Document{}, nil
+	}
+	if mapping.Kind != yaml.MappingNode {
+		return Document{}, fmt.Errorf("compose file must be a mapping")
+	}
+
+	doc := Document{}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key := mapping.Content[i].Value
+		value := mapping.Content[i+1]
+		switch key {
+		case "version":
+			if version, ok := decodeString(value); ok {
+				doc.Version = version
+				continue
+			}
+		case "services":
+			if services, ok := decodeServices(value); ok {
+				doc.Services = services
+				continue
+			}
+		case "volumes":
+			if volumes, ok := decodeResources(value); ok {
+				doc.Volumes = volumes
+				continue
+			}
+		case "networks":
+			if networks, ok := decodeResources(value); ok {
+				doc.Networks = networks
+				continue
+			}
+		case "secrets":
+			if secrets, ok := decodeResources(value); ok {
+				doc.Secrets = secrets
+				continue
+			}
+		}
+		doc.Extra = append(doc.Extra, Field{Key: key, Value: value})
+	}
+	return doc, nil
+}
+
+func decodeServices(node *yaml.Node) ([]Service, bool) {
+	node = resolveAlias(node)
+	if isNull(node) {
+		return nil, true
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil, false
+	}
+	services := make([]Service, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		services = append(services, decodeService(node.Content[i].Value, node.Content[i+1]))
+	}
+	return services, true
+}
+
+func decodeService(name string, node *yaml.Node) Service {
+	svc := Service{Name: name}
+	node = resolveAlias(node)
+	if node.Kind != yaml.MappingNode {
+		if !isNull(node) {
+			svc.Extra = append(svc.Extra, Field{Key: "", Value: node})
+		}
+		return svc
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i].Value
+		value := node.Content[i+1]
+		if decodeServiceField(&svc, key, value) {
+			continue
+		}
+		svc.Extra = append(svc.Extra, Field{Key: key, Value: value})
+	}
+	return svc
+}
+
+func decodeServiceField(svc *Service, key string, value *yaml.Node) bool {
+	var ok bool
+	switch key {
+	case "build":
+		svc.Build, ok = decodeBuild(value)
+	case "develop":
+		svc.Develop, ok = decodeDevelop(value)
+	case "image":
+		svc.Image, ok = decodeString(value)
+	case "profiles":
+		svc.Profiles, ok = decodeStringList(value)
+	case "ports":
+		svc.Ports, ok = decodeStringList(value)
+	case "expose":
+		svc.Expose, ok = decodeStringList(value)
+	case "environment":
+		svc.Environment, ok = decodeEnvironment(value)
+	case "command":
+		svc.Command, ok = decodeStringList(value)
+	case "entrypoint":
+		svc.Entrypoint, ok = decodeStringList(value)
+	case "volumes":
+		svc.Volumes, ok = decodeStringList(value)
+	case "secrets":
+		svc.Secrets, ok = decodeStringList(value)
+	case "healthcheck":
+		svc.Healthcheck, ok = decodeHealthcheck(value)
+	case "depends_on":
+		svc.DependsOn, ok = decodeDependsOn(value)
+	case "networks":
+		svc.Networks, ok = decodeStringList(value)
+	}
+	return ok
+}
+
+func decodeBuild(node *yaml.Node) (*Build, bool) {
+	node = resolveAlias(node)
+	if node.Kind != yaml.MappingNode {
+		return nil, false
+	}
+	build := &Build{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i].Value
+		value := node.Content[i+1]
+		var ok bool
+		switch key {
+		case "context":
+			build.Context, ok = decodeString(value)
+		case "dockerfile":
+			build.Dockerfile, ok = decodeString(value)
+		case "target":
+			build.Target, ok = decodeString(value)
+		case "platforms":
+			build.Platforms, ok = decodeStringList(value)
+		}
+		if !ok {
+			build.Extra = append(build.Extra, Field{Key: key, Value: value})
+		}
+	}
+	return build, true
+}
+
+// decodeDevelop keeps the section raw unless every watch rule is a mapping.
+func decodeDevelop(node *yaml.Node) (*Develop, bool) {
+	node = resolveAlias(node)
+	if node.Kind != yaml.MappingNode {
+		return nil, false
+	}
+	develop := &Develop{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i].Value
+		value := resolveAlias(node.Content[i+1])
+		if key != "watch" || value.Kind != yaml.SequenceNode {
+			develop.Extra = append(develop.Extra, Field{Key: key, Value: node.Content[i+1]})
+			continue
+		}
+		for _, item := range value.Content {
+			watch, ok := decodeWatch(item)
+			if !ok {
+				return nil, false
+			}
+			develop.Watch = append(develop.Watch, watch)
+		}
+	}
+	return develop, true
+}
+
+func decodeWatch(node *yaml.Node) (Watch, bool) {
+	node = resolveAlias(node)
+	if node.Kind != yaml.MappingNode {
+		return Watch{}, false
+	}
+	watch := Watch{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i].Value
+		value := node.Content[i+1]
+		var ok bool
+		switch key {
+		case "action":
+			watch.Action, ok = decodeString(value)
+		case "path":
+			watch.Path, ok = decodeString(value)
+		case "target":
+			watch.Target, ok = decodeString(value)
+		case "ignore":
+			watch.Ignore, ok = decodeStringList(value)
+		}
+		if !ok {
+			watch.Extra = append(watch.Extra, Field{Key: key, Value: value})
+		}
+	}
+	return watch, true
+}
+
+func decodeHealthcheck(node *yaml.Node) (*Healthcheck, bool) {
+	node = resolveAlias(node)
+	if node.Kind != yaml.MappingNode {
+		return nil, false
+	}
+	check := &Healthcheck{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i].Value
+		value := node.Content[i+1]
+		ok := false
+		switch key {
+		case "test":
+			check.Test, ok = decodeStringList(value)
+			if !ok {
+				return nil, false
+			}
+		case "interval":
+			check.Interval, ok = decodeString(value)
+		case "timeout":
+			check.Timeout, ok = decodeString(value)
+		case "start_period":
+			check.StartPeriod, ok = decodeString(value)
+		case "retries":
+			if raw, isString := decodeString(value); isString {
+				retries, err := strconv.Atoi(raw)
+				check.Retries, ok = retries, err == nil
+			}
+		}
+		if !ok {
+			check.Extra = append(check.Extra, Field{Key: key, Value: value})
+		}
+	}
+	return check, true
+}
+
+func decodeEnvironment(node *yaml.Node) (Environment, bool) {
+	node = resolveAlias(node)
+	switch node.Kind {
+	case yaml.SequenceNode:
+		env := Environment{}
+		for _, item := range node.Content {
+			entry, ok := decodeString(item)
+			if !ok {
+				return Environment{}, false
+			}
+			env.Vars = append(env.Vars, parseEnvEntry(entry))
+		}
+		return env, true
+	case yaml.MappingNode:
+		env := Environment{MapForm: true}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			value := resolveAlias(node.Content[i+1])
+			if value.Kind != yaml.ScalarNode {
+				return Environment{}, false
+			}
+			env.Vars = append(env.Vars, EnvVar{
+				Key:      node.Content[i].Value,
+				Value:    value.Value,
+				HasValue: !isNull(value),
+			})
+		}
+		return env, true
+	default:
+		return Environment{}, isNull(node)
+	}
+}
+
+func parseEnvEntry(entry string) EnvVar {
+	if idx := strings.Index(entry, "="); idx >= 0 {
+		return EnvVar{Key: strings.TrimSpace(entry[:idx]), Value: entry[idx+1:], HasValue: true}
+	}
+	return EnvVar{Key: strings.TrimSpace(entry)}
+}
+
+func decodeDependsOn(node *yaml.Node) (DependsOn, bool) {
+	node = resolveAlias(node)
+	switch node.Kind {
+	case yaml.SequenceNode:
+		names, ok := decodeStringList(node)
+		if !ok {
+			return DependsOn{}, false
+		}
+		depends := DependsOn{}
+		for _, name := range names {
+			depends.Entries = append(depends.Entries, Dependency{Name: name})
+		}
+		return depends, true
+	case yaml.MappingNode:
+		depends := DependsOn{LongForm: true}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			dep := Dependency{Name: node.Content[i].Value}
+			options := resolveAlias(node.Content[i+1])
+			if options.Kind == yaml.MappingNode {
+				for j := 0; j+1 < len(options.Content); j += 2 {
+					key := options.Content[j].Value
+					value := options.Content[j+1]
+					if key == "condition" {
+						if condition, ok := decodeString(value); ok {
+							dep.Condition = condition
+							continue
+						}
+					}
+					dep.Extra = append(dep.Extra, Field{Key: key, Value: value})
+				}
+			} else if !isNull(options) {
+				return DependsOn{}, false
+			}
+			depends.Entries = append(depends.Entries, dep)
+		}
+		return depends, true
+	default:
+		return DependsOn{}, isNull(node)
+	}
+}
+
+func decodeResources(node *yaml.Node) ([]Resource, bool) {
+	node = resolveAlias(node)
+	if isNull(node) {
+		return nil, true
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil, false
+	}
+	resources := make([]Resource, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		resource := Resource{Name: node.Content[i].Value}
+		if config := node.Content[i+1]; !isNull(resolveAlias(config)) {
+			resource.Config = config
+		}
+		resources = append(resources, resource)
+	}
+	return resources, true
+}
+
+func decodeString(node *yaml.Node) (string, bool) {
+	node = resolveAlias(node)
+	if node.Kind != yaml.ScalarNode || isNull(node) {
+		return "", false
+	}
+	return node.Value, true
+}
+
+func decodeStringList(node *yaml.Node) ([]string, bool) {
+	node = resolveAlias(node)
+	if isNull(node) {
+		return nil, true
+	}
+	if node.Kind != yaml.SequenceNode {
+		return nil, false
+	}
+	values := make([]string, 0, len(node.Content))
+	for _, item := range node.Content {
+		value, ok := decodeString(item)
+		if !ok {
+			return nil, false
+		}
+		values = append(values, value)
+	}
+	return values, true
+}
+
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node != nil && node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+func isNull(node *yaml.Node) bool {
+	return node == nil || (node.Kind == yaml.ScalarNode && node.Tag == "!!null")
+}
+
+// Marshal renders the document as YAML with a stable key order.
+func (d Document) Marshal() (string, error) {
+	root := &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{d.Node()}}
+
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(root); err != nil {
+		return "", err
+	}
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
+
+// Node returns the document as a YAML mapping node.
+func (d Document) Node() *yaml.Node {
+	node := mappingNode()
+	if d.Version != "" {
+		appendPair(node, "version", quotedNode(d.Version))
+	}
+
+	services := mappingNode()
+	for _, svc := range d.Services {
+		appendPair(services, svc.Name, svc.Node())
+	}
+	if len(services.Content) == 0 {
+		services = nullNode()
+	}
+	appendPair(node, "services", services)
+
+	if len(d.Volumes) > 0 {
+		appendPair(node, "volumes", resourcesNode(d.Volumes))
+	}
+	if len(d.Networks) > 0 {
+		appendPair(node, "networks", resourcesNode(d.Networks))
+	}
+	if len(d.Secrets) > 0 {
+		appendPair(node, "secrets", resourcesNode(d.Secrets))
+	}
+	for _, field := range d.Extra {
+		appendPair(node, field.Key, field.Value)
+	}
+	return node
+}
+
+// Node returns the service as a YAML mapping node. Raw fields take precedence
+// over typed values with the same key and keep their canonical position.
+func (s Service) Node() *yaml.Node {
+	if raw, ok := s.Extra.Get(""); ok {
+		return raw
+	}
+	node := mappingNode()
+	for _, key := range serviceKeyOrder {
+		if raw, ok := s.Extra.Get(key); ok {
+			appendPair(node, key, raw)
+			continue
+		}
+		if value := s.typedNode(key); value != nil {
+			appendPair(node, key, value)
+		}
+	}
+	for _, field := range s.Extra {
+		if field.Key == "" || isServiceKey(field.Key) {
+			continue
+		}
+		appendPair(node, field.Key, field.Value)
+	}
+	return node
+}
+
+func (s Service) typedNode(key string) *yaml.Node {
+	switch key {
+	case "build":
+		if s.Build == nil {
+			return nil
+		}
+		return s.Build.node()
+	case "develop":
+		if s.Develop == nil {
+			return nil
+		}
+		return s.Develop.node()
+	case "image":
+		if s.Image == "" {
+			return nil
+		}
+		return stringNode(s.Image)
+	case "profiles":
+		return sequenceNode(s.Profiles)
+	case "ports":
+		return quotedSequenceNode(s.Ports)
+	case "expose":
+		return quotedSequenceNode(s.Expose)
+	case "environment":
+		return s.Environment.Node()
+	case "command":
+		return sequenceNode(s.Command)
+	case "entrypoint":
+		return sequenceNode(s.Entrypoint)
+	case "volumes":
+		return sequenceNode(s.Volumes)
+	case "secrets":
+		return sequenceNode(s.Secrets)
+	case "healthcheck":
+		if s.Healthcheck == nil {
+			return nil
+		}
+		return s.Healthcheck.node()
+	case "depends_on":
+		return s.DependsOn.node()
+	case "networks":
+		return sequenceNode(s.Networks)
+	default:
+		return nil
+	}
+}
+
+func isServiceKey(key string) bool {
+	for _, known := range serviceKeyOrder {
+		if known == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (b Build) node() *yaml.Node {
+	node := mappingNode()
+	if b.Context != "" {
+		appendPair(node, "context", stringNode(b.Context))
+	}
+	if b.Dockerfile != "" {
+		appendPair(node, "dockerfile", stringNode(b.Dockerfile))
+	}
+	if b.Target != "" {
+		appendPair(node, "target", stringNode(b.Target))
+	}
+	if len(b.Platforms) > 0 {
+		appendPair(node, "platforms", sequenceNode(b.Platforms))
+	}
+	for _, field := range b.Extra {
+		appendPair(node, field.Key, field.Value)
+	}
+	return node
+}
+
+func (d Develop) node() *yaml.Node {
+	node := mappingNode()
+	if len(d.Watch) > 0 {
+		watch := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, rule := range d.Watch {
+			watch.Content = append(watch.Content, rule.node())
+		}
+		appendPair(node, "watch", watch)
+	}
+	for _, field := range d.Extra {
+		appendPair(node, field.Key, field.Value)
+	}
+	return node
+}
+
+func (w Watch) node() *yaml.Node {
+	node := mappingNode()
+	if w.Action != "" {
+		appendPair(node, "action", stringNode(w.Action))
+	}
+	if w.Path != "" {
+		appendPair(node, "path", stringNode(w.Path))
+	}
+	if w.Target != "" {
+		appendPair(node, "target", stringNode(w.Target))
+	}
+	if ignore := sequenceNode(w.Ignore); ignore != nil {
+		appendPair(node, "ignore", ignore)
+	}
+	for _, field := range w.Extra {
+		appendPair(node, field.Key, field.Value)
+	}
+	return node
+}
+
+func (h Healthcheck) node() *yaml.Node {
+	node := mappingNode()
+	if len(h.Test) > 0 {
+		appendPair(node, "test", quotedSequenceNode(h.Test))
+	}
+	if h.Interval != "" {
+		appendPair(node, "interval", stringNode(h.Interval))
+	}
+	if h.Timeout != "" {
+		appendPair(node, "timeout", stringNode(h.Timeout))
+	}
+	if h.Retries > 0 {
+		appendPair(node, "retries", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(h.Retries)})
+	}
+	if h.StartPeriod != "" {
+		appendPair(node, "start_period", stringNode(h.StartPeriod))
+	}
+	for _, field := range h.Extra {
+		appendPair(node, field.Key, field.Value)
+	}
+	return node
+}
+
+// Node renders the variables in list (KEY=VALUE) or map form, depending on
+// MapForm. It returns nil when there are no variables.
+func (e Environment) Node() *yaml.Node {
+	if len(e.Vars) == 0 {
+		return nil
+	}
+	if e.MapForm {
+		node := mappingNode()
+		for _, env := range e.Vars {
+			if !env.HasValue {
+				appendPair(node, env.Key, nullNode())
+				continue
+			}
+			appendPair(node, env.Key, envValueNode(env.Value))
+		}
+		return node
+	}
+	values := make([]string, 0, len(e.Vars))
+	for _, env := range e.Vars {
+		if env.HasValue {
+			values = append(values, env.Key+"="+env.Value)
+		} else {
+			values = append(values, env.Key)
+		}
+	}
+	return sequenceNode(values)
+}
+
+func (d DependsOn) node() *yaml.Node {
+	if len(d.Entries) == 0 {
+		return nil
+	}
+	if !d.LongForm {
+		return sequenceNode(d.Names())
+	}
+	node := mappingNode()
+	for _, dep := range d.Entries {
+		options := mappingNode()
+		if dep.Condition != "" {
+			appendPair(options, "condition", stringNode(dep.Condition))
+		}
+		for _, field := range dep.Extra {
+			appendPair(options, field.Key, field.Value)
+		}
+		appendPair(node, dep.Name, options)
+	}
+	return node
+}
+
+func resourcesNode(resources []Resource) *yaml.Node {
+	node := mappingNode()
+	for _, resource := range resources {
+		config := resource.Config
+		if config == nil {
+			config = nullNode()
+		}
+		appendPair(node, resource.Name, config)
+	}
+	return node
+}
+
+func mappingNode() *yaml.Node {
+	return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+}
+
+func nullNode() *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null"}
+}
+
+// stringNode returns a string scalar; the encoder adds quotes whenever the
+// plain form would be misread (": ", " #", leading "*", ...).
+func stringNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+// quotedNode always double-quotes, which compose documentation recommends
+// for port mappings and version strings.
+func quotedNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value, Style: yaml.DoubleQuotedStyle}
+}
+
+// envValueNode quotes map-form environment values that YAML 1.1 parsers would
+// read as booleans or numbers, since compose rejects non-string values there.
+func envValueNode(value string) *yaml.Node {
+	switch strings.ToLower(value) {
+	case "y", "yes", "n", "no", "on", "off", "true", "false", "null", "~", "":
+		return quotedNode(value)
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return quotedNode(value)
+	}
+	return stringNode(value)
+}
+
+func sequenceNode(values []string) *yaml.Node {
+	if len(values) == 0 {
+		return nil
+	}
+	node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	for _, value := range values {
+		node.Content = append(node.Content, stringNode(value))
+	}
+	return node
+}
+
+func quotedSequenceNode(values []string) *yaml.Node {
+	if len(values) == 0 {
+		return nil
+	}
+	node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	for _, value := range values {
+		node.Content = append(node.Content, quotedNode(value))
+	}
+	return node
+}
+
+func appendPair(node *yaml.Node, key string, value *yaml.Node) {
+	node.Content = append(node.Content, stringNode(key), value)
+}
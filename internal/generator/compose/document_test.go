@@ -0,0 +1,158 @@
+package compose
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDocumentMarshalQuotesAmbiguousScalars(t *testing.T) {
+	doc := Document{
+		Version: "3.9",
+		Services: []Service{{
+			Name:  "web",
+			Image: "busybox",
+			Ports: []string{"8080:8080"},
+			Environment: Environment{Vars: []EnvVar{
+				{Key: "SECRET", Value: "a: b", HasValue: true},
+				{Key: "NOTE", Value: "x # y", HasValue: true},
+				{Key: "PATTERN", Value: "*star", HasValue: true},
+				{Key: "HOST_TOKEN"},
+			}},
+		}},
+	}
+
+	output, err := doc.Marshal()
+	if err != nil {
+		t.Fatalf("marshal document: %v", err)
+	}
+
+	for _, want := range []string{
+		"version: \"3.9\"\n",
+		"      - \"8080:8080\"\n",
+		"      - 'SECRET=a: b'\n",
+		"      - 'NOTE=x # y'\n",
+		"      - HOST_TOKEN\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q in output:\n%s", want, output)
+		}
+	}
+
+	parsed, err := ParseDocument(output)
+	if err != nil {
+		t.Fatalf("parse marshalled document: %v", err)
+	}
+	svc, ok := parsed.Service("web")
+	if !ok {
+		t.Fatalf("expected web service after round trip")
+	}
+	for _, key := range []string{"SECRET", "NOTE", "PATTERN"} {
+		original, _ := doc.Services[0].Environment.Get(key)
+		got, ok := svc.Environment.Get(key)
+		if !ok || got.Value != original.Value {
+			t.Fatalf("expected %s=%q after round trip, got %q", key, original.Value, got.Value)
+		}
+	}
+}
+
+func TestDocumentMarshalQuotesMapFormEnvironmentValues(t *testing.T) {
+	doc := Document{Services: []Service{{
+		Name: "zookeeper",
+		Environment: Environment{MapForm: true, Vars: []EnvVar{
+			{Key: "ALLOW_ANONYMOUS_LOGIN", Value: "yes", HasValue: true},
+			{Key: "PORT", Value: "2181", HasValue: true},
+			{Key: "MODE", Value: "standalone", HasValue: true},
+		}},
+	}}}
+
+	output, err := doc.Marshal()
+	if err != nil {
+		t.Fatalf("marshal document: %v", err)
+	}
+
+	for _, want := range []string{
+		"      ALLOW_ANONYMOUS_LOGIN: \"yes\"\n",
+		"      PORT: \"2181\"\n",
+		"      MODE: standalone\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q in output:\n%s", want, output)
+		}
+	}
+}
+
+func TestParseDocumentKeepsUnknownFieldsInCanonicalOrder(t *testing.T) {
+	content := "" +
+		"services:\n" +
+		"  web:\n" +
+		"    labels:\n" +
+		"      team: core\n" +
+		"    ports:\n" +
+		"      - target: 80\n" +
+		"        published: 8080\n" +
+		"    image: nginx\n" +
+		"x-shared:\n" +
+		"  keep: true\n"
+
+	doc, err := ParseDocument(content)
+	if err != nil {
+		t.Fatalf("parse document: %v", err)
+	}
+	svc, ok := doc.Service("web")
+	if !ok {
+		t.Fatalf("expected web service")
+	}
+	if svc.Image != "nginx" {
+		t.Fatalf("expected typed image, got %q", svc.Image)
+	}
+	if !svc.Extra.Has("ports") || !svc.Extra.Has("labels") {
+		t.Fatalf("expected long-syntax ports and labels to be kept as raw fields")
+	}
+
+	output, err := doc.Marshal()
+	if err != nil {
+		t.Fatalf("marshal document: %v", err)
+	}
+	image := strings.Index(output, "image: nginx")
+	ports := strings.Index(output, "ports:")
+	labels := strings.Index(output, "labels:")
+	if image < 0 || ports < image || labels < ports {
+		t.Fatalf("expected image, ports, labels order, got:\n%s", output)
+	}
+	if !strings.Contains(output, "published: 8080") || !strings.Contains(output, "x-shared:\n  keep: true\n") {
+		t.Fatalf("expected raw fields to survive, got:\n%s", output)
+	}
+}
+
+func TestParseDocumentDecodesDevelopWatch(t *testing.T) {
+	content := "" +
+		"services:\n" +
+		"  app:\n" +
+		"    develop:\n" +
+		"      watch:\n" +
+		"        - action: sync+restart\n" +
+		"          path: ./config\n" +
+		"          target: /app/config\n" +
+		"          initial_sync: true\n"
+
+	doc, err := ParseDocument(content)
+	if err != nil {
+		t.Fatalf("parse document: %v", err)
+	}
+	app, _ := doc.Service("app")
+	if app.Develop == nil || len(app.Develop.Watch) != 1 {
+		t.Fatalf("expected a typed watch rule, got %+v", app.Develop)
+	}
+	rule := app.Develop.Watch[0]
+	if rule.Action != "sync+restart" || rule.Path != "./config" || rule.Target != "/app/config" || !rule.Extra.Has("initial_sync") {
+		t.Fatalf("unexpected watch rule: %+v", rule)
+	}
+
+	output, err := doc.Marshal()
+	if err != nil {
+		t.Fatalf("marshal document: %v", err)
+	}
+	if output != content {
+		t.Fatalf("expected develop section to round-trip, got:\n%s", output)
+	}
+}
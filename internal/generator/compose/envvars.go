@@ -0,0 +1,55 @@
+package compose
+
+import (
+	"regexp"
+	"strings"
+
+	"docker-wizard/internal/generator/catalog"
+)
+
+// secretKeyPattern matches the env keys whose catalog values are moved out of
+// the compose file into .env.
+var secretKeyPattern = regexp.MustCompile(`(?i)(PASSWORD|PASSWD|SECRET|TOKEN|API_?KEY)`)
+
+// externalizeSecrets replaces the catalog values of secret-like env keys of
+// spec with ${VAR} references, which the writer fills with random values in
+// .env. Keys set by an override keep their value, since the user chose it.
+func externalizeSecrets(spec catalog.ServiceSpec, override catalog.ServiceOverride) catalog.ServiceSpec {
+	if len(spec.Env) == 0 {
+		return spec
+	}
+	overridden := map[string]bool{}
+	for _, entry := range override.Env {
+		key, _, _ := strings.Cut(entry, "=")
+		overridden[strings.TrimSpace(key)] = true
+	}
+
+	env := make([]string, 0, len(spec.Env))
+	for _, entry := range spec.Env {
+		key, value, ok := strings.Cut(entry, "=")
+		key = strings.TrimSpace(key)
+		if ok && value != "" && !overridden[key] && !strings.Contains(value, "${") && secretKeyPattern.MatchString(key) {
+			entry = key + "=${" + EnvVariableName(spec.Name, key) + "}"
+		}
+		env = append(env, entry)
+	}
+	spec.Env = env
+	return spec
+}
+
+// EnvVariableName returns the .env variable holding the secret key of a
+// service: the key prefixed with the service name, where words the two share
+// are not repeated. POSTGRES_PASSWORD of postgres stays POSTGRES_PASSWORD,
+// while that of plausible-postgres becomes PLAUSIBLE_POSTGRES_PASSWORD.
+func EnvVariableName(service string, key string) string {
+	prefix := strings.Split(strings.ToUpper(nonIdentifier.ReplaceAllString(service, "_")), "_")
+	words := strings.Split(strings.ToUpper(key), "_")
+	for n := min(len(prefix), len(words)); n > 0; n-- {
+		if strings.Join(prefix[len(prefix)-n:], "_") == strings.Join(words[:n], "_") {
+			return strings.Join(append(prefix[:len(prefix)-n], words...), "_")
+		}
+	}
+	return strings.Join(append(prefix, words...), "_")
+}
+
+var nonIdentifier = regexp.MustCompile(`[^A-Za-z0-9_]+`)
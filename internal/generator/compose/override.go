@@ -0,0 +1,71 @@
+package compose
+
+import (
+	"sort"
+	"strings"
+
+	"docker-wizard/internal/generator/catalog"
+)
+
+// ComposeOverrideFileName is the file docker compose merges over docker-compose.yml
+// by default, which makes it the place for local development settings.
+const ComposeOverrideFileName = "docker-compose.override.yml"
+
+// splitDevSettings moves the local development settings of the services out
+// of doc into an override document: the app build target and develop section,
+// ports of services whose catalog entry marks them dev-only, and the dev env
+// vars and volumes. specs are the specs doc was built from, in the same order.
+func splitDevSettings(doc *Document, specs []catalog.ServiceSpec) Document {
+	override := Document{}
+	var volumes []string
+	for i, spec := range specs {
+		svc := &doc.Services[i]
+		dev := Service{Name: svc.Name}
+		if svc.Build != nil && svc.Build.Target != "" {
+			dev.Build = &Build{Target: svc.Build.Target}
+			svc.Build.Target = ""
+		}
+		dev.Develop, svc.Develop = svc.Develop, nil
+		if spec.Dev != nil {
+			if spec.Dev.Publish && len(spec.Ports) > 0 {
+				ports := append([]string(nil), spec.Ports...)
+				sort.Strings(ports)
+				dev.Ports = ports
+				if len(svc.Expose) == 0 {
+					svc.Expose = portsToExpose(ports)
+					sort.Strings(svc.Expose)
+				}
+				svc.Ports = nil
+			}
+			for _, env := range spec.Dev.Env {
+				dev.Environment.Vars = append(dev.Environment.Vars, parseEnvEntry(env))
+			}
+			dev.Volumes = append(dev.Volumes, spec.Dev.Volumes...)
+			for _, mount := range spec.Dev.Volumes {
+				if name, ok := namedVolume(mount); ok {
+					volumes = append(volumes, name)
+				}
+			}
+		}
+		if dev.Build != nil || dev.Develop != nil || len(dev.Ports) > 0 || len(dev.Environment.Vars) > 0 || len(dev.Volumes) > 0 {
+			override.Services = append(override.Services, dev)
+		}
+	}
+
+	volumes = uniqueStrings(volumes)
+	sort.Strings(volumes)
+	for _, name := range volumes {
+		override.Volumes = append(override.Volumes, Resource{Name: name})
+	}
+	return override
+}
+
+// namedVolume returns the volume name of a "source:target" mount whose source
+// is a named volume rather than a host path.
+func namedVolume(mount string) (string, bool) {
+	source, _, ok := strings.Cut(mount, ":")
+	if !ok || source == "" || strings.ContainsAny(source[:1], "./~$") {
+		return "", false
+	}
+	return source, true
+}
@@ -0,0 +1,307 @@
+package compose
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"docker-wizard/internal/generator/catalog"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Removal describes what removing services from an existing compose file
+// deletes. Services, Dependencies and Missing hold catalog IDs; Names holds
+// the compose service names to delete. Env and ServiceSecrets map the
+// remaining services to the variables to delete from their environment and
+// the secrets to delete from their secrets list; Secrets holds the top-level
+// secrets to delete.
+type Removal struct {
+	Services       []string
+	Dependencies   []string
+	Volumes        []string
+	Secrets        []string
+	Missing        []string
+	Names          []string
+	Env            map[string][]string
+	ServiceSecrets map[string][]string
+}
+
+// PlanRemoval works out which services and named volumes to delete when the
+// given catalog services are removed from doc. Required services that are not
+// selectable on their own (the ones `add` pulls in automatically) are removed
+// as well once no remaining service requires or depends on them. Named volumes
+// of removed services are dropped when no remaining service mounts them. The
+// variables removed services exported to the apps are dropped from them while
+// they still have the generated value; ones the user changed are kept. An app
+// stops mounting a secret of a removed service once no variable it keeps
+// points into it, and the secret itself is dropped when no remaining service
+// mounts it.
+func PlanRemoval(root string, doc Document, serviceIDs []string) (Removal, error) {
+	if len(serviceIDs) == 0 {
+		return Removal{}, fmt.Errorf("no services specified")
+	}
+
+	serviceMap, _, err := catalog.CatalogMap(root)
+	if err != nil {
+		return Removal{}, err
+	}
+
+	present := make(map[string]bool, len(doc.Services))
+	for _, svc := range doc.Services {
+		present[svc.Name] = true
+	}
+	byName := make(map[string]catalog.ServiceSpec, len(serviceMap))
+	for _, spec := range serviceMap {
+		byName[spec.Name] = spec
+	}
+
+	plan := Removal{}
+	removed := map[string]bool{}
+	for _, id := range serviceIDs {
+		spec, ok := serviceMap[id]
+		if !ok {
+			return Removal{}, fmt.Errorf("unknown service: %s", id)
+		}
+		if !present[spec.Name] {
+			plan.Missing = append(plan.Missing, id)
+			continue
+		}
+		if !removed[spec.Name] {
+			removed[spec.Name] = true
+			plan.Services = append(plan.Services, id)
+		}
+	}
+
+	candidates := map[string]bool{}
+	for _, id := range plan.Services {
+		required := map[string]bool{id: true}
+		if err := ExpandRequiredServices(required, serviceMap); err != nil {
+			return Removal{}, err
+		}
+		for dep := range required {
+			spec := serviceMap[dep]
+			if dep != id && !spec.Selectable && present[spec.Name] && !removed[spec.Name] {
+				candidates[spec.Name] = true
+			}
+		}
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for _, name := range sortedKeys(candidates) {
+			if removed[name] || neededByRemaining(name, doc, removed, byName, serviceMap) {
+				continue
+			}
+			removed[name] = true
+			plan.Dependencies = append(plan.Dependencies, byName[name].ID)
+			changed = true
+		}
+	}
+	sort.Strings(plan.Dependencies)
+
+	declared := map[string]bool{}
+	for _, volume := range doc.Volumes {
+		declared[volume.Name] = true
+	}
+	referenced := map[string]bool{}
+	for _, svc := range doc.Services {
+		if removed[svc.Name] {
+			continue
+		}
+		for _, source := range svc.VolumeSources() {
+			referenced[source] = true
+		}
+	}
+	volumes := map[string]bool{}
+	for name := range removed {
+		for _, volume := range byName[name].NamedVolumes {
+			if declared[volume] && !referenced[volume] {
+				volumes[volume] = true
+			}
+		}
+	}
+	plan.Volumes = sortedKeys(volumes)
+
+	exported, err := exportedEntries(removed, byName)
+	if err != nil {
+		return Removal{}, err
+	}
+	secrets := map[string]bool{}
+	for name := range removed {
+		for _, secret := range byName[name].Secrets {
+			secrets[secret.Name] = true
+		}
+	}
+	mounted := map[string]bool{}
+	for _, svc := range doc.Services {
+		if removed[svc.Name] {
+			plan.Names = append(plan.Names, svc.Name)
+			continue
+		}
+		var env, unused []string
+		if _, ok := byName[svc.Name]; !ok {
+			// only the apps receive exported variables
+			env, unused = appRemoval(svc, exported, secrets)
+		}
+		if len(env) > 0 {
+			if plan.Env == nil {
+				plan.Env = map[string][]string{}
+			}
+			plan.Env[svc.Name] = env
+		}
+		for _, name := range svc.SecretNames() {
+			if !sliceContains(unused, name) {
+				mounted[name] = true
+				continue
+			}
+			if plan.ServiceSecrets == nil {
+				plan.ServiceSecrets = map[string][]string{}
+			}
+			plan.ServiceSecrets[svc.Name] = append(plan.ServiceSecrets[svc.Name], name)
+		}
+	}
+	for _, secret := range doc.Secrets {
+		if secrets[secret.Name] && !mounted[secret.Name] {
+			plan.Secrets = append(plan.Secrets, secret.Name)
+		}
+	}
+	sort.Strings(plan.Secrets)
+	return plan, nil
+}
+
+// appRemoval returns the variables of an app that hold the generated value of
+// an exported entry, and the secrets of the removed services those variables
+// pointed into that none of the variables the app keeps points into.
+func appRemoval(svc Service, exported map[string]bool, secrets map[string]bool) ([]string, []string) {
+	var env []string
+	unused := map[string]bool{}
+	for _, entry := range svc.Environment.Vars {
+		if !entry.HasValue || !exported[entry.Key+"="+entry.Value] {
+			continue
+		}
+		env = append(env, entry.Key)
+		for name := range secrets {
+			if strings.Contains(entry.Value, catalog.SecretMountPath(name)) {
+				unused[name] = true
+			}
+		}
+	}
+	for _, entry := range svc.Environment.Vars {
+		if sliceContains(env, entry.Key) {
+			continue
+		}
+		for name := range unused {
+			if strings.Contains(entry.Value, catalog.SecretMountPath(name)) {
+				delete(unused, name)
+			}
+		}
+	}
+	return env, sortedKeys(unused)
+}
+
+// exportedEntries collects the KEY=value entries the removed services export
+// to the apps, rendered the way the generator renders them.
+func exportedEntries(removed map[string]bool, byName map[string]catalog.ServiceSpec) (map[string]bool, error) {
+	entries := map[string]bool{}
+	for name := range removed {
+		spec := externalizeSecrets(byName[name], catalog.ServiceOverride{})
+		exported, err := spec.ExportedEnv()
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range exported {
+			entries[entry] = true
+		}
+	}
+	return entries, nil
+}
+
+// neededByRemaining reports whether a service that is not being removed
+// requires name through the catalog or lists it in depends_on.
+func neededByRemaining(name string, doc Document, removed map[string]bool, byName map[string]catalog.ServiceSpec, serviceMap map[string]catalog.ServiceSpec) bool {
+	for _, svc := range doc.Services {
+		if svc.Name == name || removed[svc.Name] {
+			continue
+		}
+		for _, dep := range svc.DependsOn.Names() {
+			if dep == name {
+				return true
+			}
+		}
+		spec, ok := byName[svc.Name]
+		if !ok {
+			continue
+		}
+		required := map[string]bool{spec.ID: true}
+		if err := ExpandRequiredServices(required, serviceMap); err != nil {
+			continue
+		}
+		if target, ok := byName[name]; ok && required[target.ID] && target.ID != spec.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// VolumeSources returns the named volumes and host paths mounted by the
+// service, for both the short ("source:target") and long volume syntax.
+func (s Service) VolumeSources() []string {
+	var sources []string
+	for _, mount := range s.Volumes {
+		if idx := strings.Index(mount, ":"); idx > 0 {
+			sources = append(sources, mount[:idx])
+		}
+	}
+	if raw, ok := s.Extra.Get("volumes"); ok {
+		raw = resolveAlias(raw)
+		for _, item := range raw.Content {
+			item = resolveAlias(item)
+			switch item.Kind {
+			case yaml.ScalarNode:
+				if idx := strings.Index(item.Value, ":"); idx > 0 {
+					sources = append(sources, item.Value[:idx])
+				}
+			case yaml.MappingNode:
+				for i := 0; i+1 < len(item.Content); i += 2 {
+					if item.Content[i].Value == "source" {
+						sources = append(sources, item.Content[i+1].Value)
+					}
+				}
+			}
+		}
+	}
+	return sources
+}
+
+// SecretNames returns the secrets mounted by the service, for both the short
+// and the long secret syntax.
+func (s Service) SecretNames() []string {
+	names := append([]string(nil), s.Secrets...)
+	if raw, ok := s.Extra.Get("secrets"); ok {
+		raw = resolveAlias(raw)
+		for _, item := range raw.Content {
+			item = resolveAlias(item)
+			switch item.Kind {
+			case yaml.ScalarNode:
+				names = append(names, item.Value)
+			case yaml.MappingNode:
+				for i := 0; i+1 < len(item.Content); i += 2 {
+					if item.Content[i].Value == "source" {
+						names = append(names, item.Content[i+1].Value)
+					}
+				}
+			}
+		}
+	}
+	return names
+}
+
+func sortedKeys(values map[string]bool) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,76 @@
+package compose
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"docker-wizard/internal/generator/catalog"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SecretsDirName is the project directory holding the files of the generated
+// compose secrets.
+const SecretsDirName = "secrets"
+
+// SecretFile returns the file of a generated secret, relative to the project
+// root as compose expects it.
+func SecretFile(name string) string {
+	return "./" + path.Join(SecretsDirName, name+".txt")
+}
+
+// SecretFiles returns the top-level secrets of doc whose file lives in the
+// secrets directory, by name. These are the files the writer manages.
+func SecretFiles(doc Document) map[string]string {
+	files := map[string]string{}
+	for _, secret := range doc.Secrets {
+		config := resolveAlias(secret.Config)
+		if config == nil || config.Kind != yaml.MappingNode {
+			continue
+		}
+		for i := 0; i+1 < len(config.Content); i += 2 {
+			if config.Content[i].Value != "file" {
+				continue
+			}
+			file, ok := decodeString(config.Content[i+1])
+			if !ok {
+				continue
+			}
+			file = path.Clean(file)
+			if path.Dir(file) == SecretsDirName && path.Base(file) != "." {
+				files[secret.Name] = file
+			}
+		}
+	}
+	return files
+}
+
+// secretResources declares the secrets the services use, sorted by name.
+func secretResources(names []string) []Resource {
+	names = uniqueStrings(names)
+	sort.Strings(names)
+	resources := make([]Resource, 0, len(names))
+	for _, name := range names {
+		config := mappingNode()
+		appendPair(config, "file", stringNode(SecretFile(name)))
+		resources = append(resources, Resource{Name: name, Config: config})
+	}
+	return resources
+}
+
+// usedSecrets returns the secrets of spec whose mount path appears in one of
+// the exported entries, which the app then needs mounted too.
+func usedSecrets(spec catalog.ServiceSpec, exported []string) []string {
+	var used []string
+	for _, secret := range spec.Secrets {
+		mount := catalog.SecretMountPath(secret.Name)
+		for _, entry := range exported {
+			if strings.Contains(entry, mount) {
+				used = append(used, secret.Name)
+				break
+			}
+		}
+	}
+	return used
+}
@@ -0,0 +1,203 @@
+package dockerfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"docker-wizard/internal/utils"
+
+	"gopkg.in/yaml.v3"
+)
+
+// App is an app of a monorepo: a subdirectory with its own language that is
+// built into its own image.
+type App struct {
+	// Name is the compose service name, derived from the directory name.
+	Name string
+	// Dir is the directory relative to the project root, with forward
+	// slashes.
+	Dir     string
+	Details LanguageDetails
+}
+
+// DefaultWorkspaces are the app directories scanned besides the workspaces
+// the project declares.
+var DefaultWorkspaces = []string{"apps/*", "services/*"}
+
+var (
+	goWorkUsePattern = regexp.MustCompile(`(?m)^\s*use\s+(?:\(([^)]*)\)|(\S+))`)
+	appNameInvalid   = regexp.MustCompile(`[^a-z0-9_.-]+`)
+)
+
+// DetectApps finds the apps of a monorepo. patterns are glob patterns of app
+// directories relative to root; when empty, the workspaces declared in
+// package.json, pnpm-workspace.yaml and go.work are scanned along with
+// DefaultWorkspaces. A pattern starting with "!" excludes directories.
+// Directories without a detected language are skipped, and so are Node and
+// Bun packages without a start script and Go modules without a main
+// package, which are shared libraries rather than apps. A project without
+// apps yields none and is built as a single app from root.
+func DetectApps(root string, patterns []string) ([]App, error) {
+	if root == "" {
+		return nil, fmt.Errorf("root directory is required")
+	}
+	if len(patterns) == 0 {
+		patterns = workspacePatterns(root)
+	}
+
+	dirs, err := expandWorkspaces(root, patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	var apps []App
+	for _, dir := range dirs {
+		details, err := DetectLanguage(filepath.Join(root, filepath.FromSlash(dir)))
+		if err != nil {
+			return nil, fmt.Errorf("detect %s: %w", dir, err)
+		}
+		if details.Type == LanguageUnknown || isLibrary(filepath.Join(root, filepath.FromSlash(dir)), details) {
+			continue
+		}
+		apps = append(apps, App{Dir: dir, Details: details})
+	}
+	nameApps(apps)
+	return apps, nil
+}
+
+// workspacePatterns collects the workspaces the project declares plus
+// DefaultWorkspaces.
+func workspacePatterns(root string) []string {
+	var patterns []string
+
+	var packageJSON struct {
+		Workspaces json.RawMessage `json:"workspaces"`
+	}
+	if json.Unmarshal([]byte(readFile(filepath.Join(root, "package.json"))), &packageJSON) == nil && len(packageJSON.Workspaces) > 0 {
+		// either a list of globs or {"packages": [...]}
+		var list []string
+		var object struct {
+			Packages []string `json:"packages"`
+		}
+		if json.Unmarshal(packageJSON.Workspaces, &list) == nil {
+			patterns = append(patterns, list...)
+		} else if json.Unmarshal(packageJSON.Workspaces, &object) == nil {
+			patterns = append(patterns, object.Packages...)
+		}
+	}
+
+	var pnpm struct {
+		Packages []string `yaml:"packages"`
+	}
+	if yaml.Unmarshal([]byte(readFile(filepath.Join(root, "pnpm-workspace.yaml"))), &pnpm) == nil {
+		patterns = append(patterns, pnpm.Packages...)
+	}
+
+	for _, match := range goWorkUsePattern.FindAllStringSubmatch(readFile(filepath.Join(root, "go.work")), -1) {
+		patterns = append(patterns, strings.Fields(match[1]+" "+match[2])...)
+	}
+
+	return append(patterns, DefaultWorkspaces...)
+}
+
+// expandWorkspaces returns the directories matched by the patterns, relative
+// to root and sorted. The root itself, hidden directories and dependency
+// directories are never apps.
+func expandWorkspaces(root string, patterns []string) ([]string, error) {
+	included := map[string]bool{}
+	excluded := map[string]bool{}
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		target := included
+		if strings.HasPrefix(pattern, "!") {
+			pattern, target = strings.TrimPrefix(pattern, "!"), excluded
+		}
+		pattern = strings.TrimSuffix(strings.TrimPrefix(pattern, "./"), "/")
+		if pattern == "" || pattern == "." {
+			continue
+		}
+		matches, err := filepath.Glob(filepath.Join(root, filepath.FromSlash(pattern)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid workspace pattern %q: %w", pattern, err)
+		}
+		for _, match := range matches {
+			rel, err := filepath.Rel(root, match)
+			if err != nil || rel == "." || strings.HasPrefix(rel, "..") || !utils.DirExists(match) {
+				continue
+			}
+			target[filepath.ToSlash(rel)] = true
+		}
+	}
+
+	dirs := make([]string, 0, len(included))
+	for dir := range included {
+		if excluded[dir] || skippedWorkspace(dir) {
+			continue
+		}
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+func skippedWorkspace(dir string) bool {
+	for _, part := range strings.Split(dir, "/") {
+		if strings.HasPrefix(part, ".") || part == "node_modules" || part == "vendor" {
+			return true
+		}
+	}
+	return false
+}
+
+// isLibrary reports whether a workspace is a package shared by the apps
+// rather than an app of its own.
+func isLibrary(dir string, details LanguageDetails) bool {
+	switch details.Type {
+	case LanguageNode, LanguageBun:
+		var parsed struct {
+			Scripts map[string]string `json:"scripts"`
+		}
+		if err := json.Unmarshal([]byte(readFile(filepath.Join(dir, "package.json"))), &parsed); err != nil {
+			return true
+		}
+		_, ok := parsed.Scripts["start"]
+		return !ok
+	case LanguageGo:
+		if utils.FileExists(filepath.Join(dir, "main.go")) {
+			return false
+		}
+		matches, _ := filepath.Glob(filepath.Join(dir, "cmd", "*", "main.go"))
+		return len(matches) == 0
+	}
+	return false
+}
+
+// nameApps gives every app a compose service name: its directory name, or
+// the whole directory path when two apps share a directory name.
+func nameApps(apps []App) {
+	counts := map[string]int{}
+	for _, app := range apps {
+		counts[appName(path.Base(app.Dir))]++
+	}
+	for i := range apps {
+		name := appName(path.Base(apps[i].Dir))
+		if counts[name] > 1 {
+			name = appName(strings.ReplaceAll(apps[i].Dir, "/", "-"))
+		}
+		apps[i].Name = name
+	}
+}
+
+// appName turns a directory name into a valid compose service name.
+func appName(dir string) string {
+	name := strings.Trim(appNameInvalid.ReplaceAllString(strings.ToLower(dir), "-"), "-_.")
+	if name == "" {
+		return "app"
+	}
+	return name
+}
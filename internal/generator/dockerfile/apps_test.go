@@ -0,0 +1,76 @@
+package dockerfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectAppsScansWorkspaces(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"package.json":                     `{"private": true, "workspaces": ["apps/*", "packages/*"]}`,
+		"apps/web/package.json":            `{"scripts": {"start": "next start -p 3000"}}`,
+		"apps/docs/package.json":           `{"scripts": {"start": "node server.js"}}`,
+		"packages/ui/package.json":         `{"name": "ui"}`,
+		"services/api/go.mod":              "module api\n\ngo 1.22\n",
+		"services/api/main.go":             "package main\n",
+		"services/web/requirements.txt":    "flask\n",
+		"services/web/app.py":              "",
+		"apps/node_modules/x/package.json": `{"scripts": {"start": "node x.js"}}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	apps, err := DetectApps(root, nil)
+	if err != nil {
+		t.Fatalf("DetectApps: %v", err)
+	}
+	want := []struct {
+		name string
+		dir  string
+		lang Language
+	}{
+		{"docs", "apps/docs", LanguageNode},
+		{"apps-web", "apps/web", LanguageNode},
+		{"api", "services/api", LanguageGo},
+		{"services-web", "services/web", LanguagePython},
+	}
+	if len(apps) != len(want) {
+		t.Fatalf("expected %d apps, got %+v", len(want), apps)
+	}
+	for i, app := range apps {
+		if app.Name != want[i].name || app.Dir != want[i].dir || app.Details.Type != want[i].lang {
+			t.Fatalf("app %d: expected %+v, got %s %s %s", i, want[i], app.Name, app.Dir, app.Details.Type)
+		}
+	}
+
+	apps, err = DetectApps(root, []string{"apps/*", "!apps/docs"})
+	if err != nil {
+		t.Fatalf("DetectApps: %v", err)
+	}
+	if len(apps) != 1 || apps[0].Name != "web" {
+		t.Fatalf("expected only the web app for explicit patterns, got %+v", apps)
+	}
+}
+
+func TestDetectAppsIgnoresSingleAppProjects(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module demo\n"), 0o644); err != nil {
+		t.Fatalf("write go.mod: %v", err)
+	}
+	apps, err := DetectApps(root, nil)
+	if err != nil {
+		t.Fatalf("DetectApps: %v", err)
+	}
+	if len(apps) != 0 {
+		t.Fatalf("expected no apps, got %+v", apps)
+	}
+}
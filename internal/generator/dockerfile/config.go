@@ -5,19 +5,100 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
+
+	"docker-wizard/internal/generator/catalog"
 )
 
 type templateCatalog struct {
 	Dockerfiles []templateSpec `json:"dockerfiles"`
 }
 
+// templateSpec is the catalog entry of a language, or of a framework of the
+// language: the Dockerfile template, either as plain lines or as named
+// stages, and the default develop.watch rules of the app service.
 type templateSpec struct {
-	Language      string   `json:"language"`
+	Language      string              `json:"language"`
+	Framework     string              `json:"framework,omitempty"`
+	TemplateLines []string            `json:"templateLines,omitempty"`
+	Stages        []stageSpec         `json:"stages,omitempty"`
+	Watch         []catalog.WatchRule `json:"watch,omitempty"`
+}
+
+// stageSpec is one build stage. The stage is rendered as "FROM <from> AS
+// <name>" followed by its lines; from may name an earlier stage. Platform is
+// the --platform flag of the FROM line, usually $BUILDPLATFORM for stages
+// that cross-compile for the TARGETOS and TARGETARCH build args.
+type stageSpec struct {
+	Name          string   `json:"name"`
+	From          string   `json:"from"`
+	Platform      string   `json:"platform,omitempty"`
 	TemplateLines []string `json:"templateLines"`
 }
 
-func loadTemplates(root string) (map[Language][]string, error) {
+// templateKey identifies a catalog entry; Framework is empty for the
+// language template.
+type templateKey struct {
+	Language  Language
+	Framework Framework
+}
+
+func (k templateKey) String() string {
+	if k.Framework == FrameworkNone {
+		return string(k.Language)
+	}
+	return string(k.Language) + "/" + string(k.Framework)
+}
+
+var stageNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_.-]*$`)
+
+// lines returns the template lines of the whole Dockerfile, with the stages
+// separated by a blank line.
+func (s templateSpec) lines() []string {
+	if len(s.Stages) == 0 {
+		return s.TemplateLines
+	}
+	var lines []string
+	for i, stage := range s.Stages {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		from := "FROM "
+		if stage.Platform != "" {
+			from += "--platform=" + stage.Platform + " "
+		}
+		lines = append(lines, from+stage.From+" AS "+stage.Name)
+		lines = append(lines, stage.TemplateLines...)
+	}
+	return lines
+}
+
+// templateFor returns the template of the detected framework, falling back
+// to the language template, and the key of the template it chose. A
+// framework template without watch rules uses those of the language.
+func templateFor(templates map[templateKey]templateSpec, details LanguageDetails) (templateSpec, templateKey, error) {
+	key := templateKey{Language: details.Type}
+	if key.Language == "" {
+		key.Language = LanguageUnknown
+	}
+	base, ok := templates[key]
+	if framework := details.ActiveFramework(); framework != FrameworkNone {
+		frameworkKey := templateKey{Language: key.Language, Framework: framework}
+		if spec, found := templates[frameworkKey]; found {
+			if len(spec.Watch) == 0 {
+				spec.Watch = base.Watch
+			}
+			return spec, frameworkKey, nil
+		}
+	}
+	if !ok {
+		return templateSpec{}, key, fmt.Errorf("missing dockerfile template for language: %s", key.Language)
+	}
+	return base, key, nil
+}
+
+func loadTemplates(root string) (map[templateKey]templateSpec, error) {
 	if root == "" {
 		return nil, fmt.Errorf("root directory is required")
 	}
@@ -63,26 +144,36 @@ func readTemplateData(root string) ([]byte, error) {
 	return data, nil
 }
 
-func normalizeTemplateCatalog(catalog templateCatalog) (map[Language][]string, error) {
-	if len(catalog.Dockerfiles) == 0 {
+func normalizeTemplateCatalog(templateCatalog templateCatalog) (map[templateKey]templateSpec, error) {
+	if len(templateCatalog.Dockerfiles) == 0 {
 		return nil, fmt.Errorf("dockerfile catalog has no templates")
 	}
 
-	templates := make(map[Language][]string, len(catalog.Dockerfiles))
-	for _, spec := range catalog.Dockerfiles {
-		lang, ok := parseLanguage(spec.Language)
+	templates := make(map[templateKey]templateSpec, len(templateCatalog.Dockerfiles))
+	for _, spec := range templateCatalog.Dockerfiles {
+		language, ok := parseLanguage(spec.Language)
 		if !ok {
 			return nil, fmt.Errorf("invalid dockerfile language: %s", spec.Language)
 		}
+		lang := templateKey{Language: language, Framework: Framework(spec.Framework)}
+		if lang.Framework != FrameworkNone && frameworkLanguages[lang.Framework] != language {
+			return nil, fmt.Errorf("invalid dockerfile framework for %s: %s", language, spec.Framework)
+		}
 		if _, exists := templates[lang]; exists {
 			return nil, fmt.Errorf("duplicate dockerfile language: %s", lang)
 		}
-		if len(spec.TemplateLines) == 0 {
+		if len(spec.Stages) > 0 && len(spec.TemplateLines) > 0 {
+			return nil, fmt.Errorf("dockerfile template for %s has both templateLines and stages", lang)
+		}
+		if err := validateStages(lang, spec.Stages); err != nil {
+			return nil, err
+		}
+		if len(spec.lines()) == 0 {
 			return nil, fmt.Errorf("dockerfile template for %s has no lines", lang)
 		}
 
 		hasContent := false
-		for _, line := range spec.TemplateLines {
+		for _, line := range spec.lines() {
 			if strings.TrimSpace(line) != "" {
 				hasContent = true
 				break
@@ -92,14 +183,41 @@ func normalizeTemplateCatalog(catalog templateCatalog) (map[Language][]string, e
 			return nil, fmt.Errorf("dockerfile template for %s has no content", lang)
 		}
 
+		for _, rule := range spec.Watch {
+			if err := rule.Validate(); err != nil {
+				return nil, fmt.Errorf("dockerfile catalog %s: %w", lang, err)
+			}
+		}
+
 		lines := make([]string, len(spec.TemplateLines))
 		copy(lines, spec.TemplateLines)
-		templates[lang] = lines
+		spec.TemplateLines = lines
+		templates[lang] = spec
 	}
 
 	return templates, nil
 }
 
+func validateStages(lang templateKey, stages []stageSpec) error {
+	seen := map[string]bool{}
+	for _, stage := range stages {
+		if !stageNamePattern.MatchString(stage.Name) {
+			return fmt.Errorf("dockerfile template for %s has invalid stage name %q", lang, stage.Name)
+		}
+		if seen[stage.Name] {
+			return fmt.Errorf("dockerfile template for %s has duplicate stage %s", lang, stage.Name)
+		}
+		if strings.TrimSpace(stage.From) == "" {
+			return fmt.Errorf("dockerfile template for %s stage %s has no from", lang, stage.Name)
+		}
+		if strings.ContainsAny(stage.Platform, " \t") {
+			return fmt.Errorf("dockerfile template for %s stage %s has invalid platform %q", lang, stage.Name, stage.Platform)
+		}
+		seen[stage.Name] = true
+	}
+	return nil
+}
+
 func parseLanguage(value string) (Language, bool) {
 	switch strings.ToLower(strings.TrimSpace(value)) {
 	case string(LanguageGo):
@@ -116,6 +234,14 @@ func parseLanguage(value string) (Language, bool) {
 		return LanguageJava, true
 	case string(LanguageDotNet):
 		return LanguageDotNet, true
+	case string(LanguageRust):
+		return LanguageRust, true
+	case string(LanguageElixir):
+		return LanguageElixir, true
+	case string(LanguageBun):
+		return LanguageBun, true
+	case string(LanguageDeno):
+		return LanguageDeno, true
 	case string(LanguageUnknown):
 		return LanguageUnknown, true
 	default:
@@ -1,8 +1,10 @@
 package dockerfile
 
 import (
+	"encoding/json"
 	"fmt"
 	"path/filepath"
+	"strings"
 
 	"docker-wizard/internal/utils"
 )
@@ -17,36 +19,94 @@ const (
 	LanguagePHP     Language = "php"
 	LanguageJava    Language = "java"
 	LanguageDotNet  Language = "dotnet"
+	LanguageRust    Language = "rust"
+	LanguageElixir  Language = "elixir"
+	LanguageBun     Language = "bun"
+	LanguageDeno    Language = "deno"
 	LanguageUnknown Language = "unknown"
 )
 
 type LanguageDetails struct {
-	Type            Language
-	HasGoMod        bool
-	HasGoSum        bool
-	HasPackageJSON  bool
-	HasPackageLock  bool
-	HasYarnLock     bool
-	HasPnpmLock     bool
+	Type Language
+	// Framework is the web framework detected for Type; see
+	// ActiveFramework.
+	Framework      Framework
+	HasGoMod       bool
+	HasGoSum       bool
+	HasPackageJSON bool
+	HasPackageLock bool
+	HasYarnLock    bool
+	HasPnpmLock    bool
+	HasBunLockb    bool
+	HasBunLock     bool
+	HasBunfig      bool
+	// DenoConfig is deno.json or deno.jsonc, whichever exists.
+	DenoConfig      string
+	HasDenoLock     bool
 	HasRequirements bool
 	HasPyProject    bool
 	HasPipfile      bool
-	HasGemfile      bool
-	HasGemfileLock  bool
-	HasComposerJSON bool
-	HasPHPVersion   bool
-	HasPomXML       bool
-	HasGradle       bool
-	HasGradleKts    bool
-	HasCSProj       bool
-	GoVersion       string
-	NodeVersion     string
-	PythonVersion   string
-	RubyVersion     string
-	PHPVersion      string
-	JavaVersion     string
-	DotNetVersion   string
-	DotNetProject   string
+	HasPipfileLock  bool
+	HasPoetryLock   bool
+	HasUVLock       bool
+	HasPDMLock      bool
+	// HasPoetry is set for a poetry lockfile or a [tool.poetry] table, and
+	// HasPythonBuildSystem when pyproject.toml declares [build-system], so
+	// the project itself can be installed.
+	HasPoetry            bool
+	HasPythonBuildSystem bool
+	HasGemfile           bool
+	HasGemfileLock       bool
+	HasComposerJSON      bool
+	HasPHPVersion        bool
+	HasPomXML            bool
+	HasGradle            bool
+	HasGradleKts         bool
+	HasCSProj            bool
+	HasCargoToml         bool
+	HasCargoLock         bool
+	HasMixExs            bool
+	HasMixLock           bool
+	// HasPhoenix is set when mix.exs depends on phoenix, and HasAssets when
+	// the project has front-end assets to build: assets/ for Phoenix,
+	// app/assets for Rails.
+	HasPhoenix  bool
+	HasAssets   bool
+	GoVersion   string
+	NodeVersion string
+	BunVersion  string
+	DenoVersion string
+	// DenoEntry is the module deno runs, and DenoStartTask and DenoDevTask
+	// report whether the deno config defines start and dev tasks.
+	DenoEntry     string
+	DenoStartTask bool
+	DenoDevTask   bool
+	PythonVersion string
+	// PythonScript is the first console script of [project.scripts] or
+	// [tool.poetry.scripts].
+	PythonScript  string
+	RubyVersion   string
+	PHPVersion    string
+	JavaVersion   string
+	DotNetVersion string
+	DotNetProject string
+	RustVersion   string
+	// RustBinary is the binary Cargo.toml builds: the first [[bin]] name,
+	// else the package name.
+	RustBinary    string
+	ElixirVersion string
+	ErlangVersion string
+	// ElixirApp is the OTP app name of mix.exs, which names the release.
+	ElixirApp string
+	// DjangoProject is the package of the Django wsgi.py and FastAPIApp the
+	// module:variable of the FastAPI app.
+	DjangoProject string
+	FastAPIApp    string
+	// NextStandalone is set when next.config.* builds the standalone server.
+	NextStandalone bool
+	// AppPort is the port the app listens on according to its sources or
+	// config, or "" when none was found.
+	AppPort string
 }
 
 func DetectLanguage(root string) (LanguageDetails, error) {
@@ -63,10 +123,31 @@ func DetectLanguage(root string) (LanguageDetails, error) {
 	details.HasPackageLock = utils.FileExists(filepath.Join(root, "package-lock.json"))
 	details.HasYarnLock = utils.FileExists(filepath.Join(root, "yarn.lock"))
 	details.HasPnpmLock = utils.FileExists(filepath.Join(root, "pnpm-lock.yaml"))
+	details.HasBunLockb = utils.FileExists(filepath.Join(root, "bun.lockb"))
+	details.HasBunLock = utils.FileExists(filepath.Join(root, "bun.lock"))
+	details.HasBunfig = utils.FileExists(filepath.Join(root, "bunfig.toml"))
+
+	for _, name := range []string{"deno.json", "deno.jsonc"} {
+		if utils.FileExists(filepath.Join(root, name)) {
+			details.DenoConfig = name
+			break
+		}
+	}
+	details.HasDenoLock = utils.FileExists(filepath.Join(root, "deno.lock"))
+	details.DenoEntry = detectDenoEntry(root)
+	details.DenoStartTask, details.DenoDevTask = detectDenoTasks(root, details.DenoConfig)
 
 	details.HasRequirements = utils.FileExists(filepath.Join(root, "requirements.txt"))
 	details.HasPyProject = utils.FileExists(filepath.Join(root, "pyproject.toml"))
 	details.HasPipfile = utils.FileExists(filepath.Join(root, "Pipfile"))
+	details.HasPipfileLock = utils.FileExists(filepath.Join(root, "Pipfile.lock"))
+	details.HasPoetryLock = utils.FileExists(filepath.Join(root, "poetry.lock"))
+	details.HasUVLock = utils.FileExists(filepath.Join(root, "uv.lock"))
+	details.HasPDMLock = utils.FileExists(filepath.Join(root, "pdm.lock"))
+	pyproject := readFile(filepath.Join(root, "pyproject.toml"))
+	details.HasPoetry = details.HasPoetryLock || tomlHasTable(pyproject, "tool.poetry")
+	details.HasPythonBuildSystem = tomlHasTable(pyproject, "build-system")
+	details.PythonScript = pythonScript(pyproject)
 
 	details.HasGemfile = utils.FileExists(filepath.Join(root, "Gemfile"))
 	details.HasGemfileLock = utils.FileExists(filepath.Join(root, "Gemfile.lock"))
@@ -77,6 +158,14 @@ func DetectLanguage(root string) (LanguageDetails, error) {
 	details.HasGradle = utils.FileExists(filepath.Join(root, "build.gradle"))
 	details.HasGradleKts = utils.FileExists(filepath.Join(root, "build.gradle.kts"))
 
+	details.HasCargoToml = utils.FileExists(filepath.Join(root, "Cargo.toml"))
+	details.HasCargoLock = utils.FileExists(filepath.Join(root, "Cargo.lock"))
+
+	details.HasMixExs = utils.FileExists(filepath.Join(root, "mix.exs"))
+	details.HasMixLock = utils.FileExists(filepath.Join(root, "mix.lock"))
+	details.HasPhoenix = details.HasMixExs && strings.Contains(readFile(filepath.Join(root, "mix.exs")), "{:phoenix,")
+	details.HasAssets = utils.DirExists(filepath.Join(root, "assets")) || utils.DirExists(filepath.Join(root, "app", "assets"))
+
 	csproj, err := filepath.Glob(filepath.Join(root, "*.csproj"))
 	if err != nil {
 		return LanguageDetails{}, fmt.Errorf("detect .csproj: %w", err)
@@ -88,15 +177,25 @@ func DetectLanguage(root string) (LanguageDetails, error) {
 
 	details.GoVersion = detectGoVersion(root)
 	details.NodeVersion = detectNodeVersion(root)
+	details.BunVersion = detectBunVersion(root)
+	details.DenoVersion = detectDenoVersion(root)
 	details.PythonVersion = detectPythonVersion(root)
 	details.RubyVersion = detectRubyVersion(root)
 	details.PHPVersion = detectPHPVersion(root)
 	details.JavaVersion = detectJavaVersion(root)
 	details.DotNetVersion = detectDotNetVersion(root)
+	details.RustVersion = detectRustVersion(root)
+	details.RustBinary = detectRustBinary(root)
+	details.ElixirVersion, details.ErlangVersion = detectElixirVersions(root)
+	details.ElixirApp = detectElixirApp(root)
 
 	switch {
 	case details.HasGoMod:
 		details.Type = LanguageGo
+	case details.DenoConfig != "" || details.HasDenoLock:
+		details.Type = LanguageDeno
+	case details.HasBunLockb || details.HasBunLock || details.HasBunfig:
+		details.Type = LanguageBun
 	case details.HasPackageJSON:
 		details.Type = LanguageNode
 	case details.HasRequirements || details.HasPyProject || details.HasPipfile:
@@ -109,13 +208,36 @@ func DetectLanguage(root string) (LanguageDetails, error) {
 		details.Type = LanguageJava
 	case details.HasCSProj:
 		details.Type = LanguageDotNet
+	case details.HasCargoToml:
+		details.Type = LanguageRust
+	case details.HasMixExs:
+		details.Type = LanguageElixir
 	default:
 		details.Type = LanguageUnknown
 	}
+	details.Framework = detectFramework(root, details.Type)
+	switch details.Framework {
+	case FrameworkDjango:
+		details.DjangoProject = detectDjangoProject(root)
+	case FrameworkFastAPI:
+		details.FastAPIApp = detectFastAPIApp(root)
+	case FrameworkNext:
+		details.NextStandalone = detectNextStandalone(root)
+	}
+	details.AppPort = detectAppPort(root, details.Type)
 
 	return details, nil
 }
 
+// OverrideLanguage returns details with the language replaced by language
+// and the app port detected again, since the port is read from the sources
+// of the language.
+func OverrideLanguage(root string, details LanguageDetails, language Language) LanguageDetails {
+	details.Type = language
+	details.AppPort = detectAppPort(root, language)
+	return details
+}
+
 func trimExt(name string) string {
 	ext := filepath.Ext(name)
 	if ext == "" {
@@ -123,3 +245,30 @@ func trimExt(name string) string {
 	}
 	return name[:len(name)-len(ext)]
 }
+
+// detectDenoEntry returns the first usual entry module that exists, or "".
+func detectDenoEntry(root string) string {
+	for _, name := range []string{"main.ts", "main.js", "server.ts", "mod.ts", "src/main.ts"} {
+		if utils.FileExists(filepath.Join(root, filepath.FromSlash(name))) {
+			return name
+		}
+	}
+	return ""
+}
+
+// detectDenoTasks reports whether the deno config defines start and dev
+// tasks. A deno.jsonc with comments is not read.
+func detectDenoTasks(root string, config string) (bool, bool) {
+	if config == "" {
+		return false, false
+	}
+	var parsed struct {
+		Tasks map[string]string `json:"tasks"`
+	}
+	if err := json.Unmarshal([]byte(readFile(filepath.Join(root, config))), &parsed); err != nil {
+		return false, false
+	}
+	_, start := parsed.Tasks["start"]
+	_, dev := parsed.Tasks["dev"]
+	return start, dev
+}
@@ -5,57 +5,129 @@ import (
 	"fmt"
 	"strings"
 	"text/template"
+
+	"docker-wizard/internal/generator/catalog"
 )
 
 func Dockerfile(root string, details LanguageDetails) (string, error) {
+	return DockerfileWithApp(root, details, catalog.AppSettings{})
+}
+
+// DockerfileWithApp renders the Dockerfile with the app port and start
+// command taken from app, falling back to the detected port and the template
+// defaults. A build target must name a stage of the rendered Dockerfile,
+// since compose builds the app with it.
+func DockerfileWithApp(root string, details LanguageDetails, app catalog.AppSettings) (string, error) {
 	templates, err := loadTemplates(root)
 	if err != nil {
 		return "", err
 	}
 
-	language := details.Type
-	if language == "" {
-		language = LanguageUnknown
+	spec, language, err := templateFor(templates, details)
+	if err != nil {
+		return "", err
 	}
 
-	templateLines, ok := templates[language]
-	if !ok {
-		return "", fmt.Errorf("missing dockerfile template for language: %s", language)
+	data := templateDataFromDetails(details)
+	switch {
+	case app.Port != "":
+		data.AppPort = containerPort(app.Port)
+	case details.AppPort != "":
+		data.AppPort = details.AppPort
 	}
+	data.AppCommand = escapeDoubleQuoted(app.Command)
+	data.BunDevCommand = bunDevCommand(data.AppCommand)
 
-	content, err := renderTemplateLines(templateLines, templateDataFromDetails(details))
+	content, err := renderTemplateLines(spec.lines(), data)
 	if err != nil {
 		return "", fmt.Errorf("render dockerfile template for %s: %w", language, err)
 	}
+	if app.Target != "" && !declaresStage(content, app.Target) {
+		return "", fmt.Errorf("build target %q is not a stage of the %s Dockerfile", app.Target, language)
+	}
 
 	return content, nil
 }
 
+// DevStage is the stage the templates declare for local development: the
+// toolchain plus a live-reload runner.
+const DevStage = "dev"
+
+// DevTarget returns DevStage when the Dockerfile declares it, else "".
+func DevTarget(content string) string {
+	if declaresStage(content, DevStage) {
+		return DevStage
+	}
+	return ""
+}
+
+// declaresStage reports whether a FROM instruction of content names the
+// stage.
+func declaresStage(content string, stage string) bool {
+	_, ok := ParseDocument(content).Stage(stage)
+	return ok
+}
+
 type templateData struct {
-	HasGoSum           bool
-	GoVersion          string
-	HasPackageLock     bool
-	HasYarnLock        bool
-	HasPnpmLock        bool
-	NodeVersion        string
-	NodeInstallCommand string
-	NodeStartCommand   string
-	HasRequirements    bool
-	PythonVersion      string
-	HasGemfile         bool
-	HasGemfileLock     bool
-	RubyVersion        string
-	HasComposerJSON    bool
-	PHPVersion         string
-	HasPomXML          bool
-	HasGradle          bool
-	HasGradleKts       bool
-	JavaVersion        string
-	DotNetVersion      string
-	DotNetEntryDLL     string
-}
-
-func versionOrDefault(value string, fallback string) string {
+	HasGoSum             bool
+	GoVersion            string
+	HasPackageLock       bool
+	HasYarnLock          bool
+	HasPnpmLock          bool
+	NodeVersion          string
+	NodeInstallCommand   string
+	NodeStartCommand     string
+	NodeRunCommand       string
+	HasBunLockb          bool
+	HasBunLock           bool
+	HasBunfig            bool
+	BunVersion           string
+	BunDevCommand        string
+	DenoVersion          string
+	DenoManifests        string
+	DenoEntry            string
+	DenoStartCommand     string
+	DenoDevCommand       string
+	HasRequirements      bool
+	HasPythonBuildSystem bool
+	PythonVersion        string
+	// PythonToolInstall installs the package manager, PythonManifests are
+	// the files the dependencies are resolved from, PythonInstallCommand
+	// installs only the dependencies so they are cached apart from the
+	// sources, and PythonProjectInstall installs the project after them.
+	PythonToolInstall    string
+	PythonManifests      string
+	PythonInstallCommand string
+	PythonProjectInstall string
+	PythonStartCommand   string
+	HasGemfile           bool
+	HasGemfileLock       bool
+	RubyVersion          string
+	HasComposerJSON      bool
+	PHPVersion           string
+	HasPomXML            bool
+	HasGradle            bool
+	HasGradleKts         bool
+	JavaVersion          string
+	DotNetVersion        string
+	DotNetEntryDLL       string
+	HasCargoLock         bool
+	RustVersion          string
+	RustBinary           string
+	HasMixLock           bool
+	HasPhoenix           bool
+	HasAssets            bool
+	ElixirVersion        string
+	ErlangVersion        string
+	ElixirRelease        string
+	DjangoProject        string
+	FastAPIApp           string
+	NextStandalone       bool
+	AppPort              string
+	AppCommand           string
+}
+
+func valueOrDefault(value string, fallback string) string {
 	if value == "" {
 		return fallback
 	}
@@ -69,31 +141,83 @@ func templateDataFromDetails(details LanguageDetails) templateData {
 	}
 
 	return templateData{
-		HasGoSum:           details.HasGoSum,
-		GoVersion:          versionOrDefault(details.GoVersion, "1.25"),
-		HasPackageLock:     details.HasPackageLock,
-		HasYarnLock:        details.HasYarnLock,
-		HasPnpmLock:        details.HasPnpmLock,
-		NodeVersion:        versionOrDefault(details.NodeVersion, "20"),
-		NodeInstallCommand: nodeInstallCommand(details),
-		NodeStartCommand:   nodeStartCommand(details),
-		HasRequirements:    details.HasRequirements,
-		PythonVersion:      versionOrDefault(details.PythonVersion, "3.12"),
-		HasGemfile:         details.HasGemfile,
-		HasGemfileLock:     details.HasGemfileLock,
-		RubyVersion:        versionOrDefault(details.RubyVersion, "3.3"),
-		HasComposerJSON:    details.HasComposerJSON,
-		PHPVersion:         versionOrDefault(details.PHPVersion, "8.3"),
-		HasPomXML:          details.HasPomXML,
-		HasGradle:          details.HasGradle,
-		HasGradleKts:       details.HasGradleKts,
-		JavaVersion:        versionOrDefault(details.JavaVersion, "21"),
-		DotNetVersion:      versionOrDefault(details.DotNetVersion, "8.0"),
-		DotNetEntryDLL:     entryDLL,
+		HasGoSum:             details.HasGoSum,
+		GoVersion:            valueOrDefault(details.GoVersion, "1.25"),
+		HasPackageLock:       details.HasPackageLock,
+		HasYarnLock:          details.HasYarnLock,
+		HasPnpmLock:          details.HasPnpmLock,
+		NodeVersion:          valueOrDefault(details.NodeVersion, "20"),
+		NodeInstallCommand:   nodeInstallCommand(details),
+		NodeStartCommand:     nodeStartCommand(details),
+		NodeRunCommand:       nodeRunCommand(details),
+		HasBunLockb:          details.HasBunLockb,
+		HasBunLock:           details.HasBunLock,
+		HasBunfig:            details.HasBunfig,
+		BunVersion:           valueOrDefault(details.BunVersion, "1"),
+		DenoVersion:          valueOrDefault(details.DenoVersion, "2.1.4"),
+		DenoManifests:        denoManifests(details),
+		DenoEntry:            valueOrDefault(details.DenoEntry, "main.ts"),
+		DenoStartCommand:     denoStartCommand(details),
+		DenoDevCommand:       denoDevCommand(details),
+		HasRequirements:      details.HasRequirements,
+		PythonVersion:        valueOrDefault(details.PythonVersion, "3.12"),
+		PythonToolInstall:    pythonToolInstall(details),
+		PythonManifests:      pythonManifests(details),
+		PythonInstallCommand: pythonInstallCommand(details),
+		PythonProjectInstall: pythonProjectInstall(details),
+		PythonStartCommand:   pythonStartCommand(details),
+		HasGemfile:           details.HasGemfile,
+		HasGemfileLock:       details.HasGemfileLock,
+		RubyVersion:          valueOrDefault(details.RubyVersion, "3.3"),
+		HasComposerJSON:      details.HasComposerJSON,
+		PHPVersion:           valueOrDefault(details.PHPVersion, "8.3"),
+		HasPomXML:            details.HasPomXML,
+		HasGradle:            details.HasGradle,
+		HasGradleKts:         details.HasGradleKts,
+		JavaVersion:          valueOrDefault(details.JavaVersion, "21"),
+		DotNetVersion:        valueOrDefault(details.DotNetVersion, "8.0"),
+		DotNetEntryDLL:       entryDLL,
+		HasCargoLock:         details.HasCargoLock,
+		RustVersion:          valueOrDefault(details.RustVersion, "1.90"),
+		RustBinary:           valueOrDefault(details.RustBinary, "app"),
+		HasMixLock:           details.HasMixLock,
+		HasPhoenix:           details.HasPhoenix,
+		HasAssets:            details.HasAssets,
+		ElixirVersion:        valueOrDefault(details.ElixirVersion, "1.18"),
+		ErlangVersion:        details.ErlangVersion,
+		ElixirRelease:        valueOrDefault(details.ElixirApp, "app"),
+		DjangoProject:        valueOrDefault(details.DjangoProject, "app"),
+		FastAPIApp:           valueOrDefault(details.FastAPIApp, "main:app"),
+		NextStandalone:       details.NextStandalone,
+		AppPort:              defaultAppPort,
+	}
+}
+
+const defaultAppPort = "8080"
+
+// containerPort returns the container side of a "host:container" port.
+func containerPort(port string) string {
+	if idx := strings.LastIndex(port, ":"); idx >= 0 {
+		return port[idx+1:]
 	}
+	return port
+}
+
+// escapeDoubleQuoted makes a value safe inside the double-quoted
+// APP_START_CMD value of the templates. Docker expands variables in ENV
+// values at build time, so $ is escaped and left to the shell that runs the
+// command.
+func escapeDoubleQuoted(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`).Replace(value)
 }
 
 func nodeInstallCommand(details LanguageDetails) string {
+	if details.Type == LanguageBun {
+		if details.HasBunLockb || details.HasBunLock {
+			return "bun install --frozen-lockfile"
+		}
+		return "bun install"
+	}
 	if details.HasYarnLock {
 		return "yarn install --frozen-lockfile"
 	}
@@ -107,6 +231,9 @@ func nodeInstallCommand(details LanguageDetails) string {
 }
 
 func nodeStartCommand(details LanguageDetails) string {
+	if details.Type == LanguageBun {
+		return "bun run start"
+	}
 	if details.HasYarnLock {
 		return "yarn start"
 	}
@@ -116,6 +243,154 @@ func nodeStartCommand(details LanguageDetails) string {
 	return "npm start"
 }
 
+// bunDevCommand returns the command of the bun dev stage: the start
+// command, or a custom bun command, run in watch mode. Other commands run
+// unchanged.
+func bunDevCommand(command string) string {
+	if command == "" {
+		return "bun --watch run start"
+	}
+	if rest, ok := strings.CutPrefix(command, "bun "); ok {
+		return "bun --watch " + rest
+	}
+	return command
+}
+
+// denoManifests are the files deno resolves dependencies from.
+func denoManifests(details LanguageDetails) string {
+	var files []string
+	if details.DenoConfig != "" {
+		files = append(files, details.DenoConfig)
+	}
+	if details.HasDenoLock {
+		files = append(files, "deno.lock")
+	}
+	if details.HasPackageJSON {
+		files = append(files, "package.json")
+	}
+	return strings.Join(files, " ")
+}
+
+const denoPermissions = "--allow-net --allow-env --allow-read"
+
+func denoStartCommand(details LanguageDetails) string {
+	if details.DenoStartTask {
+		return "deno task start"
+	}
+	return "deno run " + denoPermissions + " " + valueOrDefault(details.DenoEntry, "main.ts")
+}
+
+func denoDevCommand(details LanguageDetails) string {
+	if details.DenoDevTask {
+		return "deno task dev"
+	}
+	return "deno run --watch " + denoPermissions + " " + valueOrDefault(details.DenoEntry, "main.ts")
+}
+
+// pythonManager returns the package manager of a Python project, preferring
+// the tool whose lockfile is present.
+func pythonManager(details LanguageDetails) string {
+	switch {
+	case details.HasUVLock:
+		return "uv"
+	case details.HasPoetry:
+		return "poetry"
+	case details.HasPDMLock:
+		return "pdm"
+	case details.HasPipfile || details.HasPipfileLock:
+		return "pipenv"
+	case details.HasRequirements:
+		return "pip"
+	}
+	return ""
+}
+
+func pythonToolInstall(details LanguageDetails) string {
+	switch manager := pythonManager(details); manager {
+	case "uv", "poetry", "pdm", "pipenv":
+		return "pip install --no-cache-dir " + manager
+	}
+	return ""
+}
+
+func pythonManifests(details LanguageDetails) string {
+	switch pythonManager(details) {
+	case "uv":
+		return "pyproject.toml uv.lock"
+	case "poetry":
+		if details.HasPoetryLock {
+			return "pyproject.toml poetry.lock"
+		}
+		return "pyproject.toml"
+	case "pdm":
+		return "pyproject.toml pdm.lock"
+	case "pipenv":
+		if details.HasPipfileLock {
+			return "Pipfile Pipfile.lock"
+		}
+		return "Pipfile"
+	case "pip":
+		return "requirements.txt"
+	}
+	return ""
+}
+
+// pythonInstallCommand installs the locked dependencies into the system
+// interpreter, without the project, so framework servers installed with pip
+// see them.
+func pythonInstallCommand(details LanguageDetails) string {
+	switch pythonManager(details) {
+	case "uv":
+		return "uv export --frozen --no-dev --no-emit-project --no-hashes -o /tmp/requirements.txt && uv pip install --system -r /tmp/requirements.txt"
+	case "poetry":
+		return "poetry config virtualenvs.create false && poetry install --no-root --only main --no-interaction"
+	case "pdm":
+		return "pdm export --prod --without-hashes -o /tmp/requirements.txt && pip install --no-cache-dir -r /tmp/requirements.txt"
+	case "pipenv":
+		if details.HasPipfileLock {
+			return "pipenv install --system --deploy"
+		}
+		return "pipenv install --system --skip-lock"
+	case "pip":
+		return "pip install --no-cache-dir -r requirements.txt"
+	}
+	return ""
+}
+
+// pythonProjectInstall installs a packaged project, which puts its console
+// scripts on the PATH. Dependencies come from pythonInstallCommand, except
+// for a pyproject.toml without a lockfile or requirements.
+func pythonProjectInstall(details LanguageDetails) string {
+	if !details.HasPythonBuildSystem {
+		return ""
+	}
+	if pythonManager(details) == "" {
+		return "pip install --no-cache-dir ."
+	}
+	return "pip install --no-cache-dir --no-deps ."
+}
+
+func pythonStartCommand(details LanguageDetails) string {
+	if details.PythonScript != "" && details.HasPythonBuildSystem {
+		return details.PythonScript
+	}
+	return "python main.py"
+}
+
+// nodeRunCommand runs a package.json script, for example "npm run" build.
+func nodeRunCommand(details LanguageDetails) string {
+	if details.Type == LanguageBun {
+		return "bun run"
+	}
+	if details.HasYarnLock {
+		return "yarn"
+	}
+	if details.HasPnpmLock {
+		return "pnpm"
+	}
+	return "npm run"
+}
+
 func renderTemplateLines(lines []string, data templateData) (string, error) {
 	if len(lines) == 0 {
 		return "", fmt.Errorf("template is empty")
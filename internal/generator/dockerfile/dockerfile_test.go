@@ -5,6 +5,8 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"docker-wizard/internal/generator/catalog"
 )
 
 func TestDockerfileNodeUsesNpmCIWithPackageLock(t *testing.T) {
@@ -43,6 +45,31 @@ func TestDockerfileNodeUsesNpmInstallWithoutLockfile(t *testing.T) {
 	}
 }
 
+func TestDockerfileGoCrossCompilesForTargetPlatform(t *testing.T) {
+	root := t.TempDir()
+	writeDockerfileCatalog(t, root)
+
+	details := LanguageDetails{Type: LanguageGo, GoVersion: "1.22"}
+	content, err := Dockerfile(root, details)
+	if err != nil {
+		t.Fatalf("dockerfile: %v", err)
+	}
+
+	for _, want := range []string{
+		"FROM --platform=$BUILDPLATFORM golang:1.22-alpine AS build",
+		"ARG TARGETOS TARGETARCH",
+		"RUN CGO_ENABLED=0 GOOS=$TARGETOS GOARCH=$TARGETARCH go build -o /out/app .",
+		"FROM alpine:3.20 AS runtime",
+	} {
+		if !strings.Contains(content, want) {
+			t.Fatalf("expected %q in go Dockerfile, got:\n%s", want, content)
+		}
+	}
+	if strings.Contains(content, "GOARCH=amd64") {
+		t.Fatalf("expected no hard-coded architecture, got:\n%s", content)
+	}
+}
+
 func TestDockerfileJavaUsesMavenMultiStage(t *testing.T) {
 	root := t.TempDir()
 	writeDockerfileCatalog(t, root)
@@ -53,7 +80,7 @@ func TestDockerfileJavaUsesMavenMultiStage(t *testing.T) {
 		t.Fatalf("dockerfile: %v", err)
 	}
 
-	if !strings.Contains(content, "FROM maven:3.9-eclipse-temurin-21 AS build") {
+	if !strings.Contains(content, "FROM --platform=$BUILDPLATFORM maven:3.9-eclipse-temurin-21 AS build") {
 		t.Fatalf("expected maven build stage")
 	}
 	if !strings.Contains(content, "COPY --from=build /out/app.jar /app/app.jar") {
@@ -74,9 +101,12 @@ func TestDockerfileDotNetUsesMultiStageAndProjectDLL(t *testing.T) {
 		t.Fatalf("dockerfile: %v", err)
 	}
 
-	if !strings.Contains(content, "FROM mcr.microsoft.com/dotnet/sdk:8.0 AS build") {
+	if !strings.Contains(content, "FROM --platform=$BUILDPLATFORM mcr.microsoft.com/dotnet/sdk:8.0 AS build") {
 		t.Fatalf("expected sdk build stage")
 	}
+	if !strings.Contains(content, `dotnet publish -c Release -a "$(echo $TARGETARCH | sed s/amd64/x64/)"`) {
+		t.Fatalf("expected publish for the target architecture")
+	}
 	if !strings.Contains(content, "FROM mcr.microsoft.com/dotnet/aspnet:8.0") {
 		t.Fatalf("expected aspnet runtime stage")
 	}
@@ -85,6 +115,329 @@ func TestDockerfileDotNetUsesMultiStageAndProjectDLL(t *testing.T) {
 	}
 }
 
+func TestDockerfileRustCachesDependenciesWithCargoChef(t *testing.T) {
+	root := t.TempDir()
+	writeDockerfileCatalog(t, root)
+
+	details := LanguageDetails{Type: LanguageRust, HasCargoLock: true, RustVersion: "1.82", RustBinary: "api"}
+	content, err := Dockerfile(root, details)
+	if err != nil {
+		t.Fatalf("dockerfile: %v", err)
+	}
+
+	for _, want := range []string{
+		"FROM --platform=$BUILDPLATFORM rust:1.82-slim-bookworm AS chef",
+		"RUN cargo chef prepare --recipe-path recipe.json",
+		"RUN xx-cargo chef cook --release --recipe-path recipe.json",
+		"RUN xx-cargo build --release --locked --bin api && mkdir -p /out && cp target/$(xx-cargo --print-target-triple)/release/api /out/app",
+		"FROM debian:bookworm-slim AS runtime",
+		"ENV APP_START_CMD=\"/app/app\"",
+	} {
+		if !strings.Contains(content, want) {
+			t.Fatalf("expected %q in rust Dockerfile, got:\n%s", want, content)
+		}
+	}
+	if DevTarget(content) != DevStage {
+		t.Fatalf("expected rust Dockerfile to declare the dev stage")
+	}
+}
+
+func TestDetectLanguageReadsRustToolchainAndBinary(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"Cargo.toml":          "[package]\nname = \"server\"\nrust-version = \"1.74\"\n\n[[bin]]\nname = \"api\" # entry point\npath = \"src/main.rs\"\n",
+		"Cargo.lock":          "",
+		"rust-toolchain.toml": "[toolchain]\nchannel = \"1.82.0\"\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	details, err := DetectLanguage(root)
+	if err != nil {
+		t.Fatalf("detect language: %v", err)
+	}
+	if details.Type != LanguageRust || !details.HasCargoLock {
+		t.Fatalf("expected rust with Cargo.lock, got %+v", details)
+	}
+	if details.RustVersion != "1.82" || details.RustBinary != "api" {
+		t.Fatalf("expected toolchain version and [[bin]] name, got %q and %q", details.RustVersion, details.RustBinary)
+	}
+
+	if err := os.Remove(filepath.Join(root, "rust-toolchain.toml")); err != nil {
+		t.Fatalf("remove rust-toolchain.toml: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "Cargo.toml"), []byte("[package]\nname = \"server\"\nrust-version = \"1.74\"\n"), 0o644); err != nil {
+		t.Fatalf("write Cargo.toml: %v", err)
+	}
+	details, err = DetectLanguage(root)
+	if err != nil {
+		t.Fatalf("detect language: %v", err)
+	}
+	if details.RustVersion != "1.74" || details.RustBinary != "server" {
+		t.Fatalf("expected rust-version and package name, got %q and %q", details.RustVersion, details.RustBinary)
+	}
+}
+
+func TestDockerfileElixirBuildsPhoenixRelease(t *testing.T) {
+	root := t.TempDir()
+	writeDockerfileCatalog(t, root)
+
+	details := LanguageDetails{Type: LanguageElixir, HasMixLock: true, HasPhoenix: true, HasAssets: true, ElixirVersion: "1.17", ErlangVersion: "27", ElixirApp: "shop", AppPort: "4000"}
+	content, err := Dockerfile(root, details)
+	if err != nil {
+		t.Fatalf("dockerfile: %v", err)
+	}
+
+	for _, want := range []string{
+		"FROM elixir:1.17-otp-27-slim AS build",
+		"ENV MIX_ENV=prod",
+		"COPY mix.exs mix.lock ./",
+		"RUN mix assets.deploy",
+		"RUN mix release --path /out",
+		"ENV APP_START_CMD=\"mix phx.server\"",
+		"FROM debian:bookworm-slim AS runtime",
+		"ENV PHX_SERVER=true PORT=4000",
+		"ENV APP_START_CMD=\"/app/bin/shop start\"",
+	} {
+		if !strings.Contains(content, want) {
+			t.Fatalf("expected %q in elixir Dockerfile, got:\n%s", want, content)
+		}
+	}
+
+	// assets/ alone does not make a Phoenix app with the assets.deploy alias
+	content, err = Dockerfile(root, LanguageDetails{Type: LanguageElixir, HasAssets: true, ElixirApp: "worker"})
+	if err != nil {
+		t.Fatalf("dockerfile: %v", err)
+	}
+	if strings.Contains(content, "assets.deploy") || strings.Contains(content, "PHX_SERVER") || !strings.Contains(content, "mix run --no-halt") {
+		t.Fatalf("expected plain mix release without Phoenix steps, got:\n%s", content)
+	}
+}
+
+func TestDetectLanguageReadsElixirToolVersions(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"mix.exs":            "defmodule Shop.MixProject do\n  def project do\n    [app: :shop, elixir: \"~> 1.15\"]\n  end\n  defp deps do\n    [{:phoenix, \"~> 1.7\"}]\n  end\nend\n",
+		".tool-versions":     "erlang 27.1.2\nelixir 1.17.3-otp-27\n",
+		"config/runtime.exs": "port = String.to_integer(System.get_env(\"PORT\") || \"4001\")\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("create %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	details, err := DetectLanguage(root)
+	if err != nil {
+		t.Fatalf("detect language: %v", err)
+	}
+	if details.Type != LanguageElixir || !details.HasPhoenix || details.HasAssets {
+		t.Fatalf("expected Phoenix app without assets, got %+v", details)
+	}
+	if details.ElixirVersion != "1.17" || details.ErlangVersion != "27" || details.ElixirApp != "shop" || details.AppPort != "4001" {
+		t.Fatalf("expected versions, app and port from the project, got %+v", details)
+	}
+}
+
+func TestDockerfilePythonInstallsWithPackageManager(t *testing.T) {
+	root := t.TempDir()
+	writeDockerfileCatalog(t, root)
+
+	tests := []struct {
+		name    string
+		details LanguageDetails
+		want    []string
+	}{
+		{
+			name:    "uv",
+			details: LanguageDetails{Type: LanguagePython, HasPyProject: true, HasUVLock: true, HasPythonBuildSystem: true, PythonScript: "serve"},
+			want: []string{
+				"RUN pip install --no-cache-dir uv\nCOPY pyproject.toml uv.lock ./\nRUN uv export --frozen --no-dev --no-emit-project --no-hashes -o /tmp/requirements.txt && uv pip install --system -r /tmp/requirements.txt\nCOPY . .\nRUN pip install --no-cache-dir --no-deps .\n",
+				"ENV APP_START_CMD=\"serve\"",
+			},
+		},
+		{
+			name:    "poetry without lockfile",
+			details: LanguageDetails{Type: LanguagePython, HasPyProject: true, HasPoetry: true},
+			want: []string{
+				"COPY pyproject.toml ./\nRUN poetry config virtualenvs.create false && poetry install --no-root --only main --no-interaction\nCOPY . .\n\n",
+				"ENV APP_START_CMD=\"python main.py\"",
+			},
+		},
+		{
+			name:    "pdm",
+			details: LanguageDetails{Type: LanguagePython, HasPyProject: true, HasPDMLock: true},
+			want:    []string{"COPY pyproject.toml pdm.lock ./\nRUN pdm export --prod --without-hashes -o /tmp/requirements.txt"},
+		},
+		{
+			name:    "pipenv",
+			details: LanguageDetails{Type: LanguagePython, HasPipfile: true, HasPipfileLock: true},
+			want:    []string{"COPY Pipfile Pipfile.lock ./\nRUN pipenv install --system --deploy\n"},
+		},
+		{
+			name:    "requirements",
+			details: LanguageDetails{Type: LanguagePython, HasRequirements: true},
+			want:    []string{"WORKDIR /app\nCOPY requirements.txt ./\nRUN pip install --no-cache-dir -r requirements.txt\nCOPY . .\n"},
+		},
+		{
+			name:    "pyproject only",
+			details: LanguageDetails{Type: LanguagePython, HasPyProject: true, HasPythonBuildSystem: true},
+			want:    []string{"WORKDIR /app\nCOPY . .\nRUN pip install --no-cache-dir .\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, err := Dockerfile(root, tt.details)
+			if err != nil {
+				t.Fatalf("dockerfile: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(content, want) {
+					t.Fatalf("expected %q, got:\n%s", want, content)
+				}
+			}
+		})
+	}
+}
+
+func TestDetectLanguageReadsPythonPackageManager(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"pyproject.toml": "[tool.poetry]\nname = \"svc\"\n\n[tool.poetry.scripts]\n# entry points\nsvc-serve = \"svc.main:run\"\n\n[build-system]\nrequires = [\"poetry-core\"]\n",
+		"poetry.lock":    "",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	details, err := DetectLanguage(root)
+	if err != nil {
+		t.Fatalf("detect language: %v", err)
+	}
+	if details.Type != LanguagePython || !details.HasPoetry || !details.HasPoetryLock || !details.HasPythonBuildSystem {
+		t.Fatalf("expected poetry project with a build system, got %+v", details)
+	}
+	if details.PythonScript != "svc-serve" {
+		t.Fatalf("expected svc-serve script, got %q", details.PythonScript)
+	}
+}
+
+func TestDockerfileBunUsesFrozenLockfile(t *testing.T) {
+	root := t.TempDir()
+	writeDockerfileCatalog(t, root)
+
+	details := LanguageDetails{Type: LanguageBun, HasPackageJSON: true, HasBunLock: true, BunVersion: "1.1"}
+	content, err := Dockerfile(root, details)
+	if err != nil {
+		t.Fatalf("dockerfile: %v", err)
+	}
+	for _, want := range []string{"FROM oven/bun:1.1-alpine AS build", "COPY bun.lock ./", "RUN bun install --frozen-lockfile", "ENV APP_START_CMD=\"bun --watch run start\"", "ENV APP_START_CMD=\"bun run start\""} {
+		if !strings.Contains(content, want) {
+			t.Fatalf("expected %q in bun Dockerfile, got:\n%s", want, content)
+		}
+	}
+}
+
+func TestDockerfileBunDevStageWatchesCustomCommand(t *testing.T) {
+	root := t.TempDir()
+	writeDockerfileCatalog(t, root)
+
+	details := LanguageDetails{Type: LanguageBun, HasPackageJSON: true}
+	cases := []struct {
+		command string
+		dev     string
+	}{
+		{command: "bun src/server.ts", dev: "bun --watch src/server.ts"},
+		{command: "node dist/server.js", dev: "node dist/server.js"},
+	}
+	for _, tc := range cases {
+		content, err := DockerfileWithApp(root, details, catalog.AppSettings{Command: tc.command})
+		if err != nil {
+			t.Fatalf("dockerfile: %v", err)
+		}
+		dev, ok := ParseDocument(content).Stage("dev")
+		if !ok {
+			t.Fatalf("expected a dev stage, got:\n%s", content)
+		}
+		env, _ := dev.Last("ENV")
+		if want := "APP_START_CMD=\"" + tc.dev + "\""; env.Args != want {
+			t.Fatalf("expected dev stage ENV %q, got %q", want, env.Args)
+		}
+	}
+}
+
+func TestDockerfileDenoCachesDependencies(t *testing.T) {
+	root := t.TempDir()
+	writeDockerfileCatalog(t, root)
+
+	details := LanguageDetails{Type: LanguageDeno, DenoConfig: "deno.json", HasDenoLock: true, DenoEntry: "server.ts", DenoDevTask: true}
+	content, err := Dockerfile(root, details)
+	if err != nil {
+		t.Fatalf("dockerfile: %v", err)
+	}
+	for _, want := range []string{
+		"FROM denoland/deno:2.1.4 AS build",
+		"COPY deno.json deno.lock ./\nRUN deno install\nCOPY . .\nRUN deno cache server.ts\n",
+		"ENV APP_START_CMD=\"deno task dev\"",
+		"ENV APP_START_CMD=\"deno run --allow-net --allow-env --allow-read server.ts\"",
+	} {
+		if !strings.Contains(content, want) {
+			t.Fatalf("expected %q in deno Dockerfile, got:\n%s", want, content)
+		}
+	}
+}
+
+func TestDetectLanguagePrefersBunAndDenoOverNode(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  Language
+	}{
+		{name: "bun lockfile", files: map[string]string{"package.json": `{"packageManager":"bun@1.1.38"}`, "bun.lockb": ""}, want: LanguageBun},
+		{name: "bunfig", files: map[string]string{"package.json": "{}", "bunfig.toml": ""}, want: LanguageBun},
+		{name: "deno config", files: map[string]string{"package.json": "{}", "deno.json": `{"tasks":{"start":"deno run -A main.ts"}}`}, want: LanguageDeno},
+		{name: "node", files: map[string]string{"package.json": "{}", "package-lock.json": ""}, want: LanguageNode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o644); err != nil {
+					t.Fatalf("write %s: %v", name, err)
+				}
+			}
+			details, err := DetectLanguage(root)
+			if err != nil {
+				t.Fatalf("detect language: %v", err)
+			}
+			if details.Type != tt.want {
+				t.Fatalf("expected %s, got %s", tt.want, details.Type)
+			}
+			switch tt.want {
+			case LanguageBun:
+				if tt.name == "bun lockfile" && details.BunVersion != "1.1" {
+					t.Fatalf("expected bun 1.1 from packageManager, got %q", details.BunVersion)
+				}
+			case LanguageDeno:
+				if !details.DenoStartTask || details.DenoDevTask {
+					t.Fatalf("expected only the start task, got %+v", details)
+				}
+			}
+		})
+	}
+}
+
 func TestDockerfileReturnsErrorWhenTemplateMissing(t *testing.T) {
 	root := t.TempDir()
 	configDir := filepath.Join(root, "config")
@@ -121,3 +474,164 @@ func writeDockerfileCatalog(t *testing.T, root string) {
 		t.Fatalf("write dockerfile catalog: %v", err)
 	}
 }
+
+func TestDockerfileWithAppSetsPortAndStartCommand(t *testing.T) {
+	root := t.TempDir()
+	writeDockerfileCatalog(t, root)
+
+	details := LanguageDetails{Type: LanguagePython}
+	content, err := DockerfileWithApp(root, details, catalog.AppSettings{Port: "3000:8000", Command: `gunicorn -b "0.0.0.0:8000" app:app`})
+	if err != nil {
+		t.Fatalf("dockerfile: %v", err)
+	}
+
+	if !strings.Contains(content, "EXPOSE 8000\n") {
+		t.Fatalf("expected container port to be exposed, got:\n%s", content)
+	}
+	if !strings.Contains(content, `ENV APP_START_CMD="gunicorn -b \"0.0.0.0:8000\" app:app"`) {
+		t.Fatalf("expected escaped start command, got:\n%s", content)
+	}
+
+	content, err = DockerfileWithApp(root, LanguageDetails{Type: LanguageNode}, catalog.AppSettings{Command: "node server.js --port $PORT"})
+	if err != nil {
+		t.Fatalf("dockerfile: %v", err)
+	}
+	if !strings.Contains(content, `ENV APP_START_CMD="node server.js --port \$PORT"`) {
+		t.Fatalf("expected the variable to be left to the shell, got:\n%s", content)
+	}
+
+	content, err = DockerfileWithApp(root, LanguageDetails{Type: LanguagePHP}, catalog.AppSettings{Port: "9000"})
+	if err != nil {
+		t.Fatalf("dockerfile: %v", err)
+	}
+	if !strings.Contains(content, `ENV APP_START_CMD="php -S 0.0.0.0:9000 -t public"`) {
+		t.Fatalf("expected default php command to follow the port, got:\n%s", content)
+	}
+}
+
+func TestDockerfileWithAppChecksBuildTarget(t *testing.T) {
+	root := t.TempDir()
+	writeDockerfileCatalog(t, root)
+
+	details := LanguageDetails{Type: LanguageGo}
+	if _, err := DockerfileWithApp(root, details, catalog.AppSettings{Target: "build"}); err != nil {
+		t.Fatalf("expected build stage to be accepted: %v", err)
+	}
+	if _, err := DockerfileWithApp(root, details, catalog.AppSettings{Target: "dev"}); err != nil {
+		t.Fatalf("expected dev stage to be accepted: %v", err)
+	}
+	if _, err := DockerfileWithApp(root, details, catalog.AppSettings{Target: "debug"}); err == nil {
+		t.Fatal("expected error for a target the Dockerfile does not declare")
+	}
+}
+
+func TestWatchRulesSkipsMissingPaths(t *testing.T) {
+	root := t.TempDir()
+	writeDockerfileCatalog(t, root)
+	for _, name := range []string{"package.json", "yarn.lock"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("{}"), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	rules, err := WatchRules(root, LanguageDetails{Type: LanguageNode})
+	if err != nil {
+		t.Fatalf("watch rules: %v", err)
+	}
+	var paths []string
+	for _, rule := range rules {
+		paths = append(paths, rule.Action+" "+rule.Path)
+	}
+	if strings.Join(paths, ",") != "rebuild package.json,rebuild yarn.lock" {
+		t.Fatalf("expected only rules for existing paths, got %v", paths)
+	}
+
+	if err := os.Mkdir(filepath.Join(root, "src"), 0o755); err != nil {
+		t.Fatalf("mkdir src: %v", err)
+	}
+	rules, err = WatchRules(root, LanguageDetails{Type: LanguageNode})
+	if err != nil {
+		t.Fatalf("watch rules: %v", err)
+	}
+	if len(rules) != 3 || rules[0].Action != "sync" || rules[0].Target != "/app/src" {
+		t.Fatalf("expected src to be synced into the image, got %+v", rules)
+	}
+}
+
+func TestNormalizeTemplateCatalogRejectsInvalidWatchRules(t *testing.T) {
+	_, err := normalizeTemplateCatalog(templateCatalog{Dockerfiles: []templateSpec{{
+		Language:      "node",
+		TemplateLines: []string{"FROM node"},
+		Watch:         []catalog.WatchRule{{Action: "sync", Path: "./src"}},
+	}}})
+	if err == nil || !strings.Contains(err.Error(), "needs a target") {
+		t.Fatalf("expected missing sync target error, got %v", err)
+	}
+}
+
+func TestDockerfileRendersNamedStages(t *testing.T) {
+	root := t.TempDir()
+	writeDockerfileCatalog(t, root)
+
+	for _, language := range []Language{LanguageGo, LanguageNode, LanguagePython, LanguageRuby, LanguagePHP, LanguageJava, LanguageDotNet} {
+		content, err := Dockerfile(root, LanguageDetails{Type: language})
+		if err != nil {
+			t.Fatalf("dockerfile %s: %v", language, err)
+		}
+		build := strings.Index(content, " AS build\n")
+		dev := strings.Index(content, " AS dev\n")
+		runtime := strings.Index(content, " AS runtime\n")
+		if build < 0 || dev < build || runtime < dev {
+			t.Fatalf("expected build, dev and runtime stages in order for %s, got:\n%s", language, content)
+		}
+		if DevTarget(content) != DevStage {
+			t.Fatalf("expected %s to declare the dev stage", language)
+		}
+	}
+
+	content, err := Dockerfile(root, LanguageDetails{Type: LanguageUnknown})
+	if err != nil {
+		t.Fatalf("dockerfile: %v", err)
+	}
+	if DevTarget(content) != "" {
+		t.Fatalf("expected the fallback template to have no dev stage, got:\n%s", content)
+	}
+}
+
+func TestNormalizeTemplateCatalogRejectsInvalidStages(t *testing.T) {
+	tests := []struct {
+		name   string
+		spec   templateSpec
+		errMsg string
+	}{
+		{
+			name: "duplicate stage",
+			spec: templateSpec{Language: "go", Stages: []stageSpec{
+				{Name: "build", From: "golang", TemplateLines: []string{"RUN true"}},
+				{Name: "build", From: "alpine", TemplateLines: []string{"RUN true"}},
+			}},
+			errMsg: "duplicate stage build",
+		},
+		{
+			name:   "missing from",
+			spec:   templateSpec{Language: "go", Stages: []stageSpec{{Name: "dev", TemplateLines: []string{"RUN true"}}}},
+			errMsg: "stage dev has no from",
+		},
+		{
+			name: "lines and stages",
+			spec: templateSpec{Language: "go", TemplateLines: []string{"FROM alpine"}, Stages: []stageSpec{
+				{Name: "dev", From: "alpine"},
+			}},
+			errMsg: "both templateLines and stages",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := normalizeTemplateCatalog(templateCatalog{Dockerfiles: []templateSpec{tt.spec}})
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Fatalf("expected %q error, got %v", tt.errMsg, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package dockerfile
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"docker-wizard/internal/utils"
+)
+
+// Framework is the web framework of a project. Frameworks refine the
+// Dockerfile template of their language; "" means none was detected.
+type Framework string
+
+const (
+	FrameworkNone       Framework = ""
+	FrameworkNext       Framework = "next"
+	FrameworkDjango     Framework = "django"
+	FrameworkFastAPI    Framework = "fastapi"
+	FrameworkRails      Framework = "rails"
+	FrameworkLaravel    Framework = "laravel"
+	FrameworkSpringBoot Framework = "spring-boot"
+)
+
+// frameworkLanguages maps each framework to the language it is written in.
+var frameworkLanguages = map[Framework]Language{
+	FrameworkNext:       LanguageNode,
+	FrameworkDjango:     LanguagePython,
+	FrameworkFastAPI:    LanguagePython,
+	FrameworkRails:      LanguageRuby,
+	FrameworkLaravel:    LanguagePHP,
+	FrameworkSpringBoot: LanguageJava,
+}
+
+var fastAPIAppPattern = regexp.MustCompile(`(?m)^(\w+)\s*=\s*FastAPI\(`)
+
+var nextStandalonePattern = regexp.MustCompile(`output\s*:\s*["']standalone["']`)
+
+// ActiveFramework returns the detected framework when it belongs to the
+// language of the details, so a language override drops it.
+func (d LanguageDetails) ActiveFramework() Framework {
+	if d.Framework == FrameworkNone || frameworkLanguages[d.Framework] != d.Type {
+		return FrameworkNone
+	}
+	return d.Framework
+}
+
+// detectFramework looks for the marker files and dependencies of the
+// frameworks of language.
+func detectFramework(root string, language Language) Framework {
+	switch language {
+	case LanguageNode:
+		if nodeDependsOn(root, "next") {
+			return FrameworkNext
+		}
+	case LanguagePython:
+		if utils.FileExists(filepath.Join(root, "manage.py")) {
+			return FrameworkDjango
+		}
+		for _, name := range []string{"requirements.txt", "pyproject.toml"} {
+			content := strings.ToLower(readFile(filepath.Join(root, name)))
+			if strings.Contains(content, "fastapi") {
+				return FrameworkFastAPI
+			}
+		}
+	case LanguageRuby:
+		if utils.FileExists(filepath.Join(root, "config", "application.rb")) {
+			return FrameworkRails
+		}
+	case LanguagePHP:
+		if utils.FileExists(filepath.Join(root, "artisan")) {
+			return FrameworkLaravel
+		}
+	case LanguageJava:
+		if strings.Contains(readFile(filepath.Join(root, "pom.xml")), "spring-boot") {
+			return FrameworkSpringBoot
+		}
+		for _, name := range []string{"build.gradle", "build.gradle.kts"} {
+			if strings.Contains(readFile(filepath.Join(root, name)), "org.springframework.boot") {
+				return FrameworkSpringBoot
+			}
+		}
+	}
+	return FrameworkNone
+}
+
+func nodeDependsOn(root string, name string) bool {
+	var parsed struct {
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	if err := json.Unmarshal([]byte(readFile(filepath.Join(root, "package.json"))), &parsed); err != nil {
+		return false
+	}
+	if _, ok := parsed.Dependencies[name]; ok {
+		return true
+	}
+	_, ok := parsed.DevDependencies[name]
+	return ok
+}
+
+// detectDjangoProject returns the package holding the project's wsgi.py and
+// settings.py, which gunicorn serves.
+func detectDjangoProject(root string) string {
+	matches, err := filepath.Glob(filepath.Join(root, "*", "wsgi.py"))
+	if err != nil {
+		return ""
+	}
+	for _, match := range matches {
+		dir := filepath.Dir(match)
+		if utils.FileExists(filepath.Join(dir, "settings.py")) {
+			return filepath.Base(dir)
+		}
+	}
+	return ""
+}
+
+// detectFastAPIApp returns the module:variable of the FastAPI app in the
+// usual entry files, for example main:app.
+func detectFastAPIApp(root string) string {
+	for _, name := range []string{"main.py", "app.py", filepath.Join("app", "main.py"), filepath.Join("src", "main.py")} {
+		match := fastAPIAppPattern.FindStringSubmatch(readFile(filepath.Join(root, name)))
+		if match == nil {
+			continue
+		}
+		module := strings.ReplaceAll(strings.TrimSuffix(filepath.ToSlash(name), ".py"), "/", ".")
+		return module + ":" + match[1]
+	}
+	return ""
+}
+
+// detectNextStandalone reports whether next.config.* sets output: "standalone",
+// without which the build has no .next/standalone server to copy.
+func detectNextStandalone(root string) bool {
+	for _, name := range []string{"next.config.js", "next.config.mjs", "next.config.ts", "next.config.cjs"} {
+		if nextStandalonePattern.MatchString(readFile(filepath.Join(root, name))) {
+			return true
+		}
+	}
+	return false
+}
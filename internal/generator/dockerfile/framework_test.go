@@ -0,0 +1,227 @@
+package dockerfile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDetectLanguageFindsFramework(t *testing.T) {
+	tests := []struct {
+		name      string
+		files     map[string]string
+		want      Framework
+		checkData func(t *testing.T, details LanguageDetails)
+	}{
+		{
+			name:  "next dependency",
+			files: map[string]string{"package.json": `{"dependencies":{"next":"14.2.0","react":"18.3.0"}}`},
+			want:  FrameworkNext,
+			checkData: func(t *testing.T, details LanguageDetails) {
+				if details.NextStandalone {
+					t.Fatalf("expected no standalone output without next.config")
+				}
+			},
+		},
+		{
+			name: "next standalone output",
+			files: map[string]string{
+				"package.json":    `{"dependencies":{"next":"14.2.0"}}`,
+				"next.config.mjs": "const nextConfig = {\n  output: 'standalone',\n};\n\nexport default nextConfig;\n",
+			},
+			want: FrameworkNext,
+			checkData: func(t *testing.T, details LanguageDetails) {
+				if !details.NextStandalone {
+					t.Fatalf("expected standalone output from next.config.mjs")
+				}
+			},
+		},
+		{
+			name: "django manage.py",
+			files: map[string]string{
+				"requirements.txt":   "Django==5.0\n",
+				"manage.py":          "#!/usr/bin/env python\n",
+				"mysite/settings.py": "",
+				"mysite/wsgi.py":     "",
+			},
+			want: FrameworkDjango,
+			checkData: func(t *testing.T, details LanguageDetails) {
+				if details.DjangoProject != "mysite" {
+					t.Fatalf("expected django project mysite, got %q", details.DjangoProject)
+				}
+			},
+		},
+		{
+			name: "fastapi in pyproject",
+			files: map[string]string{
+				"pyproject.toml": "[project]\ndependencies = [\"FastAPI>=0.110\", \"uvicorn\"]\n",
+				"app/main.py":    "from fastapi import FastAPI\n\napi = FastAPI()\n",
+			},
+			want: FrameworkFastAPI,
+			checkData: func(t *testing.T, details LanguageDetails) {
+				if details.FastAPIApp != "app.main:api" {
+					t.Fatalf("expected app.main:api, got %q", details.FastAPIApp)
+				}
+			},
+		},
+		{
+			name:  "rails application",
+			files: map[string]string{"Gemfile": "gem \"rails\"\n", "config/application.rb": ""},
+			want:  FrameworkRails,
+		},
+		{
+			name:  "laravel artisan",
+			files: map[string]string{"composer.json": "{}", "artisan": ""},
+			want:  FrameworkLaravel,
+		},
+		{
+			name:  "spring boot gradle plugin",
+			files: map[string]string{"build.gradle.kts": "plugins {\n  id(\"org.springframework.boot\") version \"3.3.0\"\n}\n"},
+			want:  FrameworkSpringBoot,
+		},
+		{
+			name:  "plain node",
+			files: map[string]string{"package.json": `{"dependencies":{"express":"4.19.0"}}`},
+			want:  FrameworkNone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			for name, content := range tt.files {
+				path := filepath.Join(root, filepath.FromSlash(name))
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatalf("create %s: %v", name, err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+					t.Fatalf("write %s: %v", name, err)
+				}
+			}
+			details, err := DetectLanguage(root)
+			if err != nil {
+				t.Fatalf("detect language: %v", err)
+			}
+			if details.Framework != tt.want {
+				t.Fatalf("expected framework %q, got %q", tt.want, details.Framework)
+			}
+			if tt.checkData != nil {
+				tt.checkData(t, details)
+			}
+		})
+	}
+}
+
+func TestDockerfileUsesFrameworkTemplate(t *testing.T) {
+	root := t.TempDir()
+	writeDockerfileCatalog(t, root)
+
+	tests := []struct {
+		name    string
+		details LanguageDetails
+		want    []string
+	}{
+		{
+			name:    "next standalone",
+			details: LanguageDetails{Type: LanguageNode, Framework: FrameworkNext, HasPnpmLock: true, NextStandalone: true, AppPort: "3000"},
+			want:    []string{"RUN mkdir -p public && pnpm build", "COPY --from=build /app/.next/standalone ./", "ENV APP_START_CMD=\"node server.js\""},
+		},
+		{
+			name:    "next start without standalone output",
+			details: LanguageDetails{Type: LanguageNode, Framework: FrameworkNext, AppPort: "3000"},
+			want:    []string{"COPY --from=build /app ./", "ENV APP_START_CMD=\"npx next start -H 0.0.0.0 -p 3000\""},
+		},
+		{
+			name:    "django gunicorn",
+			details: LanguageDetails{Type: LanguagePython, Framework: FrameworkDjango, DjangoProject: "mysite"},
+			want:    []string{"ENV APP_START_CMD=\"gunicorn mysite.wsgi:application --bind 0.0.0.0:8080\"", "ENV APP_START_CMD=\"python manage.py runserver 0.0.0.0:8080\""},
+		},
+		{
+			name:    "rails puma",
+			details: LanguageDetails{Type: LanguageRuby, Framework: FrameworkRails, HasGemfileLock: true},
+			want:    []string{"COPY Gemfile Gemfile.lock ./", "ENV APP_START_CMD=\"bundle exec puma -b tcp://0.0.0.0:8080\""},
+		},
+		{
+			name:    "laravel php-fpm and nginx",
+			details: LanguageDetails{Type: LanguagePHP, Framework: FrameworkLaravel},
+			want:    []string{"COPY <<'EOF' /etc/nginx/http.d/default.conf", "    listen 8080;", "ENV APP_START_CMD=\"php-fpm -D && nginx -g 'daemon off;'\""},
+		},
+		{
+			name:    "spring boot layers",
+			details: LanguageDetails{Type: LanguageJava, Framework: FrameworkSpringBoot, HasPomXML: true, JavaVersion: "21"},
+			want:    []string{"RUN java -Djarmode=layertools -jar app.jar extract", "COPY --from=extract /out/application/ ./"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, err := Dockerfile(root, tt.details)
+			if err != nil {
+				t.Fatalf("dockerfile: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(content, want) {
+					t.Fatalf("expected %q, got:\n%s", want, content)
+				}
+			}
+			doc := ParseDocument(content)
+			if final := doc.Stages[len(doc.Stages)-1]; final.Name != "runtime" {
+				t.Fatalf("expected runtime as the final stage, got %q", final.Name)
+			}
+		})
+	}
+}
+
+func TestDockerfileFallsBackToLanguageTemplate(t *testing.T) {
+	root := t.TempDir()
+	configDir := filepath.Join(root, "config")
+	if err := os.Mkdir(configDir, 0o755); err != nil {
+		t.Fatalf("create config directory: %v", err)
+	}
+	content := `{"dockerfiles":[
+		{"language":"python","templateLines":["FROM python:3.12-slim"],"watch":[{"action":"rebuild","path":"requirements.txt"}]},
+		{"language":"python","framework":"fastapi","templateLines":["FROM python:3.12-slim AS fastapi"]}
+	]}`
+	if err := os.WriteFile(filepath.Join(configDir, "dockerfiles.json"), []byte(content), 0o644); err != nil {
+		t.Fatalf("write dockerfile catalog: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "requirements.txt"), []byte("fastapi\n"), 0o644); err != nil {
+		t.Fatalf("write requirements.txt: %v", err)
+	}
+
+	for _, tt := range []struct {
+		details LanguageDetails
+		want    string
+	}{
+		{LanguageDetails{Type: LanguagePython, Framework: FrameworkFastAPI}, "FROM python:3.12-slim AS fastapi\n"},
+		{LanguageDetails{Type: LanguagePython, Framework: FrameworkDjango}, "FROM python:3.12-slim\n"},
+		// a language override drops the framework of the detected language
+		{LanguageDetails{Type: LanguagePython, Framework: FrameworkNext}, "FROM python:3.12-slim\n"},
+	} {
+		got, err := Dockerfile(root, tt.details)
+		if err != nil {
+			t.Fatalf("dockerfile: %v", err)
+		}
+		if got != tt.want {
+			t.Fatalf("expected %q for %s, got %q", tt.want, tt.details.Framework, got)
+		}
+	}
+
+	rules, err := WatchRules(root, LanguageDetails{Type: LanguagePython, Framework: FrameworkFastAPI})
+	if err != nil {
+		t.Fatalf("watch rules: %v", err)
+	}
+	if len(rules) != 1 || rules[0].Path != "requirements.txt" {
+		t.Fatalf("expected the framework to use the language watch rules, got %v", rules)
+	}
+}
+
+func TestNormalizeTemplateCatalogRejectsFrameworkOfOtherLanguage(t *testing.T) {
+	_, err := normalizeTemplateCatalog(templateCatalog{Dockerfiles: []templateSpec{
+		{Language: "ruby", Framework: "django", TemplateLines: []string{"FROM ruby:3.3"}},
+	}})
+	if err == nil || !strings.Contains(err.Error(), "invalid dockerfile framework") {
+		t.Fatalf("expected invalid framework error, got %v", err)
+	}
+}
@@ -0,0 +1,308 @@
+package dockerfile
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// Document is a parsed Dockerfile. It keeps the source lines, so callers can
+// edit the file in place through the line ranges of its instructions without
+// reformatting anything else.
+type Document struct {
+	Lines []string
+	// Escape is the line continuation character, set by an escape parser
+	// directive; the default is a backslash.
+	Escape byte
+	// Preamble holds the instructions before the first FROM, usually global
+	// ARGs.
+	Preamble []Instruction
+	Stages   []Stage
+}
+
+// Stage is a FROM instruction and the instructions up to the next one.
+type Stage struct {
+	// Name is the lower-cased AS name, or "" for an unnamed stage.
+	Name         string
+	From         Instruction
+	Instructions []Instruction
+}
+
+// Instruction is one instruction. StartLine and EndLine are the indexes of
+// its first and last source line, counting continuation lines and heredoc
+// bodies.
+type Instruction struct {
+	// Keyword is the upper-cased instruction, for example RUN.
+	Keyword string
+	// Args are the arguments with continuation lines joined by a space and
+	// without comment lines or heredoc bodies.
+	Args      string
+	Heredocs  []Heredoc
+	StartLine int
+	EndLine   int
+}
+
+// Heredoc is a here-document of a RUN, COPY or ADD instruction.
+type Heredoc struct {
+	Name string
+	Body string
+}
+
+var (
+	escapeDirectivePattern = regexp.MustCompile(`(?i)^#\s*escape\s*=\s*(\S)\s*$`)
+	parserDirectivePattern = regexp.MustCompile(`^#\s*[a-zA-Z][a-zA-Z0-9_-]*\s*=`)
+	heredocPattern         = regexp.MustCompile(`<<(-?)(["']?)([A-Za-z_][A-Za-z0-9_]*)(["']?)`)
+)
+
+// ParseDocument parses a Dockerfile. Parsing is lenient: text that is not a
+// valid instruction is skipped and an unterminated heredoc or continuation
+// runs to the end of the file, so any existing file can be merged.
+func ParseDocument(content string) Document {
+	doc := Document{Escape: '\\'}
+	if content == "" {
+		return doc
+	}
+	doc.Lines = strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+
+	i := doc.parseDirectives()
+	for i < len(doc.Lines) {
+		trimmed := strings.TrimSpace(doc.Lines[i])
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			i++
+			continue
+		}
+		instruction := doc.parseInstruction(i)
+		i = instruction.EndLine + 1
+		if instruction.Keyword == "FROM" {
+			doc.Stages = append(doc.Stages, Stage{Name: stageName(instruction.Args), From: instruction})
+			continue
+		}
+		if len(doc.Stages) == 0 {
+			doc.Preamble = append(doc.Preamble, instruction)
+			continue
+		}
+		stage := &doc.Stages[len(doc.Stages)-1]
+		stage.Instructions = append(stage.Instructions, instruction)
+	}
+	return doc
+}
+
+// parseDirectives reads the parser directives at the top of the file and
+// returns the index of the first line after them.
+func (d *Document) parseDirectives() int {
+	for i, line := range d.Lines {
+		trimmed := strings.TrimSpace(line)
+		if !parserDirectivePattern.MatchString(trimmed) {
+			return i
+		}
+		if match := escapeDirectivePattern.FindStringSubmatch(trimmed); match != nil && (match[1] == "`" || match[1] == `\`) {
+			d.Escape = match[1][0]
+		}
+	}
+	return len(d.Lines)
+}
+
+// parseInstruction reads the instruction starting at line start, following
+// continuation lines and then the bodies of its heredocs.
+func (d *Document) parseInstruction(start int) Instruction {
+	var parts []string
+	end := start
+	for end < len(d.Lines) {
+		line := strings.TrimRight(d.Lines[end], " \t\r")
+		trimmed := strings.TrimSpace(line)
+		if end > start && (trimmed == "" || strings.HasPrefix(trimmed, "#")) {
+			// comments and empty lines inside a continuation are ignored
+			end++
+			continue
+		}
+		if !strings.HasSuffix(line, string(d.Escape)) {
+			parts = append(parts, trimmed)
+			break
+		}
+		parts = append(parts, strings.TrimSpace(strings.TrimSuffix(line, string(d.Escape))))
+		end++
+	}
+	if end >= len(d.Lines) {
+		end = len(d.Lines) - 1
+	}
+
+	text := strings.Join(parts, " ")
+	keyword, args, _ := strings.Cut(text, " ")
+	instruction := Instruction{
+		Keyword:   strings.ToUpper(keyword),
+		Args:      strings.TrimSpace(args),
+		StartLine: start,
+		EndLine:   end,
+	}
+	switch instruction.Keyword {
+	case "RUN", "COPY", "ADD":
+		instruction.EndLine = d.parseHeredocs(&instruction)
+	}
+	return instruction
+}
+
+// parseHeredocs reads the heredoc bodies following the instruction, in the
+// order the heredocs are declared, and returns the last line they use.
+func (d *Document) parseHeredocs(instruction *Instruction) int {
+	end := instruction.EndLine
+	for _, match := range heredocPattern.FindAllStringSubmatch(instruction.Args, -1) {
+		if match[2] != match[4] {
+			continue
+		}
+		stripTabs := match[1] == "-"
+		heredoc := Heredoc{Name: match[3]}
+		var body []string
+		for end+1 < len(d.Lines) {
+			end++
+			line := d.Lines[end]
+			if stripTabs {
+				line = strings.TrimLeft(line, "\t")
+			}
+			if line == heredoc.Name {
+				break
+			}
+			body = append(body, line)
+		}
+		heredoc.Body = strings.Join(body, "\n")
+		instruction.Heredocs = append(instruction.Heredocs, heredoc)
+	}
+	return end
+}
+
+// stageName returns the lower-cased AS name of FROM arguments.
+func stageName(args string) string {
+	fields := strings.Fields(args)
+	if len(fields) >= 3 && strings.EqualFold(fields[len(fields)-2], "AS") {
+		return strings.ToLower(fields[len(fields)-1])
+	}
+	return ""
+}
+
+// Text returns the instruction on one line.
+func (i Instruction) Text() string {
+	if i.Args == "" {
+		return i.Keyword
+	}
+	return i.Keyword + " " + i.Args
+}
+
+// ExecForm returns the arguments of a JSON array instruction such as
+// CMD ["sh", "-c", "..."], and false for the shell form.
+func (i Instruction) ExecForm() ([]string, bool) {
+	if !strings.HasPrefix(i.Args, "[") {
+		return nil, false
+	}
+	var args []string
+	if err := json.Unmarshal([]byte(i.Args), &args); err != nil {
+		return nil, false
+	}
+	return args, true
+}
+
+// EnvKeys returns the variable names an ENV instruction sets, in both the
+// KEY=value and the legacy "KEY value" form.
+func (i Instruction) EnvKeys() []string {
+	if i.Keyword != "ENV" {
+		return nil
+	}
+	words := shellWords(i.Args)
+	if len(words) == 0 {
+		return nil
+	}
+	if !strings.Contains(words[0], "=") {
+		return []string{words[0]}
+	}
+	keys := make([]string, 0, len(words))
+	for _, word := range words {
+		key, _, _ := strings.Cut(word, "=")
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// shellWords splits on unquoted whitespace, keeping quotes in the words.
+func shellWords(value string) []string {
+	var words []string
+	var current strings.Builder
+	var quote rune
+	escaped := false
+	for _, r := range value {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ' ' || r == '\t':
+			if current.Len() > 0 {
+				words = append(words, current.String())
+				current.Reset()
+			}
+			continue
+		}
+		current.WriteRune(r)
+	}
+	if current.Len() > 0 {
+		words = append(words, current.String())
+	}
+	return words
+}
+
+// BaseImage returns the image or stage a stage starts from.
+func (s Stage) BaseImage() string {
+	for _, field := range strings.Fields(s.From.Args) {
+		if !strings.HasPrefix(field, "--") {
+			return field
+		}
+	}
+	return ""
+}
+
+// Find returns the instructions of the stage with the keyword.
+func (s Stage) Find(keyword string) []Instruction {
+	var found []Instruction
+	for _, instruction := range s.Instructions {
+		if instruction.Keyword == keyword {
+			found = append(found, instruction)
+		}
+	}
+	return found
+}
+
+// Last returns the last instruction of the stage with the keyword.
+func (s Stage) Last(keyword string) (Instruction, bool) {
+	found := s.Find(keyword)
+	if len(found) == 0 {
+		return Instruction{}, false
+	}
+	return found[len(found)-1], true
+}
+
+// EndLine returns the last source line of the stage's instructions.
+func (s Stage) EndLine() int {
+	if len(s.Instructions) == 0 {
+		return s.From.EndLine
+	}
+	return s.Instructions[len(s.Instructions)-1].EndLine
+}
+
+// Stage returns the stage with the AS name.
+func (d Document) Stage(name string) (Stage, bool) {
+	name = strings.ToLower(name)
+	for _, stage := range d.Stages {
+		if stage.Name == name {
+			return stage, true
+		}
+	}
+	return Stage{}, false
+}
+
+// Source returns the source lines from start to end, inclusive.
+func (d Document) Source(start int, end int) []string {
+	return append([]string(nil), d.Lines[start:end+1]...)
+}
@@ -0,0 +1,83 @@
+package dockerfile
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDocumentReadsStagesAndInstructions(t *testing.T) {
+	content := "" +
+		"# syntax=docker/dockerfile:1\n" +
+		"ARG GO_VERSION=1.22\n" +
+		"\n" +
+		"FROM golang:${GO_VERSION} AS Build\n" +
+		"RUN apk add --no-cache \\\n" +
+		"    # the compiler toolchain\n" +
+		"    git \\\n" +
+		"    make\n" +
+		"RUN <<-EOF\n" +
+		"\tgo build -o /out/app .\n" +
+		"\tEOF\n" +
+		"\n" +
+		"FROM --platform=linux/amd64 alpine:3.20\n" +
+		"ENV APP_START_CMD=\"/app/app --port 8080\" MODE=prod\n" +
+		"CMD [\"sh\", \"-lc\", \"$APP_START_CMD\"]\n"
+
+	doc := ParseDocument(content)
+	if len(doc.Preamble) != 1 || doc.Preamble[0].Text() != "ARG GO_VERSION=1.22" {
+		t.Fatalf("expected global ARG in the preamble, got %+v", doc.Preamble)
+	}
+	if len(doc.Stages) != 2 {
+		t.Fatalf("expected 2 stages, got %d", len(doc.Stages))
+	}
+
+	build, ok := doc.Stage("build")
+	if !ok || build.BaseImage() != "golang:${GO_VERSION}" {
+		t.Fatalf("expected build stage from golang, got %+v", build)
+	}
+	runs := build.Find("RUN")
+	if len(runs) != 2 {
+		t.Fatalf("expected 2 RUN instructions, got %+v", runs)
+	}
+	if runs[0].Args != "apk add --no-cache git make" || runs[0].StartLine != 4 || runs[0].EndLine != 7 {
+		t.Fatalf("expected continuation lines to be joined without the comment, got %+v", runs[0])
+	}
+	if len(runs[1].Heredocs) != 1 || runs[1].Heredocs[0].Body != "go build -o /out/app ." || runs[1].EndLine != 10 {
+		t.Fatalf("expected tab-stripped heredoc body, got %+v", runs[1])
+	}
+
+	final := doc.Stages[1]
+	if final.Name != "" || final.BaseImage() != "alpine:3.20" || final.EndLine() != 14 {
+		t.Fatalf("expected unnamed final stage from alpine, got %+v", final)
+	}
+	env, _ := final.Last("ENV")
+	if keys := env.EnvKeys(); !reflect.DeepEqual(keys, []string{"APP_START_CMD", "MODE"}) {
+		t.Fatalf("expected ENV keys, got %v", keys)
+	}
+	cmd, _ := final.Last("CMD")
+	if args, ok := cmd.ExecForm(); !ok || !reflect.DeepEqual(args, []string{"sh", "-lc", "$APP_START_CMD"}) {
+		t.Fatalf("expected exec form CMD, got %v", args)
+	}
+}
+
+func TestParseDocumentHonorsEscapeDirective(t *testing.T) {
+	content := "" +
+		"# escape=`\n" +
+		"FROM mcr.microsoft.com/windows/servercore\n" +
+		"RUN dir c:\\ `\n" +
+		"    && echo done\n" +
+		"ENV APP_START_CMD C:\\app\\app.exe\n"
+
+	doc := ParseDocument(content)
+	if doc.Escape != '`' || len(doc.Stages) != 1 {
+		t.Fatalf("expected backtick escape and one stage, got %+v", doc)
+	}
+	run, _ := doc.Stages[0].Last("RUN")
+	if run.Args != `dir c:\ && echo done` || run.EndLine != 3 {
+		t.Fatalf("expected backtick continuation, got %+v", run)
+	}
+	env, _ := doc.Stages[0].Last("ENV")
+	if keys := env.EnvKeys(); !reflect.DeepEqual(keys, []string{"APP_START_CMD"}) {
+		t.Fatalf("expected legacy ENV form key, got %v", keys)
+	}
+}
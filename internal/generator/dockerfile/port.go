@@ -0,0 +1,207 @@
+package dockerfile
+
+import (
+	"encoding/json"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxScanDepth and maxScanFiles bound the source scan of detectAppPort so
+// large repositories do not slow detection down.
+const (
+	maxScanDepth = 3
+	maxScanFiles = 500
+)
+
+var (
+	scriptPortPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`\bPORT=(\d+)`),
+		regexp.MustCompile(`--port[= ](\d+)`),
+		regexp.MustCompile(`(?:^|\s)-p (\d+)`),
+	}
+	nodeEnvPortPattern  = regexp.MustCompile(`process\.env\.PORT\s*(?:\|\||\?\?)\s*['"]?(\d+)`)
+	springPortPattern   = regexp.MustCompile(`(?m)^\s*server\.port\s*[=:]\s*(\d+)\s*$`)
+	pythonRunPattern    = regexp.MustCompile(`\.run\([^)]*\bport\s*=\s*(\d+)`)
+	goListenPattern     = regexp.MustCompile(`ListenAndServe(?:TLS)?\(\s*"[^"]*:(\d+)"`)
+	denoServePattern    = regexp.MustCompile(`Deno\.serve\(\s*\{[^}]*\bport:\s*(\d+)`)
+	phoenixPortPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`System\.get_env\("PORT"(?:,\s*|\)\s*\|\|\s*)"(\d+)"`),
+		regexp.MustCompile(`http:\s*\[[^\]]*\bport:\s*(\d+)`),
+	}
+)
+
+// detectAppPort returns the port the app listens on, read from the sources
+// and framework config of language, falling back to the EXPOSE of an
+// existing Dockerfile. It returns "" when nothing declares a port.
+func detectAppPort(root string, language Language) string {
+	var port string
+	switch language {
+	case LanguageNode, LanguageBun:
+		port = nodePort(root)
+	case LanguageDeno:
+		port = scanSources(root, ".ts", denoServePattern)
+	case LanguageJava:
+		port = springPort(root)
+	case LanguagePython:
+		port = scanSources(root, ".py", pythonRunPattern)
+	case LanguageGo:
+		port = scanSources(root, ".go", goListenPattern)
+	case LanguageElixir:
+		port = phoenixPort(root)
+	}
+	if port == "" {
+		port = exposedPort(readFile(filepath.Join(root, "Dockerfile")))
+	}
+	return port
+}
+
+// nodePort looks for a port in the package.json scripts, preferring start
+// and dev, then for a process.env.PORT fallback in the usual entry files.
+func nodePort(root string) string {
+	var parsed struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	if err := json.Unmarshal([]byte(readFile(filepath.Join(root, "package.json"))), &parsed); err == nil {
+		for _, name := range []string{"start", "dev", "serve"} {
+			for _, pattern := range scriptPortPatterns {
+				if port := firstPort(pattern, parsed.Scripts[name]); port != "" {
+					return port
+				}
+			}
+		}
+	}
+
+	for _, name := range []string{"server.js", "index.js", "app.js", "main.js", "server.ts", "index.ts", "app.ts", "main.ts"} {
+		for _, dir := range []string{"", "src"} {
+			if port := firstPort(nodeEnvPortPattern, readFile(filepath.Join(root, dir, name))); port != "" {
+				return port
+			}
+		}
+	}
+	return ""
+}
+
+// phoenixPort reads the endpoint port of the Phoenix config, preferring the
+// runtime config that releases use.
+func phoenixPort(root string) string {
+	for _, name := range []string{"runtime.exs", "prod.exs", "dev.exs", "config.exs"} {
+		content := readFile(filepath.Join(root, "config", name))
+		for _, pattern := range phoenixPortPatterns {
+			if port := firstPort(pattern, content); port != "" {
+				return port
+			}
+		}
+	}
+	return ""
+}
+
+// springPort reads server.port from the Spring Boot application config.
+func springPort(root string) string {
+	for _, dir := range []string{filepath.Join("src", "main", "resources"), ""} {
+		if port := firstPort(springPortPattern, readFile(filepath.Join(root, dir, "application.properties"))); port != "" {
+			return port
+		}
+		for _, name := range []string{"application.yml", "application.yaml"} {
+			if port := springYAMLPort(readFile(filepath.Join(root, dir, name))); port != "" {
+				return port
+			}
+		}
+	}
+	return ""
+}
+
+func springYAMLPort(content string) string {
+	if content == "" {
+		return ""
+	}
+	var parsed struct {
+		Server struct {
+			Port string `yaml:"port"`
+		} `yaml:"server"`
+	}
+	if err := yaml.Unmarshal([]byte(content), &parsed); err != nil {
+		return ""
+	}
+	if port := validPort(parsed.Server.Port); port != "" {
+		return port
+	}
+	return firstPort(springPortPattern, content)
+}
+
+// scanSources returns the first port matched by pattern in the files with
+// the given extension, walking the project in lexical order. Hidden,
+// dependency and test directories are skipped.
+func scanSources(root string, ext string, pattern *regexp.Regexp) string {
+	port := ""
+	scanned := 0
+	_ = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if entry.IsDir() {
+			if path == root {
+				return nil
+			}
+			name := entry.Name()
+			rel, _ := filepath.Rel(root, path)
+			if strings.HasPrefix(name, ".") || name == "vendor" || name == "node_modules" || name == "testdata" ||
+				strings.Count(rel, string(filepath.Separator)) >= maxScanDepth {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) != ext || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		scanned++
+		if scanned > maxScanFiles {
+			return filepath.SkipAll
+		}
+		if found := firstPort(pattern, readFile(path)); found != "" {
+			port = found
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return port
+}
+
+// exposedPort returns the first port the final stage of a Dockerfile
+// exposes, since that stage builds the image that runs.
+func exposedPort(content string) string {
+	doc := ParseDocument(content)
+	if len(doc.Stages) == 0 {
+		return ""
+	}
+	for _, expose := range doc.Stages[len(doc.Stages)-1].Find("EXPOSE") {
+		for _, field := range strings.Fields(expose.Args) {
+			number, _, _ := strings.Cut(field, "/")
+			if port := validPort(number); port != "" {
+				return port
+			}
+		}
+	}
+	return ""
+}
+
+func firstPort(pattern *regexp.Regexp, content string) string {
+	for _, match := range pattern.FindAllStringSubmatch(content, -1) {
+		if port := validPort(match[1]); port != "" {
+			return port
+		}
+	}
+	return ""
+}
+
+func validPort(value string) string {
+	port, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || port < 1 || port > 65535 {
+		return ""
+	}
+	return strconv.Itoa(port)
+}
@@ -0,0 +1,151 @@
+package dockerfile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDetectLanguageFindsAppPort(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{
+			name: "node start script",
+			files: map[string]string{
+				"package.json": `{"scripts": {"build": "PORT=9999 build", "start": "PORT=4000 node server.js"}}`,
+			},
+			want: "4000",
+		},
+		{
+			name: "node dev script flag",
+			files: map[string]string{
+				"package.json": `{"scripts": {"dev": "next dev -p 3001"}}`,
+			},
+			want: "3001",
+		},
+		{
+			name: "node env fallback",
+			files: map[string]string{
+				"package.json":  `{"scripts": {"start": "node src/index.js"}}`,
+				"src/index.js":  "const port = process.env.PORT || 5050;\n",
+				"src/ignore.js": "process.env.PORT || 1",
+			},
+			want: "5050",
+		},
+		{
+			name: "spring properties",
+			files: map[string]string{
+				"pom.xml": "<project/>",
+				"src/main/resources/application.properties": "spring.application.name=demo\nserver.port=8081\n",
+			},
+			want: "8081",
+		},
+		{
+			name: "spring yaml",
+			files: map[string]string{
+				"build.gradle":                       "",
+				"src/main/resources/application.yml": "server:\n  port: 9090\n",
+			},
+			want: "9090",
+		},
+		{
+			name: "flask run",
+			files: map[string]string{
+				"requirements.txt": "flask\n",
+				"app.py":           "if __name__ == \"__main__\":\n    app.run(host=\"0.0.0.0\", port=5000)\n",
+			},
+			want: "5000",
+		},
+		{
+			name: "go listen and serve",
+			files: map[string]string{
+				"go.mod":            "module example\n\ngo 1.22\n",
+				"cmd/api/main.go":   "package main\n\nfunc main() { http.ListenAndServe(\":9000\", nil) }\n",
+				"cmd/api/x_test.go": "package main\n\nvar _ = http.ListenAndServe(\":1111\", nil)\n",
+			},
+			want: "9000",
+		},
+		{
+			name: "existing dockerfile expose",
+			files: map[string]string{
+				"Gemfile":    "source 'https://rubygems.org'\n",
+				"Dockerfile": "FROM ruby:3.3\nEXPOSE 3000/tcp\n",
+			},
+			want: "3000",
+		},
+		{
+			name: "out of range port is ignored",
+			files: map[string]string{
+				"requirements.txt": "flask\n",
+				"app.py":           "app.run(port=70000)\n",
+			},
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			for name, content := range tt.files {
+				path := filepath.Join(root, filepath.FromSlash(name))
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatalf("mkdir: %v", err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+					t.Fatalf("write %s: %v", name, err)
+				}
+			}
+
+			details, err := DetectLanguage(root)
+			if err != nil {
+				t.Fatalf("detect: %v", err)
+			}
+			if details.AppPort != tt.want {
+				t.Fatalf("expected port %q, got %q", tt.want, details.AppPort)
+			}
+		})
+	}
+}
+
+func TestDockerfileUsesDetectedPort(t *testing.T) {
+	root := t.TempDir()
+	writeDockerfileCatalog(t, root)
+
+	content, err := Dockerfile(root, LanguageDetails{Type: LanguageRuby, AppPort: "3000"})
+	if err != nil {
+		t.Fatalf("dockerfile: %v", err)
+	}
+	if !strings.Contains(content, "EXPOSE 3000\n") {
+		t.Fatalf("expected detected port to be exposed, got:\n%s", content)
+	}
+}
+
+func TestOverrideLanguageDetectsPortForLanguage(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"package.json": `{"scripts": {"start": "PORT=4000 node server.js"}}`,
+		"app.py":       "app.run(host=\"0.0.0.0\", port=5000)\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	details, err := DetectLanguage(root)
+	if err != nil {
+		t.Fatalf("detect: %v", err)
+	}
+	if details.Type != LanguageNode || details.AppPort != "4000" {
+		t.Fatalf("expected node on port 4000, got %s on %q", details.Type, details.AppPort)
+	}
+
+	overridden := OverrideLanguage(root, details, LanguagePython)
+	if overridden.Type != LanguagePython || overridden.AppPort != "5000" {
+		t.Fatalf("expected python on port 5000, got %s on %q", overridden.Type, overridden.AppPort)
+	}
+}
@@ -8,7 +8,10 @@ import (
 	"strings"
 )
 
-var versionPattern = regexp.MustCompile(`\d+(?:\.\d+){0,2}`)
+var (
+	versionPattern = regexp.MustCompile(`\d+(?:\.\d+){0,2}`)
+	mixAppPattern  = regexp.MustCompile(`\bapp:\s*:([a-z_][a-zA-Z0-9_]*)`)
+)
 
 func detectGoVersion(root string) string {
 	content := readFile(filepath.Join(root, "go.mod"))
@@ -45,6 +48,39 @@ func detectNodeVersion(root string) string {
 	return normalizeMajor(parsed.Engines["node"])
 }
 
+// detectBunVersion reads .bun-version, then the bun version of the
+// package.json packageManager field and then its engines.
+func detectBunVersion(root string) string {
+	if version := firstToken(readFile(filepath.Join(root, ".bun-version"))); version != "" {
+		return normalizeMajorMinor(version)
+	}
+	var parsed struct {
+		PackageManager string            `json:"packageManager"`
+		Engines        map[string]string `json:"engines"`
+	}
+	if err := json.Unmarshal([]byte(readFile(filepath.Join(root, "package.json"))), &parsed); err != nil {
+		return ""
+	}
+	if version, ok := strings.CutPrefix(parsed.PackageManager, "bun@"); ok {
+		return normalizeMajorMinor(version)
+	}
+	return normalizeMajorMinor(parsed.Engines["bun"])
+}
+
+// detectDenoVersion reads .dvmrc or the deno entry of .tool-versions. The
+// denoland/deno images are only tagged with full versions.
+func detectDenoVersion(root string) string {
+	if version := firstToken(readFile(filepath.Join(root, ".dvmrc"))); version != "" {
+		return extractVersion(version)
+	}
+	for _, line := range strings.Split(readFile(filepath.Join(root, ".tool-versions")), "\n") {
+		if fields := strings.Fields(line); len(fields) >= 2 && fields[0] == "deno" {
+			return extractVersion(fields[1])
+		}
+	}
+	return ""
+}
+
 func detectPythonVersion(root string) string {
 	if version := firstToken(readFile(filepath.Join(root, ".python-version"))); version != "" {
 		return normalizeMajorMinor(version)
@@ -96,6 +132,125 @@ func detectDotNetVersion(root string) string {
 	return normalizeMajorMinor(parsed.SDK.Version)
 }
 
+// detectRustVersion reads the toolchain channel of rust-toolchain.toml or the
+// legacy rust-toolchain file, then the rust-version of Cargo.toml. Named
+// channels such as stable have no version.
+func detectRustVersion(root string) string {
+	if channel := tomlValue(readFile(filepath.Join(root, "rust-toolchain.toml")), "toolchain", "channel"); channel != "" {
+		return normalizeMajorMinor(channel)
+	}
+	if channel := firstToken(readFile(filepath.Join(root, "rust-toolchain"))); channel != "" && !strings.HasPrefix(channel, "[") {
+		return normalizeMajorMinor(channel)
+	}
+	return normalizeMajorMinor(tomlValue(readFile(filepath.Join(root, "Cargo.toml")), "package", "rust-version"))
+}
+
+func detectRustBinary(root string) string {
+	content := readFile(filepath.Join(root, "Cargo.toml"))
+	if name := tomlValue(content, "[bin]", "name"); name != "" {
+		return name
+	}
+	return tomlValue(content, "package", "name")
+}
+
+// tomlValue returns the first string value of key in the TOML table, "[bin]"
+// meaning the [[bin]] array of tables. It only understands the flat
+// key = "value" lines Cargo and rustup files use.
+func tomlValue(content string, table string, key string) string {
+	current := ""
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			current = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(line, "["), "]"))
+			continue
+		}
+		if current != table {
+			continue
+		}
+		name, value, ok := strings.Cut(line, "=")
+		if !ok || strings.TrimSpace(name) != key {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if idx := strings.Index(value, " #"); idx >= 0 {
+			value = strings.TrimSpace(value[:idx])
+		}
+		return strings.Trim(value, `"'`)
+	}
+	return ""
+}
+
+// detectElixirVersions reads the elixir and erlang entries of .tool-versions,
+// taking the OTP release from an "1.17.3-otp-27" elixir version when erlang
+// is not pinned, then the elixir requirement of mix.exs.
+func detectElixirVersions(root string) (string, string) {
+	elixir, erlang := "", ""
+	for _, line := range strings.Split(readFile(filepath.Join(root, ".tool-versions")), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "elixir":
+			elixir = normalizeMajorMinor(fields[1])
+			if _, otp, ok := strings.Cut(fields[1], "-otp-"); ok && erlang == "" {
+				erlang = normalizeMajor(otp)
+			}
+		case "erlang":
+			erlang = normalizeMajor(fields[1])
+		}
+	}
+	if elixir == "" {
+		elixir = normalizeMajorMinor(extractVersionFromPattern(readFile(filepath.Join(root, "mix.exs")), `elixir:\s*"[~>=\s]*([\d.]+)"`))
+	}
+	return elixir, erlang
+}
+
+func detectElixirApp(root string) string {
+	match := mixAppPattern.FindStringSubmatch(readFile(filepath.Join(root, "mix.exs")))
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+// tomlHasTable reports whether the TOML content declares the table.
+func tomlHasTable(content string, table string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		if strings.TrimSpace(line) == "["+table+"]" {
+			return true
+		}
+	}
+	return false
+}
+
+// tomlFirstKey returns the first key of the TOML table.
+func tomlFirstKey(content string, table string) string {
+	current := ""
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			current = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(line, "["), "]"))
+			continue
+		}
+		if current != table || line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if name, _, ok := strings.Cut(line, "="); ok {
+			return strings.Trim(strings.TrimSpace(name), `"'`)
+		}
+	}
+	return ""
+}
+
+// pythonScript returns the first console script pyproject.toml declares.
+func pythonScript(pyproject string) string {
+	if script := tomlFirstKey(pyproject, "project.scripts"); script != "" {
+		return script
+	}
+	return tomlFirstKey(pyproject, "tool.poetry.scripts")
+}
+
 func detectPHPVersion(root string) string {
 	if version := firstToken(readFile(filepath.Join(root, ".php-version"))); version != "" {
 		return normalizeMajorMinor(version)
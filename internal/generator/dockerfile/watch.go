@@ -0,0 +1,40 @@
+package dockerfile
+
+import (
+	"os"
+	"path/filepath"
+
+	"docker-wizard/internal/generator/catalog"
+)
+
+// WatchRules returns the develop.watch rules the catalog declares for the
+// framework or language, without the rules whose path does not exist in root, so that a
+// Node project without src or a Python project without pyproject.toml gets
+// only the rules that apply to it.
+func WatchRules(root string, details LanguageDetails) ([]catalog.WatchRule, error) {
+	return AppWatchRules(root, root, details)
+}
+
+// AppWatchRules is WatchRules for an app whose sources are in dir rather
+// than root, such as an app of a monorepo. The rule paths stay relative to
+// dir.
+func AppWatchRules(root string, dir string, details LanguageDetails) ([]catalog.WatchRule, error) {
+	templates, err := loadTemplates(root)
+	if err != nil {
+		return nil, err
+	}
+
+	spec, _, err := templateFor(templates, details)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []catalog.WatchRule
+	for _, rule := range spec.Watch {
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(rule.Path))); err != nil {
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
@@ -5,6 +5,7 @@ import (
 	"docker-wizard/internal/generator/compose"
 	"docker-wizard/internal/generator/dockerfile"
 	"docker-wizard/internal/generator/preview"
+	"docker-wizard/internal/generator/project"
 	"docker-wizard/internal/generator/validate"
 	"docker-wizard/internal/generator/write"
 )
@@ -14,10 +15,19 @@ type LanguageDetails = dockerfile.LanguageDetails
 type ServiceSpec = catalog.ServiceSpec
 type Output = write.Output
 type WriteStatus = write.WriteStatus
+type Conflict = write.Conflict
 type Preview = preview.Preview
 type FilePreview = preview.FilePreview
 type FileStatus = preview.FileStatus
 type ComposeSelection = compose.ComposeSelection
+type Removal = compose.Removal
+type AppSettings = catalog.AppSettings
+type WatchRule = catalog.WatchRule
+type ServiceOverride = catalog.ServiceOverride
+type ProjectConfig = project.Config
+type App = dockerfile.App
+type AppFile = write.AppFile
+type AppService = compose.AppService
 
 const (
 	LanguageGo      = dockerfile.LanguageGo
@@ -27,13 +37,21 @@ const (
 	LanguagePHP     = dockerfile.LanguagePHP
 	LanguageJava    = dockerfile.LanguageJava
 	LanguageDotNet  = dockerfile.LanguageDotNet
+	LanguageRust    = dockerfile.LanguageRust
+	LanguageElixir  = dockerfile.LanguageElixir
+	LanguageBun     = dockerfile.LanguageBun
+	LanguageDeno    = dockerfile.LanguageDeno
 	LanguageUnknown = dockerfile.LanguageUnknown
 )
 
 const (
-	ComposeFileName      = write.ComposeFileName
-	DockerfileFileName   = write.DockerfileFileName
-	DockerignoreFileName = write.DockerignoreFileName
+	ComposeFileName         = write.ComposeFileName
+	DockerfileFileName      = write.DockerfileFileName
+	DockerignoreFileName    = write.DockerignoreFileName
+	ComposeOverrideFileName = write.ComposeOverrideFileName
+	EnvFileName             = write.EnvFileName
+	EnvExampleFileName      = write.EnvExampleFileName
+	SecretsDirName          = compose.SecretsDirName
 )
 
 const (
@@ -53,14 +71,44 @@ func DetectLanguage(root string) (LanguageDetails, error) {
 	return dockerfile.DetectLanguage(root)
 }
 
+func OverrideLanguage(root string, details LanguageDetails, language Language) LanguageDetails {
+	return dockerfile.OverrideLanguage(root, details, language)
+}
+
+func DetectApps(root string, patterns []string) ([]App, error) {
+	return dockerfile.DetectApps(root, patterns)
+}
+
 func Dockerfile(root string, details LanguageDetails) (string, error) {
 	return dockerfile.Dockerfile(root, details)
 }
 
+func DockerfileWithApp(root string, details LanguageDetails, app AppSettings) (string, error) {
+	return dockerfile.DockerfileWithApp(root, details, app)
+}
+
+// MergedDevTarget returns the dev stage as build target when the Dockerfile
+// of the app in dir still declares it once generated is merged into it.
+func MergedDevTarget(root string, dir string, generated string) (string, error) {
+	merged, err := write.MergedDockerfile(root, dir, generated)
+	if err != nil {
+		return "", err
+	}
+	return dockerfile.DevTarget(merged), nil
+}
+
+func WatchRules(root string, details LanguageDetails) ([]WatchRule, error) {
+	return dockerfile.WatchRules(root, details)
+}
+
 func Compose(root string, selection ComposeSelection) (string, error) {
 	return compose.Compose(root, selection)
 }
 
+func ComposeFiles(root string, selection ComposeSelection) (string, string, error) {
+	return compose.ComposeFiles(root, selection)
+}
+
 func SelectableServices(root string) ([]ServiceSpec, error) {
 	return catalog.SelectableServices(root)
 }
@@ -73,16 +121,36 @@ func PreviewFiles(root string, compose string, dockerfile string) (Preview, erro
 	return preview.PreviewFiles(root, compose, dockerfile)
 }
 
+func PreviewFilesWithComposeOverride(root string, compose string, override string, dockerfile string) (Preview, error) {
+	return preview.PreviewFilesWithComposeOverride(root, compose, override, dockerfile)
+}
+
 func SelectionWarnings(root string, selection ComposeSelection) ([]string, error) {
 	return validate.SelectionWarnings(root, selection)
 }
 
+func PreviewAppFiles(root string, compose string, override string, apps []AppFile) (Preview, error) {
+	return preview.PreviewAppFiles(root, compose, override, apps)
+}
+
 func WriteFiles(root string, compose string, dockerfile string) (Output, error) {
 	return write.WriteFiles(root, compose, dockerfile)
 }
 
-func ComposeFragment(root string, serviceIDs []string) (string, []string, error) {
-	return compose.ComposeFragment(root, serviceIDs)
+func WriteFilesWithComposeOverride(root string, compose string, override string, dockerfile string) (Output, error) {
+	return write.WriteFilesWithComposeOverride(root, compose, override, dockerfile)
+}
+
+func AppFileName(dir string, name string) string {
+	return write.AppFileName(dir, name)
+}
+
+func WriteAppFiles(root string, compose string, override string, apps []AppFile) (Output, error) {
+	return write.WriteAppFiles(root, compose, override, apps)
+}
+
+func ComposeFragment(root string, selection ComposeSelection, existing string) (string, []string, error) {
+	return compose.ComposeFragment(root, selection, existing)
 }
 
 func ExistingComposeServices(content string) (map[string]bool, error) {
@@ -97,6 +165,62 @@ func PreviewComposeFile(root string, composeContent string) (FilePreview, error)
 	return preview.PreviewComposeFile(root, composeContent)
 }
 
-func WriteComposeFile(root string, composeContent string) (WriteStatus, string, error) {
+func WriteComposeFile(root string, composeContent string) (WriteStatus, string, []Conflict, error) {
 	return write.WriteComposeFile(root, composeContent)
 }
+
+func PreviewSupportFiles(root string, composeContent string) (Preview, error) {
+	return preview.PreviewSupportFiles(root, composeContent)
+}
+
+func WriteSupportFiles(root string, composeContent string) (Output, error) {
+	return write.WriteSupportFiles(root, composeContent)
+}
+
+func ConflictWarnings(fileName string, conflicts []Conflict) []string {
+	return preview.ConflictWarnings(fileName, conflicts)
+}
+
+func PlanRemoval(root string, content string, serviceIDs []string) (Removal, error) {
+	doc, err := compose.ParseDocument(content)
+	if err != nil {
+		return Removal{}, err
+	}
+	return compose.PlanRemoval(root, doc, serviceIDs)
+}
+
+func PreviewComposeRemoval(root string, plan Removal) (FilePreview, error) {
+	return preview.PreviewComposeRemoval(root, plan)
+}
+
+func WriteComposeRemoval(root string, plan Removal) (WriteStatus, string, error) {
+	return write.WriteComposeRemoval(root, plan)
+}
+
+func SetOverride(overrides map[string]ServiceOverride, assignment string) error {
+	return catalog.SetOverride(overrides, assignment)
+}
+
+func SetProfile(overrides map[string]ServiceOverride, assignment string) error {
+	return catalog.SetProfile(overrides, assignment)
+}
+
+func ValidateProfiles(profiles []string) error {
+	return catalog.ValidateProfiles(profiles)
+}
+
+func ValidatePorts(ports []string) error {
+	return catalog.ValidatePorts(ports)
+}
+
+func MergeAppSettings(base AppSettings, top AppSettings) AppSettings {
+	return catalog.MergeAppSettings(base, top)
+}
+
+func LoadProjectConfig(root string) (ProjectConfig, string, error) {
+	return project.Load(root)
+}
+
+func SaveProjectConfig(root string, config ProjectConfig) (string, error) {
+	return project.Save(root, config)
+}
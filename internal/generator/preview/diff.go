@@ -0,0 +1,213 @@
+package preview
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+// Diff returns a unified diff from the file on disk to the content that would
+// be written. It is empty when the file is unchanged or kept as is.
+func (p FilePreview) Diff() string {
+	return p.DiffAs(filepath.Base(p.Path))
+}
+
+// DiffAs is Diff with the file labelled name, such as the path of an app's
+// Dockerfile relative to the project root.
+func (p FilePreview) DiffAs(name string) string {
+	switch p.Status {
+	case FileStatusNew:
+		return UnifiedDiff("/dev/null", "b/"+name, "", p.Content)
+	case FileStatusDifferent:
+		return UnifiedDiff("a/"+name, "b/"+name, p.Existing, p.Content)
+	default:
+		return ""
+	}
+}
+
+// UnifiedDiff compares two texts line by line and renders the result in
+// unified diff format with diffContext lines of context. Identical texts give
+// an empty string.
+func UnifiedDiff(oldName string, newName string, oldContent string, newContent string) string {
+	if oldContent == newContent {
+		return ""
+	}
+	oldLines := splitLines(oldContent)
+	newLines := splitLines(newContent)
+	ops := diffLines(oldLines, newLines)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldName, newName)
+	for _, hunk := range groupHunks(ops) {
+		writeHunk(&b, hunk)
+	}
+	return b.String()
+}
+
+type diffKind int
+
+const (
+	diffEqual diffKind = iota
+	diffDelete
+	diffInsert
+)
+
+// diffOp is one line of the edit script. oldIndex and newIndex are the
+// positions of the line before the op is applied, so they also locate
+// inserts and deletes.
+type diffOp struct {
+	kind     diffKind
+	line     string
+	oldIndex int
+	newIndex int
+}
+
+// splitLines splits text into lines that keep their "\n", so a last line
+// without one compares unequal to the same line with one.
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines builds an edit script from the longest common subsequence of the
+// two line slices. The common prefix and suffix are stripped first, which
+// keeps the table small for the usual case of a few local merge changes.
+func diffLines(oldLines []string, newLines []string) []diffOp {
+	prefix := 0
+	for prefix < len(oldLines) && prefix < len(newLines) && oldLines[prefix] == newLines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(oldLines)-prefix && suffix < len(newLines)-prefix &&
+		oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+		suffix++
+	}
+
+	oldMid := oldLines[prefix : len(oldLines)-suffix]
+	newMid := newLines[prefix : len(newLines)-suffix]
+
+	// lcs[i][j] is the LCS length of oldMid[i:] and newMid[j:].
+	lcs := make([][]int, len(oldMid)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(newMid)+1)
+	}
+	for i := len(oldMid) - 1; i >= 0; i-- {
+		for j := len(newMid) - 1; j >= 0; j-- {
+			if oldMid[i] == newMid[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	ops := make([]diffOp, 0, len(oldLines)+len(newLines))
+	for i := 0; i < prefix; i++ {
+		ops = append(ops, diffOp{kind: diffEqual, line: oldLines[i], oldIndex: i, newIndex: i})
+	}
+	i, j := 0, 0
+	for i < len(oldMid) || j < len(newMid) {
+		switch {
+		case i < len(oldMid) && j < len(newMid) && oldMid[i] == newMid[j]:
+			ops = append(ops, diffOp{kind: diffEqual, line: oldMid[i], oldIndex: prefix + i, newIndex: prefix + j})
+			i++
+			j++
+		case j < len(newMid) && (i == len(oldMid) || lcs[i][j+1] > lcs[i+1][j]):
+			ops = append(ops, diffOp{kind: diffInsert, line: newMid[j], oldIndex: prefix + i, newIndex: prefix + j})
+			j++
+		default:
+			ops = append(ops, diffOp{kind: diffDelete, line: oldMid[i], oldIndex: prefix + i, newIndex: prefix + j})
+			i++
+		}
+	}
+	for k := 0; k < suffix; k++ {
+		ops = append(ops, diffOp{
+			kind:     diffEqual,
+			line:     oldLines[len(oldLines)-suffix+k],
+			oldIndex: len(oldLines) - suffix + k,
+			newIndex: len(newLines) - suffix + k,
+		})
+	}
+	return ops
+}
+
+// groupHunks splits the edit script into hunks of changes with up to
+// diffContext equal lines around them. Changes separated by at most twice the
+// context share a hunk.
+func groupHunks(ops []diffOp) [][]diffOp {
+	var hunks [][]diffOp
+	start, end := -1, -1
+	for idx, op := range ops {
+		if op.kind == diffEqual {
+			continue
+		}
+		if start >= 0 && idx-end-1 <= 2*diffContext {
+			end = idx
+			continue
+		}
+		if start >= 0 {
+			hunks = append(hunks, contextRange(ops, start, end))
+		}
+		start, end = idx, idx
+	}
+	if start >= 0 {
+		hunks = append(hunks, contextRange(ops, start, end))
+	}
+	return hunks
+}
+
+func contextRange(ops []diffOp, start int, end int) []diffOp {
+	from := max(start-diffContext, 0)
+	to := min(end+diffContext+1, len(ops))
+	return ops[from:to]
+}
+
+func writeHunk(b *strings.Builder, hunk []diffOp) {
+	oldCount, newCount := 0, 0
+	for _, op := range hunk {
+		if op.kind != diffInsert {
+			oldCount++
+		}
+		if op.kind != diffDelete {
+			newCount++
+		}
+	}
+	fmt.Fprintf(b, "@@ -%s +%s @@\n", hunkRange(hunk[0].oldIndex, oldCount), hunkRange(hunk[0].newIndex, newCount))
+	for _, op := range hunk {
+		prefix := " "
+		switch op.kind {
+		case diffDelete:
+			prefix = "-"
+		case diffInsert:
+			prefix = "+"
+		}
+		b.WriteString(prefix)
+		b.WriteString(op.line)
+		if !strings.HasSuffix(op.line, "\n") {
+			b.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// hunkRange formats a 0-based start and a line count the way diff -u does:
+// 1-based, the count omitted when it is 1, and an empty range anchored on
+// the line before it.
+func hunkRange(start int, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, count)
+	}
+}
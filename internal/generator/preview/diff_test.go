@@ -0,0 +1,86 @@
+package preview
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"docker-wizard/internal/generator/write"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	oldContent := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\n"
+	newContent := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n"
+
+	diff := UnifiedDiff("a/file", "b/file", oldContent, newContent)
+	expected := "" +
+		"--- a/file\n" +
+		"+++ b/file\n" +
+		"@@ -1,5 +1,5 @@\n" +
+		" a\n" +
+		"-b\n" +
+		"+B\n" +
+		" c\n" +
+		" d\n" +
+		" e\n" +
+		"@@ -10,3 +10,4 @@\n" +
+		" j\n" +
+		" k\n" +
+		" l\n" +
+		"+m\n"
+	if diff != expected {
+		t.Fatalf("unexpected diff:\n%s", diff)
+	}
+
+	if diff := UnifiedDiff("a/file", "b/file", oldContent, oldContent); diff != "" {
+		t.Fatalf("expected empty diff for identical content, got:\n%s", diff)
+	}
+}
+
+func TestUnifiedDiffNewFileAndMissingNewline(t *testing.T) {
+	diff := UnifiedDiff("/dev/null", "b/file", "", "one\ntwo")
+	expected := "" +
+		"--- /dev/null\n" +
+		"+++ b/file\n" +
+		"@@ -0,0 +1,2 @@\n" +
+		"+one\n" +
+		"+two\n" +
+		"\\ No newline at end of file\n"
+	if diff != expected {
+		t.Fatalf("unexpected diff:\n%s", diff)
+	}
+}
+
+func TestFilePreviewDiffComparesAgainstFileOnDisk(t *testing.T) {
+	root := t.TempDir()
+
+	existingCompose := "" +
+		"services:\n" +
+		"  custom:\n" +
+		"    image: busybox\n"
+	if err := os.WriteFile(filepath.Join(root, write.ComposeFileName), []byte(existingCompose), 0o644); err != nil {
+		t.Fatalf("write compose: %v", err)
+	}
+
+	generatedCompose := "" +
+		"services:\n" +
+		"  redis:\n" +
+		"    image: redis:7-alpine\n"
+
+	preview, err := PreviewComposeFile(root, generatedCompose)
+	if err != nil {
+		t.Fatalf("preview compose: %v", err)
+	}
+
+	diff := preview.Diff()
+	if !strings.HasPrefix(diff, "--- a/docker-compose.yml\n+++ b/docker-compose.yml\n") {
+		t.Fatalf("expected diff headers, got:\n%s", diff)
+	}
+	if !strings.Contains(diff, "+  redis:\n") || !strings.Contains(diff, "     image: busybox\n") {
+		t.Fatalf("expected diff to add redis next to the existing service, got:\n%s", diff)
+	}
+	if strings.Contains(diff, "\n-") {
+		t.Fatalf("expected no removed lines, got:\n%s", diff)
+	}
+}
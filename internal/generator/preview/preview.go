@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"docker-wizard/internal/generator/compose"
 	"docker-wizard/internal/generator/write"
 	"docker-wizard/internal/utils"
 )
@@ -18,35 +19,121 @@ const (
 	FileStatusExists    FileStatus = "exists"
 )
 
+// FilePreview is the content a managed file would get. Existing holds the
+// current file on disk, which Diff compares against; it is empty for new files.
 type FilePreview struct {
-	Path    string
-	Status  FileStatus
-	Content string
+	Path      string
+	Status    FileStatus
+	Content   string
+	Existing  string
+	Conflicts []write.Conflict
 }
 
+// Preview holds the previews of the managed files. Env and EnvExample are
+// zero when the compose file references no variables, ComposeOverride when
+// the compose output is not split.
 type Preview struct {
-	Compose      FilePreview
+	Compose         FilePreview
+	ComposeOverride FilePreview
+	Dockerfile      FilePreview
+	Dockerignore    FilePreview
+	Env             FilePreview
+	EnvExample      FilePreview
+	Secrets         []FilePreview
+	// Apps holds the previews of the app files of a monorepo, which replace
+	// Dockerfile and Dockerignore.
+	Apps []AppPreview
+}
+
+// AppPreview holds the previews of the Dockerfile and .dockerignore of an
+// app.
+type AppPreview struct {
+	Dir          string
 	Dockerfile   FilePreview
 	Dockerignore FilePreview
 }
 
+// secretPlaceholder stands in for the random values .env gets on write, so
+// previews do not print secrets that will never be used.
+const secretPlaceholder = "<random>"
+
 func PreviewFiles(root string, compose string, dockerfile string) (Preview, error) {
+	return PreviewFilesWithComposeOverride(root, compose, "", dockerfile)
+}
+
+// PreviewFilesWithComposeOverride previews the managed files of a split
+// compose output, with override merged into docker-compose.override.yml.
+func PreviewFilesWithComposeOverride(root string, compose string, override string, dockerfile string) (Preview, error) {
+	preview, err := previewFiles(root, compose, override, []write.AppFile{{Dir: ".", Dockerfile: dockerfile}})
+	if err != nil {
+		return Preview{}, err
+	}
+	preview.Dockerfile = preview.Apps[0].Dockerfile
+	preview.Dockerignore = preview.Apps[0].Dockerignore
+	preview.Apps = nil
+	return preview, nil
+}
+
+// PreviewAppFiles previews the managed files of a monorepo, with a
+// Dockerfile and .dockerignore in the directory of every app.
+func PreviewAppFiles(root string, compose string, override string, apps []write.AppFile) (Preview, error) {
+	if len(apps) == 0 {
+		return Preview{}, fmt.Errorf("no apps to preview")
+	}
+	return previewFiles(root, compose, override, apps)
+}
+
+func previewFiles(root string, compose string, override string, apps []write.AppFile) (Preview, error) {
 	if root == "" {
 		return Preview{}, fmt.Errorf("root directory is required")
 	}
 
 	composePath := filepath.Join(root, write.ComposeFileName)
-	dockerfilePath := filepath.Join(root, write.DockerfileFileName)
-	dockerignorePath := filepath.Join(root, write.DockerignoreFileName)
 
-	composePreview, err := buildFilePreview(composePath, compose, write.MergeCompose)
+	state, err := write.ReadState(root)
+	if err != nil {
+		return Preview{}, err
+	}
+
+	composePreview, err := buildFilePreview(composePath, state.Base(write.ComposeFileName), compose, write.MergeComposeThreeWay)
 	if err != nil {
 		return Preview{}, err
 	}
-	dockerfilePreview, err := buildFilePreview(dockerfilePath, dockerfile, write.MergeDockerfile)
+	var overridePreview FilePreview
+	if override != "" {
+		overridePath := filepath.Join(root, write.ComposeOverrideFileName)
+		overridePreview, err = buildFilePreview(overridePath, state.Base(write.ComposeOverrideFileName), override, write.MergeComposeThreeWay)
+		if err != nil {
+			return Preview{}, err
+		}
+	}
+
+	support, err := PreviewSupportFiles(root, compose)
 	if err != nil {
 		return Preview{}, err
 	}
+	support.Compose = composePreview
+	support.ComposeOverride = overridePreview
+	for _, app := range apps {
+		appPreview, err := buildAppPreview(root, app, state)
+		if err != nil {
+			return Preview{}, err
+		}
+		support.Apps = append(support.Apps, appPreview)
+	}
+	return support, nil
+}
+
+func buildAppPreview(root string, app write.AppFile, state write.State) (AppPreview, error) {
+	dir := filepath.Join(root, filepath.FromSlash(app.Dir))
+	dockerfilePath := filepath.Join(dir, write.DockerfileFileName)
+	dockerignorePath := filepath.Join(dir, write.DockerignoreFileName)
+
+	base := state.Base(write.AppFileName(app.Dir, write.DockerfileFileName))
+	dockerfilePreview, err := buildFilePreview(dockerfilePath, base, app.Dockerfile, write.MergeDockerfileThreeWay)
+	if err != nil {
+		return AppPreview{}, err
+	}
 
 	dockerignorePreview := FilePreview{Path: dockerignorePath, Status: FileStatusExists}
 	if !utils.FileExists(dockerignorePath) {
@@ -56,17 +143,100 @@ func PreviewFiles(root string, compose string, dockerfile string) (Preview, erro
 			Content: write.DefaultDockerignore(),
 		}
 	}
+	return AppPreview{Dir: app.Dir, Dockerfile: dockerfilePreview, Dockerignore: dockerignorePreview}, nil
+}
 
-	return Preview{
-		Compose:      composePreview,
-		Dockerfile:   dockerfilePreview,
-		Dockerignore: dockerignorePreview,
-	}, nil
+// PreviewSupportFiles previews the files a compose file needs besides itself:
+// .env, .env.example and the secret files. Only those fields of the result
+// are set. Used directly by the add subcommand.
+func PreviewSupportFiles(root string, compose string) (Preview, error) {
+	if root == "" {
+		return Preview{}, fmt.Errorf("root directory is required")
+	}
+	env, envExample, err := buildEnvPreviews(root, compose)
+	if err != nil {
+		return Preview{}, err
+	}
+	secrets, err := buildSecretPreviews(root, compose)
+	if err != nil {
+		return Preview{}, err
+	}
+	return Preview{Env: env, EnvExample: envExample, Secrets: secrets}, nil
 }
 
-type mergeFunc func(existing string, generated string) (string, error)
+// buildSecretPreviews previews the secret files. Existing ones are never
+// changed and new ones show the placeholder instead of a value.
+func buildSecretPreviews(root string, compose string) ([]FilePreview, error) {
+	paths, err := write.SecretFilePaths(root, compose)
+	if err != nil {
+		return nil, err
+	}
+	previews := make([]FilePreview, 0, len(paths))
+	for _, path := range paths {
+		if utils.FileExists(path) {
+			previews = append(previews, FilePreview{Path: path, Status: FileStatusExists})
+			continue
+		}
+		previews = append(previews, FilePreview{Path: path, Status: FileStatusNew, Content: secretPlaceholder})
+	}
+	return previews, nil
+}
+
+func buildEnvPreviews(root string, compose string) (FilePreview, FilePreview, error) {
+	if len(write.ComposeVariables(compose)) == 0 {
+		return FilePreview{}, FilePreview{}, nil
+	}
+
+	existingCompose, err := readExisting(filepath.Join(root, write.ComposeFileName))
+	if err != nil {
+		return FilePreview{}, FilePreview{}, err
+	}
 
-func buildFilePreview(path string, content string, merge mergeFunc) (FilePreview, error) {
+	envPath := filepath.Join(root, write.EnvFileName)
+	existingEnv, err := readExisting(envPath)
+	if err != nil {
+		return FilePreview{}, FilePreview{}, err
+	}
+	env, err := write.EnvFile(existingEnv, existingCompose, compose, nil, func() (string, error) { return secretPlaceholder, nil })
+	if err != nil {
+		return FilePreview{}, FilePreview{}, err
+	}
+
+	examplePath := filepath.Join(root, write.EnvExampleFileName)
+	existingExample, err := readExisting(examplePath)
+	if err != nil {
+		return FilePreview{}, FilePreview{}, err
+	}
+	example := write.EnvExample(existingExample, compose)
+
+	return envFilePreview(envPath, existingEnv, env), envFilePreview(examplePath, existingExample, example), nil
+}
+
+func envFilePreview(path string, existing string, content string) FilePreview {
+	status := FileStatusDifferent
+	switch {
+	case !utils.FileExists(path):
+		return FilePreview{Path: path, Status: FileStatusNew, Content: content}
+	case existing == content:
+		status = FileStatusSame
+	}
+	return FilePreview{Path: path, Status: status, Content: content, Existing: existing}
+}
+
+func readExisting(path string) (string, error) {
+	if !utils.FileExists(path) {
+		return "", nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read %s: %w", filepath.Base(path), err)
+	}
+	return string(data), nil
+}
+
+type mergeFunc func(base string, existing string, generated string) (string, []write.Conflict, error)
+
+func buildFilePreview(path string, base string, content string, merge mergeFunc) (FilePreview, error) {
 	if !utils.FileExists(path) {
 		return FilePreview{Path: path, Status: FileStatusNew, Content: content}, nil
 	}
@@ -77,12 +247,14 @@ func buildFilePreview(path string, content string, merge mergeFunc) (FilePreview
 	}
 
 	targetContent := content
+	var conflicts []write.Conflict
 	if merge != nil {
-		merged, mergeErr := merge(string(existing), content)
+		merged, mergeConflicts, mergeErr := merge(base, string(existing), content)
 		if mergeErr != nil {
 			return FilePreview{}, fmt.Errorf("merge %s: %w", filepath.Base(path), mergeErr)
 		}
 		targetContent = merged
+		conflicts = mergeConflicts
 	}
 
 	status := FileStatusDifferent
@@ -90,15 +262,70 @@ func buildFilePreview(path string, content string, merge mergeFunc) (FilePreview
 		status = FileStatusSame
 	}
 
-	return FilePreview{Path: path, Status: status, Content: targetContent}, nil
+	return FilePreview{Path: path, Status: status, Content: targetContent, Existing: string(existing), Conflicts: conflicts}, nil
 }
 
 // PreviewComposeFile previews only the compose file merge result, without
 // touching Dockerfile or .dockerignore. Used by the add subcommand.
 func PreviewComposeFile(root string, compose string) (FilePreview, error) {
+	if root == "" {
+		return FilePreview{}, fmt.Errorf("root directory is required")
+	}
+	state, err := write.ReadState(root)
+	if err != nil {
+		return FilePreview{}, err
+	}
+	base := state.Base(write.ComposeFileName)
+	generated, err := write.ComposeWithFragment(base, compose)
+	if err != nil {
+		return FilePreview{}, err
+	}
+	composePath := filepath.Join(root, write.ComposeFileName)
+	return buildFilePreview(composePath, base, generated, write.MergeComposeThreeWay)
+}
+
+// PreviewComposeRemoval previews the compose file after applying a removal
+// plan. Used by the remove subcommand.
+func PreviewComposeRemoval(root string, plan compose.Removal) (FilePreview, error) {
 	if root == "" {
 		return FilePreview{}, fmt.Errorf("root directory is required")
 	}
 	composePath := filepath.Join(root, write.ComposeFileName)
-	return buildFilePreview(composePath, compose, write.MergeCompose)
+	existing, err := os.ReadFile(composePath)
+	if err != nil {
+		return FilePreview{}, fmt.Errorf("read %s: %w", write.ComposeFileName, err)
+	}
+	content, err := write.RemoveComposeServices(string(existing), plan)
+	if err != nil {
+		return FilePreview{}, err
+	}
+	status := FileStatusDifferent
+	if string(existing) == content {
+		status = FileStatusSame
+	}
+	return FilePreview{Path: composePath, Status: status, Content: content, Existing: string(existing)}, nil
+}
+
+// ConflictWarnings describes the merge conflicts of the compose files and the
+// Dockerfiles, one line per conflict.
+func (p Preview) ConflictWarnings() []string {
+	warnings := ConflictWarnings(write.ComposeFileName, p.Compose.Conflicts)
+	warnings = append(warnings, ConflictWarnings(write.ComposeOverrideFileName, p.ComposeOverride.Conflicts)...)
+	warnings = append(warnings, ConflictWarnings(write.DockerfileFileName, p.Dockerfile.Conflicts)...)
+	for _, app := range p.Apps {
+		warnings = append(warnings, ConflictWarnings(write.AppFileName(app.Dir, write.DockerfileFileName), app.Dockerfile.Conflicts)...)
+	}
+	return warnings
+}
+
+func ConflictWarnings(fileName string, conflicts []write.Conflict) []string {
+	warnings := make([]string, 0, len(conflicts))
+	for _, conflict := range conflicts {
+		if conflict.NoBase {
+			warnings = append(warnings, fmt.Sprintf("%s: existing file differs from generated %s", fileName, conflict))
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf("%s: both you and the generator changed %s", fileName, conflict))
+	}
+	return warnings
 }
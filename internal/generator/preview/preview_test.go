@@ -124,3 +124,84 @@ func TestPreviewFilesComposeReflectsEnvironmentAndPortConflictMerge(t *testing.T
 		t.Fatalf("expected preview to include non-conflicting generated mapping")
 	}
 }
+
+func TestPreviewFilesShowsEnvVariablesWithoutSecrets(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, write.EnvFileName), []byte("POSTGRES_PASSWORD=hunter2\n"), 0o600); err != nil {
+		t.Fatalf("write env: %v", err)
+	}
+
+	generatedCompose := "" +
+		"services:\n" +
+		"  postgres:\n" +
+		"    environment:\n" +
+		"      - POSTGRES_PASSWORD=${POSTGRES_PASSWORD}\n" +
+		"      - API_TOKEN=${API_TOKEN}\n"
+
+	preview, err := PreviewFiles(root, generatedCompose, "FROM alpine:3.20\n")
+	if err != nil {
+		t.Fatalf("preview files: %v", err)
+	}
+
+	if preview.Env.Status != FileStatusDifferent {
+		t.Fatalf("expected env status different, got %s", preview.Env.Status)
+	}
+	if preview.Env.Content != "POSTGRES_PASSWORD=hunter2\nAPI_TOKEN=<random>\n" {
+		t.Fatalf("unexpected env preview:\n%s", preview.Env.Content)
+	}
+	if preview.EnvExample.Status != FileStatusNew || !strings.Contains(preview.EnvExample.Content, "\nPOSTGRES_PASSWORD=\nAPI_TOKEN=\n") {
+		t.Fatalf("unexpected env example preview (%s):\n%s", preview.EnvExample.Status, preview.EnvExample.Content)
+	}
+}
+
+func TestPreviewFilesListsSecretFiles(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "secrets"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "secrets", "a.txt"), []byte("kept"), 0o644); err != nil {
+		t.Fatalf("write secret: %v", err)
+	}
+
+	generatedCompose := "" +
+		"services:\n" +
+		"  db:\n" +
+		"    secrets:\n" +
+		"      - a\n" +
+		"      - b\n" +
+		"secrets:\n" +
+		"  a:\n" +
+		"    file: ./secrets/a.txt\n" +
+		"  b:\n" +
+		"    file: ./secrets/b.txt\n"
+
+	preview, err := PreviewFiles(root, generatedCompose, "FROM alpine:3.20\n")
+	if err != nil {
+		t.Fatalf("preview files: %v", err)
+	}
+	if len(preview.Secrets) != 2 {
+		t.Fatalf("expected two secret previews, got %+v", preview.Secrets)
+	}
+	if preview.Secrets[0].Status != FileStatusExists || preview.Secrets[0].Content != "" {
+		t.Fatalf("expected the existing secret to be kept unread, got %+v", preview.Secrets[0])
+	}
+	if preview.Secrets[1].Status != FileStatusNew || preview.Secrets[1].Content != "<random>" {
+		t.Fatalf("expected a new secret with a placeholder, got %+v", preview.Secrets[1])
+	}
+}
+
+func TestConflictWarningsTellMergesWithoutBaseApart(t *testing.T) {
+	warnings := ConflictWarnings(write.DockerfileFileName, []write.Conflict{
+		{Path: "Dockerfile CMD", Existing: "CMD [\"a\"]", Generated: "CMD [\"b\"]"},
+		{Path: "Dockerfile CMD", Existing: "ENTRYPOINT [\"a\"]", Generated: "CMD [\"b\"]", NoBase: true},
+	})
+	if len(warnings) != 2 {
+		t.Fatalf("expected one warning per conflict, got %v", warnings)
+	}
+	if !strings.Contains(warnings[0], "both you and the generator changed") {
+		t.Fatalf("expected a three-way conflict, got %q", warnings[0])
+	}
+	if !strings.Contains(warnings[1], "existing file differs from generated") || strings.Contains(warnings[1], "both you") {
+		t.Fatalf("expected a difference without a base, got %q", warnings[1])
+	}
+}
@@ -0,0 +1,195 @@
+package project
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"docker-wizard/internal/generator/catalog"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	FileNameYAML = ".docker-wizard.yml"
+	FileNameJSON = ".docker-wizard.json"
+)
+
+// fileNames lists the accepted config file names in lookup order.
+var fileNames = []string{FileNameYAML, ".docker-wizard.yaml", FileNameJSON}
+
+// Config is the project config file. It pins the generated stack so a batch
+// run reproduces it without flags.
+type Config struct {
+	Services  []string                           `json:"services,omitempty" yaml:"services,omitempty"`
+	Language  string                             `json:"language,omitempty" yaml:"language,omitempty"`
+	App       catalog.AppSettings                `json:"app,omitzero" yaml:"app,omitempty"`
+	Overrides map[string]catalog.ServiceOverride `json:"overrides,omitempty" yaml:"overrides,omitempty"`
+	// ComposeOverride splits local development settings into
+	// docker-compose.override.yml.
+	ComposeOverride bool `json:"composeOverride,omitempty" yaml:"composeOverride,omitempty"`
+	// Workspaces lists the app directories of a monorepo as glob patterns,
+	// replacing the detected workspaces.
+	Workspaces []string `json:"workspaces,omitempty" yaml:"workspaces,omitempty"`
+	// ExcludeApps lists the apps of a monorepo, by service name, that get
+	// no service or Dockerfile.
+	ExcludeApps []string `json:"excludeApps,omitempty" yaml:"excludeApps,omitempty"`
+}
+
+// Find returns the path of the project config file, or "" when there is none.
+func Find(root string) (string, error) {
+	for _, name := range fileNames {
+		path := filepath.Join(root, name)
+		info, err := os.Stat(path)
+		if err == nil {
+			if info.IsDir() {
+				return "", fmt.Errorf("%s is a directory", name)
+			}
+			return path, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", fmt.Errorf("stat %s: %w", name, err)
+		}
+	}
+	return "", nil
+}
+
+// Load reads the project config file. It returns an empty config and an
+// empty path when the project has none. JSON files are read with the YAML
+// decoder, which accepts them as well, so both formats share one schema.
+func Load(root string) (Config, string, error) {
+	if root == "" {
+		return Config{}, "", fmt.Errorf("root directory is required")
+	}
+	path, err := Find(root)
+	if err != nil || path == "" {
+		return Config{}, "", err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, "", fmt.Errorf("read %s: %w", filepath.Base(path), err)
+	}
+	config, err := Parse(data)
+	if err != nil {
+		return Config{}, "", fmt.Errorf("parse %s: %w", filepath.Base(path), err)
+	}
+	return config, path, nil
+}
+
+// Parse decodes a YAML or JSON config. Unknown keys are rejected so typos do
+// not silently change nothing.
+func Parse(data []byte) (Config, error) {
+	var config Config
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+		return Config{}, err
+	}
+	config.normalize()
+	if err := config.validate(); err != nil {
+		return Config{}, err
+	}
+	return config, nil
+}
+
+// validate checks the override ports, which end up in the compose file and
+// the connection strings of the apps.
+func (c Config) validate() error {
+	ids := make([]string, 0, len(c.Overrides))
+	for id := range c.Overrides {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if err := catalog.ValidatePorts(c.Overrides[id].Ports); err != nil {
+			return fmt.Errorf("overrides.%s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+func (c *Config) normalize() {
+	services := make([]string, 0, len(c.Services))
+	seen := map[string]bool{}
+	for _, id := range c.Services {
+		id = strings.ToLower(strings.TrimSpace(id))
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		services = append(services, id)
+	}
+	c.Services = services
+	c.Language = strings.TrimSpace(c.Language)
+	c.App.Name = strings.TrimSpace(c.App.Name)
+	c.App.Port = strings.TrimSpace(c.App.Port)
+	c.App.Command = strings.TrimSpace(c.App.Command)
+	c.App.Target = strings.TrimSpace(c.App.Target)
+	c.App.Platforms = trimmedValues(c.App.Platforms)
+	c.Workspaces = trimmedValues(c.Workspaces)
+	c.ExcludeApps = trimmedValues(c.ExcludeApps)
+}
+
+// trimmedValues trims the values of a list and drops the empty ones.
+func trimmedValues(values []string) []string {
+	var trimmed []string
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			trimmed = append(trimmed, value)
+		}
+	}
+	return trimmed
+}
+
+// Save writes the config back to the existing project config file, keeping
+// its format, or to .docker-wizard.yml when there is none. It returns the
+// written path.
+func Save(root string, config Config) (string, error) {
+	if root == "" {
+		return "", fmt.Errorf("root directory is required")
+	}
+	path, err := Find(root)
+	if err != nil {
+		return "", err
+	}
+	if path == "" {
+		path = filepath.Join(root, FileNameYAML)
+	}
+
+	content, err := config.marshal(filepath.Ext(path) == ".json")
+	if err != nil {
+		return "", fmt.Errorf("encode %s: %w", filepath.Base(path), err)
+	}
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		return "", fmt.Errorf("write %s: %w", filepath.Base(path), err)
+	}
+	return path, nil
+}
+
+func (c Config) marshal(asJSON bool) ([]byte, error) {
+	if asJSON {
+		data, err := json.MarshalIndent(c, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(c); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
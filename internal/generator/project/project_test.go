@@ -0,0 +1,122 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadYAMLConfig(t *testing.T) {
+	root := t.TempDir()
+	content := `services: [Postgres, redis, postgres]
+language: python
+app:
+  port: 8000
+  command: gunicorn app:app
+overrides:
+  postgres:
+    image: postgres:16.4
+    ports:
+      - "5544:5432"
+`
+	if err := os.WriteFile(filepath.Join(root, FileNameYAML), []byte(content), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	config, path, err := Load(root)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if filepath.Base(path) != FileNameYAML {
+		t.Fatalf("expected %s, got %s", FileNameYAML, path)
+	}
+	if strings.Join(config.Services, ",") != "postgres,redis" {
+		t.Fatalf("expected normalized services, got %v", config.Services)
+	}
+	if config.App.Port != "8000" || config.App.Command != "gunicorn app:app" {
+		t.Fatalf("unexpected app settings: %+v", config.App)
+	}
+	if config.Overrides["postgres"].Image != "postgres:16.4" || config.Overrides["postgres"].Ports[0] != "5544:5432" {
+		t.Fatalf("unexpected overrides: %+v", config.Overrides)
+	}
+}
+
+func TestLoadJSONConfigAndMissingFile(t *testing.T) {
+	root := t.TempDir()
+
+	config, path, err := Load(root)
+	if err != nil || path != "" || len(config.Services) != 0 {
+		t.Fatalf("expected empty config without file, got %+v %q %v", config, path, err)
+	}
+
+	content := `{"services": ["mysql"], "app": {"port": 3000}}`
+	if err := os.WriteFile(filepath.Join(root, FileNameJSON), []byte(content), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	config, _, err = Load(root)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if config.Services[0] != "mysql" || config.App.Port != "3000" {
+		t.Fatalf("unexpected config: %+v", config)
+	}
+}
+
+func TestLoadRejectsUnknownKeys(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, FileNameYAML), []byte("service: [mysql]\n"), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if _, _, err := Load(root); err == nil {
+		t.Fatal("expected error for unknown key")
+	}
+}
+
+func TestLoadRejectsInvalidOverridePorts(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, FileNameYAML), []byte("overrides:\n  postgres:\n    ports: [abc]\n"), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	_, _, err := Load(root)
+	if err == nil || !strings.Contains(err.Error(), "overrides.postgres") {
+		t.Fatalf("expected an invalid port error for postgres, got %v", err)
+	}
+}
+
+func TestSaveKeepsExistingFormat(t *testing.T) {
+	root := t.TempDir()
+
+	path, err := Save(root, Config{Services: []string{"mysql"}, Language: "go"})
+	if err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read config: %v", err)
+	}
+	if string(data) != "services:\n  - mysql\nlanguage: go\n" {
+		t.Fatalf("unexpected yaml config:\n%s", data)
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("remove config: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, FileNameJSON), []byte("{}\n"), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	path, err = Save(root, Config{Services: []string{"redis"}})
+	if err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if filepath.Base(path) != FileNameJSON {
+		t.Fatalf("expected json config to be updated, got %s", path)
+	}
+	data, err = os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read config: %v", err)
+	}
+	if string(data) != "{\n  \"services\": [\n    \"redis\"\n  ]\n}\n" {
+		t.Fatalf("unexpected json config:\n%s", data)
+	}
+}
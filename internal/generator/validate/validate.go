@@ -14,12 +14,16 @@ func SelectionWarnings(root string, selection compose.ComposeSelection) ([]strin
 		return nil, fmt.Errorf("root directory is required")
 	}
 
-	serviceMap, _, err := catalog.CatalogMap(root)
+	serviceMap, ordered, err := catalog.CatalogMap(root)
 	if err != nil {
 		return nil, err
 	}
+	if err := catalog.ApplyOverrides(serviceMap, selection.Overrides); err != nil {
+		return nil, err
+	}
 
 	selected := make(map[string]bool, len(selection.Services))
+	ids := make([]string, 0, len(selection.Services))
 	for _, id := range selection.Services {
 		if id == "" {
 			continue
@@ -28,16 +32,29 @@ func SelectionWarnings(root string, selection compose.ComposeSelection) ([]strin
 			return nil, fmt.Errorf("unknown service: %s", id)
 		}
 		selected[id] = true
+		ids = append(ids, id)
 	}
 
 	if err := compose.ExpandRequiredServices(selected, serviceMap); err != nil {
 		return nil, err
 	}
 
+	selection.Services = ids
+	doc, override, err := compose.ComposeDocuments(root, selection)
+	if err != nil {
+		return nil, err
+	}
+	labels := serviceLabels(serviceMap)
+	// the override file adds to the services, so its ports and env count too
+	combined := compose.Document{Services: append(append([]compose.Service(nil), doc.Services...), override.Services...)}
+
 	warnings := []string{}
 	warnings = append(warnings, dependencyWarnings(selected, serviceMap)...)
-	warnings = append(warnings, portCollisionWarnings(selected, serviceMap)...)
-	warnings = append(warnings, insecureDefaultWarnings(selected, serviceMap)...)
+	warnings = append(warnings, portCollisionWarnings(combined, labels)...)
+	warnings = append(warnings, appEnvWarnings(ordered, selected, serviceMap)...)
+	warnings = append(warnings, insecureDefaultWarnings(combined, labels)...)
+	warnings = append(warnings, profileWarnings(doc, labels)...)
+	warnings = append(warnings, platformWarnings(doc, selected, serviceMap)...)
 	sort.Strings(warnings)
 	return warnings, nil
 }
@@ -66,20 +83,11 @@ func dependencyWarnings(selected map[string]bool, services map[string]catalog.Se
 	return warnings
 }
 
-func portCollisionWarnings(selected map[string]bool, services map[string]catalog.ServiceSpec) []string {
+// portCollisionWarnings reports host ports published by more than one
+// service of the generated compose document.
+func portCollisionWarnings(doc compose.Document, labels map[string]string) []string {
 	portOwners := map[string]map[string]bool{}
-	selectedServices := make([]catalog.ServiceSpec, 0, len(selected)+1)
-	selectedServices = append(selectedServices, compose.AppServiceSpec())
-	for id := range selected {
-		if svc, ok := services[id]; ok {
-			selectedServices = append(selectedServices, svc)
-		}
-	}
-
-	for _, svc := range selectedServices {
-		if !svc.Public {
-			continue
-		}
+	for _, svc := range doc.Services {
 		for _, port := range svc.Ports {
 			host := hostPort(port)
 			if host == "" {
@@ -90,7 +98,7 @@ func portCollisionWarnings(selected map[string]bool, services map[string]catalog
 				owners = map[string]bool{}
 				portOwners[host] = owners
 			}
-			owners[serviceDisplayName(svc)] = true
+			owners[composeServiceLabel(svc, labels)] = true
 		}
 	}
 
@@ -106,17 +114,142 @@ func portCollisionWarnings(selected map[string]bool, services map[string]catalog
 		if len(owners) < 2 {
 			continue
 		}
-		labels := make([]string, 0, len(owners))
+		names := make([]string, 0, len(owners))
 		for owner := range owners {
-			labels = append(labels, owner)
+			names = append(names, owner)
+		}
+		sort.Strings(names)
+		warnings = append(warnings, fmt.Sprintf("host port %s is published by %s", port, strings.Join(names, ", ")))
+	}
+
+	return warnings
+}
+
+// appEnvWarnings reports variables exported to the app by more than one
+// selected service. The app gets the value of the first in catalog order.
+func appEnvWarnings(ordered []catalog.ServiceSpec, selected map[string]bool, services map[string]catalog.ServiceSpec) []string {
+	owners := map[string][]string{}
+	keys := []string{}
+	for _, spec := range ordered {
+		if !selected[spec.ID] {
+			continue
+		}
+		svc := services[spec.ID]
+		exported, err := svc.ExportedEnv()
+		if err != nil {
+			continue
+		}
+		for _, entry := range exported {
+			key, _, _ := strings.Cut(entry, "=")
+			if len(owners[key]) == 0 {
+				keys = append(keys, key)
+			}
+			owners[key] = append(owners[key], serviceDisplayName(svc))
 		}
-		sort.Strings(labels)
-		warnings = append(warnings, fmt.Sprintf("host port %s is published by %s", port, strings.Join(labels, ", ")))
 	}
 
+	warnings := []string{}
+	for _, key := range keys {
+		names := owners[key]
+		if len(names) < 2 {
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf("%s is exported to the app by %s; the app uses the value from %s", key, strings.Join(names, ", "), names[0]))
+	}
+	return warnings
+}
+
+// profileWarnings reports services that can start without a profile their
+// dependency needs: docker compose up then fails because the dependency is
+// not enabled.
+func profileWarnings(doc compose.Document, labels map[string]string) []string {
+	warnings := []string{}
+	for _, svc := range doc.Services {
+		for _, dep := range svc.DependsOn.Entries {
+			target, ok := doc.Service(dep.Name)
+			if !ok || len(target.Profiles) == 0 || coversProfiles(target.Profiles, svc.Profiles) {
+				continue
+			}
+			warnings = append(warnings, fmt.Sprintf("%s depends on %s, which only starts with profile %s",
+				composeServiceLabel(svc, labels), composeServiceLabel(*target, labels), strings.Join(target.Profiles, ", ")))
+		}
+	}
 	return warnings
 }
 
+// platformWarnings reports selected images that would run emulated: images
+// the catalog records no variant of an app build platform for, and, when the
+// apps build for the default platform only, images published for a single
+// architecture. Images without recorded platforms are not checked.
+func platformWarnings(doc compose.Document, selected map[string]bool, services map[string]catalog.ServiceSpec) []string {
+	var targets []string
+	for _, svc := range doc.Services {
+		if svc.Build == nil {
+			continue
+		}
+		for _, platform := range svc.Build.Platforms {
+			if !containsString(targets, platform) {
+				targets = append(targets, platform)
+			}
+		}
+	}
+
+	warnings := []string{}
+	for id := range selected {
+		svc, ok := services[id]
+		if !ok || len(svc.Platforms) == 0 {
+			continue
+		}
+		label := serviceDisplayName(svc)
+		if len(targets) == 0 {
+			if len(svc.Platforms) == 1 {
+				warnings = append(warnings, fmt.Sprintf("%s image %s has no multi-arch manifest (%s only); other architectures run it emulated", label, svc.Image, svc.Platforms[0]))
+			}
+			continue
+		}
+		var missing []string
+		for _, platform := range targets {
+			if !containsString(svc.Platforms, platform) {
+				missing = append(missing, platform)
+			}
+		}
+		if len(missing) > 0 {
+			warnings = append(warnings, fmt.Sprintf("%s image %s is not published for %s; it runs emulated there", label, svc.Image, strings.Join(missing, ", ")))
+		}
+	}
+	return warnings
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// coversProfiles reports whether every profile that starts a service also
+// starts its dependency. A service without profiles always starts.
+func coversProfiles(dependency []string, dependent []string) bool {
+	if len(dependent) == 0 {
+		return false
+	}
+	for _, profile := range dependent {
+		found := false
+		for _, candidate := range dependency {
+			if candidate == profile {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
 func serviceDisplayName(svc catalog.ServiceSpec) string {
 	if svc.Label != "" {
 		return svc.Label
@@ -130,6 +263,22 @@ func serviceDisplayName(svc catalog.ServiceSpec) string {
 	return "service"
 }
 
+// serviceLabels maps compose service names to catalog labels.
+func serviceLabels(services map[string]catalog.ServiceSpec) map[string]string {
+	labels := make(map[string]string, len(services))
+	for _, svc := range services {
+		labels[svc.Name] = serviceDisplayName(svc)
+	}
+	return labels
+}
+
+func composeServiceLabel(svc compose.Service, labels map[string]string) string {
+	if label, ok := labels[svc.Name]; ok {
+		return label
+	}
+	return svc.Name
+}
+
 func hostPort(port string) string {
 	parts := strings.Split(port, ":")
 	switch len(parts) {
@@ -144,17 +293,12 @@ func hostPort(port string) string {
 	}
 }
 
-func insecureDefaultWarnings(selected map[string]bool, services map[string]catalog.ServiceSpec) []string {
+func insecureDefaultWarnings(doc compose.Document, labels map[string]string) []string {
 	warnings := []string{}
-	for id := range selected {
-		svc, ok := services[id]
-		if !ok {
-			continue
-		}
-
-		label := serviceDisplayName(svc)
-		for _, env := range svc.Env {
-			if insecureEnvDefault(env) {
+	for _, svc := range doc.Services {
+		label := composeServiceLabel(svc, labels)
+		for _, env := range svc.Environment.Vars {
+			if env.HasValue && insecureEnvDefault(env.Value) {
 				warnings = append(warnings, fmt.Sprintf("%s includes placeholder environment defaults; update them before sharing or exposing this stack", label))
 				break
 			}
@@ -172,16 +316,8 @@ func insecureDefaultWarnings(selected map[string]bool, services map[string]catal
 	return dedupeStrings(warnings)
 }
 
-func insecureEnvDefault(entry string) bool {
-	entry = strings.TrimSpace(entry)
-	if entry == "" {
-		return false
-	}
-	idx := strings.Index(entry, "=")
-	if idx < 0 || idx == len(entry)-1 {
-		return false
-	}
-	value := strings.ToLower(strings.TrimSpace(entry[idx+1:]))
+func insecureEnvDefault(value string) bool {
+	value = strings.ToLower(strings.TrimSpace(value))
 	if value == "" {
 		return false
 	}
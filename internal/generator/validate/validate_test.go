@@ -6,6 +6,7 @@ import (
 	"strings"
 	"testing"
 
+	"docker-wizard/internal/generator/catalog"
 	"docker-wizard/internal/generator/compose"
 )
 
@@ -28,7 +29,7 @@ func TestSelectionWarningsIncludesInsecureDefaults(t *testing.T) {
       "category": "database",
       "image": "postgres:16",
       "selectable": true,
-      "env": ["POSTGRES_PASSWORD=example"],
+      "env": ["POSTGRES_USER=example"],
       "order": 20
     }
   ]
@@ -87,6 +88,43 @@ func TestSelectionWarningsOmitsInsecureDefaultsForSafeValues(t *testing.T) {
 	}
 }
 
+func TestSelectionWarningsSkipsSecretsMovedToEnvFile(t *testing.T) {
+	root := t.TempDir()
+	writeServicesCatalog(t, root, `{
+  "services": [
+    {
+      "id": "db",
+      "label": "Database",
+      "category": "database",
+      "image": "postgres:16",
+      "selectable": true,
+      "env": ["POSTGRES_PASSWORD=example"],
+      "order": 20
+    }
+  ]
+}`)
+
+	warnings, err := SelectionWarnings(root, compose.ComposeSelection{Services: []string{"db"}})
+	if err != nil {
+		t.Fatalf("selection warnings: %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Fatalf("expected the password to move to .env without warnings, got: %v", warnings)
+	}
+
+	overridden := compose.ComposeSelection{
+		Services:  []string{"db"},
+		Overrides: map[string]catalog.ServiceOverride{"db": {Env: []string{"POSTGRES_PASSWORD=change-me"}}},
+	}
+	warnings, err = SelectionWarnings(root, overridden)
+	if err != nil {
+		t.Fatalf("selection warnings: %v", err)
+	}
+	if !strings.Contains(strings.Join(warnings, "\n"), "Database includes placeholder environment defaults") {
+		t.Fatalf("expected a placeholder set by override to be reported, got: %v", warnings)
+	}
+}
+
 func TestSelectionWarningsDoesNotFlagSinglePartPortsAsCollision(t *testing.T) {
 	root := t.TempDir()
 	writeServicesCatalog(t, root, `{
@@ -126,6 +164,211 @@ func TestSelectionWarningsDoesNotFlagSinglePartPortsAsCollision(t *testing.T) {
 	}
 }
 
+func TestSelectionWarningsChecksOverriddenPorts(t *testing.T) {
+	root := t.TempDir()
+	writeServicesCatalog(t, root, `{
+  "services": [
+    {
+      "id": "svc-a",
+      "label": "Service A",
+      "category": "proxy",
+      "image": "busybox",
+      "ports": ["9090:80"],
+      "public": true,
+      "selectable": true,
+      "order": 10
+    },
+    {
+      "id": "svc-b",
+      "label": "Service B",
+      "category": "analytics",
+      "image": "busybox",
+      "ports": ["9000"],
+      "selectable": true,
+      "order": 20
+    }
+  ]
+}`)
+
+	selection := compose.ComposeSelection{
+		Services:  []string{"svc-a", "svc-b"},
+		Overrides: map[string]catalog.ServiceOverride{"svc-b": {Ports: []string{"9090:9000"}}},
+	}
+	warnings, err := SelectionWarnings(root, selection)
+	if err != nil {
+		t.Fatalf("selection warnings: %v", err)
+	}
+	if !strings.Contains(strings.Join(warnings, "\n"), "host port 9090 is published by Service A, Service B") {
+		t.Fatalf("expected collision on overridden port, got: %v", warnings)
+	}
+
+	selection.Overrides["svc-a"] = catalog.ServiceOverride{Ports: []string{"9091:80"}}
+	warnings, err = SelectionWarnings(root, selection)
+	if err != nil {
+		t.Fatalf("selection warnings: %v", err)
+	}
+	for _, warning := range warnings {
+		if strings.Contains(warning, "host port") {
+			t.Fatalf("did not expect collision after moving the port: %v", warnings)
+		}
+	}
+}
+
+func TestSelectionWarningsReportsDuplicateAppEnv(t *testing.T) {
+	root := t.TempDir()
+	writeServicesCatalog(t, root, `{
+  "services": [
+    {
+      "id": "mysql",
+      "label": "MySQL",
+      "category": "database",
+      "image": "mysql:8.0",
+      "ports": ["3306"],
+      "selectable": true,
+      "order": 10,
+      "appEnv": ["DATABASE_URL=mysql://{{ .Host }}:{{ .Port }}"]
+    },
+    {
+      "id": "postgres",
+      "label": "PostgreSQL",
+      "category": "database",
+      "image": "postgres:16",
+      "ports": ["5432"],
+      "selectable": true,
+      "order": 20,
+      "appEnv": ["DATABASE_URL=postgres://{{ .Host }}:{{ .Port }}"]
+    }
+  ]
+}`)
+
+	warnings, err := SelectionWarnings(root, compose.ComposeSelection{Services: []string{"postgres", "mysql"}})
+	if err != nil {
+		t.Fatalf("selection warnings: %v", err)
+	}
+	if !strings.Contains(strings.Join(warnings, "\n"), "DATABASE_URL is exported to the app by MySQL, PostgreSQL; the app uses the value from MySQL") {
+		t.Fatalf("expected duplicate app env warning, got: %v", warnings)
+	}
+}
+
+func TestSelectionWarningsReportsDependenciesOnProfiledServices(t *testing.T) {
+	root := t.TempDir()
+	writeServicesCatalog(t, root, `{
+  "services": [
+    {
+      "id": "postgres",
+      "label": "PostgreSQL",
+      "category": "database",
+      "image": "postgres:16",
+      "selectable": true,
+      "order": 10
+    },
+    {
+      "id": "metabase",
+      "label": "Metabase",
+      "category": "analytics",
+      "image": "metabase/metabase:latest",
+      "selectable": true,
+      "dependsOn": ["postgres"],
+      "order": 20
+    },
+    {
+      "id": "pgadmin",
+      "label": "pgAdmin",
+      "category": "analytics",
+      "image": "dpage/pgadmin4:latest",
+      "selectable": true,
+      "dependsOn": ["postgres"],
+      "order": 30
+    }
+  ]
+}`)
+
+	selection := compose.ComposeSelection{
+		Services: []string{"postgres", "metabase", "pgadmin"},
+		Overrides: map[string]catalog.ServiceOverride{
+			"postgres": {Profiles: []string{"debug"}},
+			"pgadmin":  {Profiles: []string{"debug"}},
+		},
+	}
+	warnings, err := SelectionWarnings(root, selection)
+	if err != nil {
+		t.Fatalf("selection warnings: %v", err)
+	}
+	joined := strings.Join(warnings, "\n")
+	if !strings.Contains(joined, "Metabase depends on PostgreSQL, which only starts with profile debug") {
+		t.Fatalf("expected profile warning for metabase, got: %v", warnings)
+	}
+	if strings.Contains(joined, "pgAdmin depends on") {
+		t.Fatalf("expected no warning for a service with the same profile, got: %v", warnings)
+	}
+}
+
+func TestSelectionWarningsReportsSingleArchImages(t *testing.T) {
+	root := t.TempDir()
+	writeServicesCatalog(t, root, `{
+  "services": [
+    {
+      "id": "postgres",
+      "label": "PostgreSQL",
+      "category": "database",
+      "image": "postgres:16",
+      "platforms": ["linux/amd64", "linux/arm64"],
+      "selectable": true,
+      "order": 10
+    },
+    {
+      "id": "metabase",
+      "label": "Metabase",
+      "category": "analytics",
+      "image": "metabase/metabase:latest",
+      "platforms": ["linux/amd64"],
+      "selectable": true,
+      "order": 20
+    },
+    {
+      "id": "pgadmin",
+      "label": "pgAdmin",
+      "category": "analytics",
+      "image": "dpage/pgadmin4:latest",
+      "selectable": true,
+      "order": 30
+    }
+  ]
+}`)
+
+	selection := compose.ComposeSelection{Services: []string{"postgres", "metabase", "pgadmin"}}
+	warnings, err := SelectionWarnings(root, selection)
+	if err != nil {
+		t.Fatalf("selection warnings: %v", err)
+	}
+	joined := strings.Join(warnings, "\n")
+	if !strings.Contains(joined, "Metabase image metabase/metabase:latest has no multi-arch manifest (linux/amd64 only)") {
+		t.Fatalf("expected a single-arch warning for metabase, got: %v", warnings)
+	}
+	if strings.Contains(joined, "PostgreSQL image") || strings.Contains(joined, "pgAdmin image") {
+		t.Fatalf("expected no warning for multi-arch or unrecorded images, got: %v", warnings)
+	}
+
+	selection.App.Platforms = []string{"linux/amd64", "linux/arm64"}
+	warnings, err = SelectionWarnings(root, selection)
+	if err != nil {
+		t.Fatalf("selection warnings: %v", err)
+	}
+	joined = strings.Join(warnings, "\n")
+	if !strings.Contains(joined, "Metabase image metabase/metabase:latest is not published for linux/arm64") {
+		t.Fatalf("expected a missing platform warning for metabase, got: %v", warnings)
+	}
+
+	selection.Overrides = map[string]catalog.ServiceOverride{"metabase": {Image: "example/metabase:arm"}}
+	warnings, err = SelectionWarnings(root, selection)
+	if err != nil {
+		t.Fatalf("selection warnings: %v", err)
+	}
+	if strings.Contains(strings.Join(warnings, "\n"), "Metabase image") {
+		t.Fatalf("expected no platform warning for an overridden image, got: %v", warnings)
+	}
+}
+
 func writeServicesCatalog(t *testing.T, root string, content string) {
 	t.Helper()
 	configDir := filepath.Join(root, "config")
@@ -0,0 +1,63 @@
+package write
+
+import "fmt"
+
+// Conflict is a value that both the user and the generator changed since the
+// last generated output. The merge keeps the existing value and reports it.
+type Conflict struct {
+	Path      string
+	Existing  string
+	Generated string
+	// NoBase is set when no output was recorded, so the existing file only
+	// differs from the generated one and nobody is known to have changed it.
+	NoBase bool
+}
+
+func (c Conflict) String() string {
+	return fmt.Sprintf("%s: kept %q, generated %q", c.Path, c.Existing, c.Generated)
+}
+
+// threeWay decides whether a value present on both sides should take the
+// generated version. Without a recorded base the existing value always wins
+// silently, as in a two-way merge. With a base, a value the user left alone
+// follows the generator, a value only the user changed is kept, and a value
+// both changed is kept and reported as a conflict. Entries the generator no
+// longer produces are removed the same way when the user left them alone.
+type threeWay struct {
+	hasBase   bool
+	conflicts []Conflict
+}
+
+func (t *threeWay) useGenerated(path string, existing string, generated string, base string, inBase bool) bool {
+	if existing == generated || !t.hasBase {
+		return false
+	}
+	if inBase {
+		if existing == base {
+			return true
+		}
+		if generated == base {
+			return false
+		}
+	}
+	t.report(path, existing, generated)
+	return false
+}
+
+// report records a conflict where the existing value is kept.
+func (t *threeWay) report(path string, existing string, generated string) {
+	t.conflicts = append(t.conflicts, Conflict{Path: path, Existing: existing, Generated: generated, NoBase: !t.hasBase})
+}
+
+// stale reports whether an entry of the existing file that was part of the
+// last generated output and that the user left as generated is removed,
+// because the generator no longer produces it.
+func (t *threeWay) stale(inBase bool, unchanged bool, generated bool) bool {
+	return t.hasBase && inBase && unchanged && !generated
+}
+
+// deleted reports whether a value missing from the existing file was part of
+// the last generated output, meaning the user removed it on purpose.
+func (t *threeWay) deleted(inBase bool) bool {
+	return t.hasBase && inBase
+}
@@ -0,0 +1,237 @@
+package write
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"docker-wizard/internal/generator/compose"
+)
+
+const (
+	EnvFileName        = ".env"
+	EnvExampleFileName = ".env.example"
+
+	envExampleHeader = "# Variables referenced by docker-compose.yml. Copy this file to .env and\n" +
+		"# set the values; docker-wizard fills in random ones for missing variables.\n"
+)
+
+var composeVariablePattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// ComposeVariables returns the ${VAR} references of a compose file without
+// default values, in the order they first appear. These are the variables
+// .env has to define.
+func ComposeVariables(compose string) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, match := range composeVariablePattern.FindAllStringSubmatch(compose, -1) {
+		if seen[match[1]] {
+			continue
+		}
+		seen[match[1]] = true
+		names = append(names, match[1])
+	}
+	return names
+}
+
+// MergeEnvFile appends the variables missing from existing, an env file, with
+// the values returned by value. Existing lines, values and comments are never
+// changed, so secrets stay stable across runs.
+func MergeEnvFile(existing string, variables []string, value func(name string) (string, error)) (string, error) {
+	defined := envFileValues(existing)
+
+	var b strings.Builder
+	b.WriteString(existing)
+	if existing != "" && !strings.HasSuffix(existing, "\n") {
+		b.WriteString("\n")
+	}
+	for _, name := range variables {
+		if _, ok := defined[name]; ok {
+			continue
+		}
+		generated, err := value(name)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(name + "=" + generated + "\n")
+	}
+	return b.String(), nil
+}
+
+// envFileValues parses the KEY=value lines of an env file. Surrounding quotes
+// are removed from values.
+func envFileValues(content string) map[string]string {
+	values := map[string]string{}
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "export "))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, _ := strings.Cut(line, "=")
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		values[strings.TrimSpace(key)] = value
+	}
+	return values
+}
+
+// EnvFile returns the .env content for the generated compose file: the
+// existing .env with the missing variables added. A variable in shared gets
+// that value, which a secret file already holds. A variable replacing a value
+// the existing compose file still sets literally gets that value, so a
+// database keeps the password its volume was initialized with; the others
+// get a value from secret.
+func EnvFile(existingEnv string, existingCompose string, compose string, shared map[string]string, secret func() (string, error)) (string, error) {
+	previous := previousValues(existingCompose, compose)
+	return MergeEnvFile(existingEnv, ComposeVariables(compose), func(name string) (string, error) {
+		if value, ok := shared[name]; ok {
+			return value, nil
+		}
+		if value, ok := previous[name]; ok {
+			return value, nil
+		}
+		return secret()
+	})
+}
+
+// EnvExample returns the .env.example content for the generated compose
+// file: existing with the missing variables added without values.
+func EnvExample(existing string, compose string) string {
+	if existing == "" {
+		existing = envExampleHeader
+	}
+	content, _ := MergeEnvFile(existing, ComposeVariables(compose), func(string) (string, error) { return "", nil })
+	return content
+}
+
+var variableReferencePattern = regexp.MustCompile(`^\$\{([A-Za-z_][A-Za-z0-9_]*)\}$`)
+
+// previousValues maps the variables that make up a whole env value of the
+// generated compose file to the literal value the existing file has for the
+// same service and key.
+func previousValues(existing string, generated string) map[string]string {
+	values := map[string]string{}
+	if existing == "" {
+		return values
+	}
+	existingDoc, err := compose.ParseDocument(existing)
+	if err != nil {
+		return values
+	}
+	generatedDoc, err := compose.ParseDocument(generated)
+	if err != nil {
+		return values
+	}
+	for _, svc := range generatedDoc.Services {
+		current, ok := existingDoc.Service(svc.Name)
+		if !ok {
+			continue
+		}
+		for _, env := range svc.Environment.Vars {
+			match := variableReferencePattern.FindStringSubmatch(env.Value)
+			if match == nil {
+				continue
+			}
+			if old, ok := current.Environment.Get(env.Key); ok && old.HasValue && old.Value != "" && !strings.Contains(old.Value, "${") {
+				values[match[1]] = old.Value
+			}
+		}
+	}
+	return values
+}
+
+// RandomSecret returns 32 random bytes, hex encoded so the value is also safe
+// inside connection URLs. The 64 characters meet the longest minimum of the
+// catalog, the 64 bytes Phoenix apps such as Plausible need in
+// SECRET_KEY_BASE.
+func RandomSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate secret: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// writeEnvFiles creates or extends .env and .env.example with the variables
+// the generated compose file references. existingCompose is the compose file
+// before this run. .env holds secrets, so it is created readable by the owner
+// only and is never backed up.
+func writeEnvFiles(root string, existingCompose string, generated string, output *Output) error {
+	if len(ComposeVariables(generated)) == 0 {
+		return nil
+	}
+
+	envPath := filepath.Join(root, EnvFileName)
+	existing, mode, err := readEnvFile(envPath, 0o600)
+	if err != nil {
+		return err
+	}
+	shared, err := sharedSecretValues(root, existingCompose, existing, generated)
+	if err != nil {
+		return err
+	}
+	content, err := EnvFile(existing, existingCompose, generated, shared, RandomSecret)
+	if err != nil {
+		return err
+	}
+	output.EnvPath = envPath
+	if output.EnvStatus, err = writeEnvFile(envPath, existing, content, mode); err != nil {
+		return err
+	}
+
+	examplePath := filepath.Join(root, EnvExampleFileName)
+	existing, mode, err = readEnvFile(examplePath, 0o644)
+	if err != nil {
+		return err
+	}
+	output.EnvExamplePath = examplePath
+	if output.EnvExampleStatus, err = writeEnvFile(examplePath, existing, EnvExample(existing, generated), mode); err != nil {
+		return err
+	}
+	return nil
+}
+
+// readOptional returns the content of a file, or "" when it does not exist.
+func readOptional(path string) (string, error) {
+	content, _, err := readEnvFile(path, 0)
+	return content, err
+}
+
+// readEnvFile returns the content and permissions of a file, or "" and
+// fallback when it does not exist yet.
+func readEnvFile(path string, fallback os.FileMode) (string, os.FileMode, error) {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return "", fallback, nil
+	}
+	if err != nil {
+		return "", 0, fmt.Errorf("stat %s: %w", filepath.Base(path), err)
+	}
+	if info.IsDir() {
+		return "", 0, fmt.Errorf("%s is a directory", filepath.Base(path))
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", 0, fmt.Errorf("read %s: %w", filepath.Base(path), err)
+	}
+	return string(data), info.Mode().Perm(), nil
+}
+
+func writeEnvFile(path string, existing string, content string, mode os.FileMode) (WriteStatus, error) {
+	if existing == content {
+		return WriteStatusUnchanged, nil
+	}
+	if err := os.WriteFile(path, []byte(content), mode); err != nil {
+		return "", fmt.Errorf("write %s: %w", filepath.Base(path), err)
+	}
+	if existing == "" {
+		return WriteStatusCreated, nil
+	}
+	return WriteStatusUpdated, nil
+}
@@ -6,54 +6,60 @@ import (
 	"path/filepath"
 )
 
-func writeManagedFile(root string, path string, pattern string, content string, merge mergeFunc) (WriteStatus, string, error) {
+// writeManagedFile creates path or merges content into it. base is the
+// content generated for path on the previous run and is passed to merge.
+func writeManagedFile(root string, path string, pattern string, base string, content string, merge mergeFunc) (WriteStatus, string, []Conflict, error) {
 	info, err := os.Stat(path)
 	if err == nil {
 		if info.IsDir() {
-			return "", "", fmt.Errorf("%s is a directory", filepath.Base(path))
+			return "", "", nil, fmt.Errorf("%s is a directory", filepath.Base(path))
 		}
 		existing, readErr := os.ReadFile(path)
 		if readErr != nil {
-			return "", "", fmt.Errorf("read %s: %w", filepath.Base(path), readErr)
+			return "", "", nil, fmt.Errorf("read %s: %w", filepath.Base(path), readErr)
 		}
 
 		targetContent := content
+		var conflicts []Conflict
 		if merge != nil {
-			mergedContent, mergeErr := merge(string(existing), content)
+			mergedContent, mergeConflicts, mergeErr := merge(base, string(existing), content)
 			if mergeErr != nil {
-				return "", "", fmt.Errorf("merge %s: %w", filepath.Base(path), mergeErr)
+				return "", "", nil, fmt.Errorf("merge %s: %w", filepath.Base(path), mergeErr)
 			}
 			targetContent = mergedContent
+			conflicts = mergeConflicts
 		}
 
 		if string(existing) == targetContent {
-			return WriteStatusUnchanged, "", nil
+			return WriteStatusUnchanged, "", conflicts, nil
 		}
 
 		backupPath := path + ".bak"
 		if err := os.WriteFile(backupPath, existing, info.Mode().Perm()); err != nil {
-			return "", "", fmt.Errorf("write %s backup: %w", filepath.Base(path), err)
+			return "", "", nil, fmt.Errorf("write %s backup: %w", filepath.Base(path), err)
 		}
 
-		if err := writeByTemp(root, path, pattern, targetContent); err != nil {
-			return "", "", err
+		if err := writeByTemp(root, path, pattern, targetContent, 0o644); err != nil {
+			return "", "", nil, err
 		}
 
-		return WriteStatusUpdated, backupPath, nil
+		return WriteStatusUpdated, backupPath, conflicts, nil
 	}
 	if !os.IsNotExist(err) {
-		return "", "", fmt.Errorf("stat %s: %w", filepath.Base(path), err)
+		return "", "", nil, fmt.Errorf("stat %s: %w", filepath.Base(path), err)
 	}
 
-	if err := writeByTemp(root, path, pattern, content); err != nil {
-		return "", "", err
+	if err := writeByTemp(root, path, pattern, content, 0o644); err != nil {
+		return "", "", nil, err
 	}
 
-	return WriteStatusCreated, "", nil
+	return WriteStatusCreated, "", nil, nil
 }
 
-func writeByTemp(root string, path string, pattern string, content string) error {
-	tempPath, err := writeTempFile(root, pattern, content)
+// writeByTemp writes content to a temporary file with the given permissions
+// and moves it over path.
+func writeByTemp(root string, path string, pattern string, content string, mode os.FileMode) error {
+	tempPath, err := writeTempFile(root, pattern, content, mode)
 	if err != nil {
 		return fmt.Errorf("write %s temp file: %w", filepath.Base(path), err)
 	}
@@ -68,7 +74,7 @@ func writeByTemp(root string, path string, pattern string, content string) error
 	return nil
 }
 
-func writeTempFile(root string, pattern string, content string) (string, error) {
+func writeTempFile(root string, pattern string, content string, mode os.FileMode) (string, error) {
 	tempFile, err := os.CreateTemp(root, pattern)
 	if err != nil {
 		return "", err
@@ -80,7 +86,7 @@ func writeTempFile(root string, pattern string, content string) (string, error)
 		_ = os.Remove(path)
 		return "", err
 	}
-	if err := tempFile.Chmod(0o644); err != nil {
+	if err := tempFile.Chmod(mode); err != nil {
 		_ = tempFile.Close()
 		_ = os.Remove(path)
 		return "", err
@@ -97,6 +103,9 @@ func DefaultDockerignore() string {
 	return "" +
 		".git\n" +
 		".gitignore\n" +
+		".docker-wizard\n" +
+		".env\n" +
+		"secrets\n" +
 		"node_modules\n" +
 		"vendor\n" +
 		"bin\n" +
@@ -0,0 +1,112 @@
+package write
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"docker-wizard/internal/generator/compose"
+	"docker-wizard/internal/utils"
+)
+
+// secretIgnoreEntries returns the .env file and the secrets directory when
+// they were written, as .gitignore lines.
+func secretIgnoreEntries(output Output) []string {
+	var entries []string
+	if output.EnvPath != "" {
+		entries = append(entries, EnvFileName)
+	}
+	if len(output.Secrets) > 0 {
+		entries = append(entries, compose.SecretsDirName+"/")
+	}
+	return entries
+}
+
+// ignoreSecretFiles keeps the credentials of .env and the secret files out
+// of git and out of the build context of the root: the missing entries are
+// added to .gitignore, which is created when needed, and to an existing
+// .dockerignore of the root. It returns the status of .dockerignore, ""
+// when there is none.
+func ignoreSecretFiles(root string, output *Output) (WriteStatus, error) {
+	entries := secretIgnoreEntries(*output)
+	if len(entries) == 0 {
+		return "", nil
+	}
+	output.GitignorePath = filepath.Join(root, GitignoreFileName)
+	status, err := ignoreEntries(output.GitignorePath, entries, true)
+	if err != nil {
+		return "", err
+	}
+	output.GitignoreStatus = status
+	return ignoreEntries(filepath.Join(root, DockerignoreFileName), entries, false)
+}
+
+// ignoreEntries appends the entries the ignore file at path does not ignore
+// yet. A missing file is created only when create is set; otherwise the
+// status is "".
+func ignoreEntries(path string, entries []string, create bool) (WriteStatus, error) {
+	exists := utils.FileExists(path)
+	if !exists && !create {
+		return "", nil
+	}
+	existing, err := readOptional(path)
+	if err != nil {
+		return "", err
+	}
+	content := ignoreFile(existing, entries)
+	if content == existing {
+		return WriteStatusUnchanged, nil
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		return "", fmt.Errorf("write %s: %w", filepath.Base(path), err)
+	}
+	if !exists {
+		return WriteStatusCreated, nil
+	}
+	return WriteStatusUpdated, nil
+}
+
+// ignoreFile returns the ignore file content with the entries it does not
+// ignore yet appended. Entries already matched by a pattern, such as .env*
+// for .env, are left out.
+func ignoreFile(content string, entries []string) string {
+	var missing []string
+	for _, entry := range entries {
+		if !ignores(content, entry) {
+			missing = append(missing, entry)
+		}
+	}
+	if len(missing) == 0 {
+		return content
+	}
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return content + strings.Join(missing, "\n") + "\n"
+}
+
+// ignores reports whether a top-level line of the ignore file matches the
+// entry, honoring later negations. Patterns are compared without leading and
+// trailing slashes or a leading **/.
+func ignores(content string, entry string) bool {
+	name := ignorePattern(entry)
+	ignored := false
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		negated := strings.HasPrefix(line, "!")
+		if matched, _ := path.Match(ignorePattern(strings.TrimPrefix(line, "!")), name); matched {
+			ignored = !negated
+		}
+	}
+	return ignored
+}
+
+func ignorePattern(pattern string) string {
+	pattern = strings.TrimPrefix(pattern, "**/")
+	return strings.Trim(pattern, "/")
+}
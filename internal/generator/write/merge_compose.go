@@ -1,179 +1,779 @@
 package write
 
-import "gopkg.in/yaml.v3"
+import (
+	"fmt"
+	"strings"
 
+	"docker-wizard/internal/generator/compose"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MergeCompose merges generated compose YAML into an existing file without a
+// recorded base; see MergeComposeThreeWay.
 func MergeCompose(existing string, generated string) (string, error) {
-	existingDoc := map[string]any{}
-	if err := yaml.Unmarshal([]byte(existing), &existingDoc); err != nil {
-		return "", err
-	}
-	generatedDoc := map[string]any{}
-	if err := yaml.Unmarshal([]byte(generated), &generatedDoc); err != nil {
-		return "", err
+	merged, _, err := MergeComposeThreeWay("", existing, generated)
+	return merged, err
+}
+
+// MergeComposeThreeWay merges generated compose YAML into an existing file.
+// The merge works on the existing yaml.Node tree, so comments, key order,
+// anchors and blank lines survive and only generated changes are applied.
+//
+// base is the previously generated output (empty when unknown). With a base,
+// entries the user deleted are not re-added, values the user left untouched
+// follow the generator, and values changed on both sides are kept and
+// returned as conflicts. Without a base existing values always win.
+func MergeComposeThreeWay(base string, existing string, generated string) (string, []Conflict, error) {
+	generatedDoc, err := compose.ParseDocument(generated)
+	if err != nil {
+		return "", nil, err
 	}
 
-	merged := deepMergeComposeValue(nil, existingDoc, generatedDoc)
-	mergedMap, ok := merged.(map[string]any)
-	if !ok {
-		return generated, nil
+	merger := &composeMerger{}
+	var baseNode *yaml.Node
+	if base != "" {
+		baseDoc, err := compose.ParseDocument(base)
+		if err != nil {
+			return "", nil, fmt.Errorf("parse merge base: %w", err)
+		}
+		baseNode = baseDoc.Node()
+		merger.hasBase = true
 	}
 
-	if mapsEqual(existingDoc, mergedMap) {
-		return existing, nil
+	root, layout, err := parseYAMLPreservingLayout(existing)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(root.Content) == 0 || isNullNode(root.Content[0]) {
+		merged, err := generatedDoc.Marshal()
+		return merged, nil, err
+	}
+	mapping := root.Content[0]
+	if mapping.Kind != yaml.MappingNode {
+		return "", nil, fmt.Errorf("compose file must be a mapping")
 	}
 
-	output, err := marshalDeterministicYAML(mergedMap)
+	merger.mergeTopLevel(mapping, generatedDoc, baseNode)
+	if !merger.changed {
+		return existing, merger.conflicts, nil
+	}
+	merged, err := layout.encode(root)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
-	return output, nil
+	return merged, merger.conflicts, nil
 }
 
-func deepMergeComposeValue(path []string, existing any, generated any) any {
-	switch existingTyped := existing.(type) {
-	case map[string]any:
-		generatedMap, ok := generated.(map[string]any)
-		if !ok {
-			return deepCopy(existing)
+type composeMerger struct {
+	threeWay
+	changed bool
+}
+
+func (m *composeMerger) mergeTopLevel(existing *yaml.Node, generated compose.Document, base *yaml.Node) {
+	generatedNode := generated.Node()
+	for i := 0; i+1 < len(generatedNode.Content); i += 2 {
+		key := generatedNode.Content[i].Value
+		generatedValue := generatedNode.Content[i+1]
+		baseValue := lookupKey(base, key)
+
+		existingValue := mappingValue(existing, key)
+		if existingValue == nil {
+			if !m.deleted(baseValue != nil) {
+				m.insertKey(existing, generatedNode, key, generatedValue, true)
+			}
+			continue
+		}
+		if isNullNode(existingValue) {
+			m.replaceValue(existing, key, generatedValue)
+			continue
+		}
+		switch key {
+		case "version":
+			m.mergeScalar(key, existingValue, generatedValue, baseValue)
+		case "services":
+			m.mergeServices(existingValue, generated.Services, baseValue)
+		case "volumes", "networks", "secrets":
+			m.mergeNamedEntries(existingValue, generatedValue, baseValue)
+		}
+	}
+}
+
+func (m *composeMerger) mergeServices(existing *yaml.Node, generated []compose.Service, base *yaml.Node) {
+	if existing.Kind != yaml.MappingNode {
+		return
+	}
+	for _, svc := range generated {
+		baseValue := lookupKey(base, svc.Name)
+		existingValue := mappingValue(existing, svc.Name)
+		if existingValue == nil {
+			if !m.deleted(baseValue != nil) {
+				m.appendKey(existing, svc.Name, svc.Node(), true)
+			}
+			continue
 		}
-		out := make(map[string]any, len(existingTyped))
-		for key, value := range existingTyped {
-			out[key] = deepCopy(value)
+		if isNullNode(existingValue) {
+			m.replaceValue(existing, svc.Name, svc.Node())
+			continue
 		}
-		for key, generatedValue := range generatedMap {
-			existingValue, found := out[key]
-			if !found {
-				out[key] = deepCopy(generatedValue)
+		m.mergeService(existingValue, svc, baseValue)
+	}
+}
+
+func (m *composeMerger) mergeService(existing *yaml.Node, generated compose.Service, base *yaml.Node) {
+	// Services reached through an alias are shared with other services, so
+	// they are left alone.
+	if existing.Kind != yaml.MappingNode {
+		return
+	}
+	generatedNode := generated.Node()
+	for i := 0; i+1 < len(generatedNode.Content); i += 2 {
+		key := generatedNode.Content[i].Value
+		generatedValue := generatedNode.Content[i+1]
+		baseValue := lookupKey(base, key)
+		path := "services." + generated.Name + "." + key
+
+		existingValue := mappingValue(existing, key)
+		if existingValue == nil {
+			if mergedKeyValue(existing, key) != nil {
+				// Inherited through a "<<" merge key; adding it would
+				// override the shared value.
 				continue
 			}
-			out[key] = deepMergeComposeValue(appendComposePath(path, key), existingValue, generatedValue)
+			if !m.deleted(baseValue != nil) {
+				m.insertKey(existing, generatedNode, key, generatedValue, false)
+			}
+			continue
 		}
-		return out
-	case []any:
-		generatedSlice, ok := generated.([]any)
-		if !ok {
-			return deepCopy(existing)
+		if isNullNode(existingValue) {
+			m.replaceValue(existing, key, generatedValue)
+			continue
 		}
-		return mergeComposeSlice(path, existingTyped, generatedSlice)
-	default:
-		if existing == nil {
-			return deepCopy(generated)
+		if existingValue.Kind == yaml.AliasNode {
+			continue
+		}
+
+		switch key {
+		case "image":
+			m.mergeScalar(path, existingValue, generatedValue, baseValue)
+		case "build", "healthcheck":
+			m.mergeMapping(path, existingValue, generatedValue, baseValue)
+		case "develop":
+			m.mergeDevelop(existingValue, generatedValue, baseValue)
+		case "ports", "profiles", "expose", "volumes", "networks", "secrets", "environment", "depends_on":
+			m.mergeEntries(existing, key, existingValue, generatedValue, generated, baseValue)
+		case "command", "entrypoint":
+			if isEmptyNode(existingValue) {
+				m.replaceValue(existing, key, generatedValue)
+				continue
+			}
+			m.mergeWholeList(path, existingValue, generatedValue, baseValue)
+		}
+	}
+
+	// A key the generator dropped altogether loses its stale entries too.
+	for _, key := range entryKeys {
+		if mappingValue(generatedNode, key) != nil {
+			continue
 		}
-		return deepCopy(existing)
+		existingValue := mappingValue(existing, key)
+		baseValue := lookupKey(base, key)
+		if existingValue == nil || baseValue == nil || existingValue.Kind == yaml.AliasNode {
+			continue
+		}
+		m.mergeEntries(existing, key, existingValue, &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}, generated, baseValue)
 	}
 }
 
-func appendComposePath(path []string, key string) []string {
-	next := make([]string, 0, len(path)+1)
-	next = append(next, path...)
-	next = append(next, key)
-	return next
+// entryKeys are the service keys merged entry by entry.
+var entryKeys = []string{"ports", "profiles", "expose", "volumes", "networks", "secrets", "environment", "depends_on"}
+
+// mergeEntries merges a service key of entryKeys. When the stale entries
+// were all that was left, the key is removed as well.
+func (m *composeMerger) mergeEntries(service *yaml.Node, key string, existing *yaml.Node, generated *yaml.Node, svc compose.Service, base *yaml.Node) {
+	path := "services." + svc.Name + "." + key
+	count := len(existing.Content)
+	switch key {
+	case "ports":
+		m.mergePorts(path, existing, generated, base)
+	case "profiles", "expose", "volumes":
+		m.mergeScalarSet(existing, generated, base)
+	case "networks":
+		m.mergeNetworks(existing, generated, base)
+	case "secrets":
+		m.mergeSecrets(existing, generated, base)
+	case "environment":
+		m.mergeEnvironment(path, existing, svc.Environment, base)
+	case "depends_on":
+		m.mergeDependsOn(path, existing, svc.DependsOn, base)
+	}
+	if count > 0 && len(existing.Content) == 0 && (existing.Kind == yaml.SequenceNode || existing.Kind == yaml.MappingNode) {
+		deleteKey(service, key)
+		m.changed = true
+	}
 }
 
-func mergeComposeSlice(path []string, existing []any, generated []any) []any {
-	if isServiceField(path, "environment") {
-		return mergeEnvironmentList(existing, generated)
+// mergeScalar updates a scalar the user has not changed since the base.
+func (m *composeMerger) mergeScalar(path string, existing *yaml.Node, generated *yaml.Node, base *yaml.Node) {
+	if existing.Kind != yaml.ScalarNode || generated.Kind != yaml.ScalarNode {
+		return
 	}
-	if isServiceField(path, "ports") {
-		return mergePortsList(existing, generated)
+	baseValue, inBase := scalarValue(base)
+	if m.useGenerated(path, existing.Value, generated.Value, baseValue, inBase) {
+		setScalar(existing, generated)
+		m.changed = true
 	}
-	if isServiceField(path, "command") || isServiceField(path, "entrypoint") {
-		return mergeUserPriorityList(existing, generated)
+}
+
+// mergeWholeList treats a list as one value, for command and entrypoint
+// where arguments only make sense together.
+func (m *composeMerger) mergeWholeList(path string, existing *yaml.Node, generated *yaml.Node, base *yaml.Node) {
+	existingValue, ok := joinedScalars(existing)
+	generatedValue, generatedOK := joinedScalars(generated)
+	if !ok || !generatedOK {
+		return
 	}
-	if isServiceField(path, "depends_on") || isServiceField(path, "networks") || isServiceField(path, "volumes") {
-		return mergeSetLikeList(existing, generated)
+	baseValue, inBase := joinedScalars(base)
+	if m.useGenerated(path, existingValue, generatedValue, baseValue, inBase) {
+		existing.Content = generated.Content
+		m.changed = true
 	}
-	return mergeSetLikeList(existing, generated)
 }
 
-func mergeUserPriorityList(existing []any, generated []any) []any {
-	if len(existing) > 0 {
-		out := make([]any, 0, len(existing))
-		for _, value := range existing {
-			out = append(out, deepCopy(value))
+// mergeMapping adds generated keys that the existing mapping lacks and
+// updates scalar values the user has not changed.
+func (m *composeMerger) mergeMapping(path string, existing *yaml.Node, generated *yaml.Node, base *yaml.Node) {
+	if existing.Kind != yaml.MappingNode || generated.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(generated.Content); i += 2 {
+		key := generated.Content[i].Value
+		generatedValue := generated.Content[i+1]
+		baseValue := lookupKey(base, key)
+
+		existingValue := mappingValue(existing, key)
+		if existingValue == nil {
+			if !m.deleted(baseValue != nil) {
+				m.insertKey(existing, generated, key, generatedValue, false)
+			}
+			continue
+		}
+		if existingValue.Kind == yaml.SequenceNode {
+			m.mergeWholeList(path+"."+key, existingValue, generatedValue, baseValue)
+			continue
 		}
-		return out
+		m.mergeScalar(path+"."+key, existingValue, generatedValue, baseValue)
 	}
+}
 
-	out := make([]any, 0, len(generated))
-	for _, value := range generated {
-		out = append(out, deepCopy(value))
+// mergeDevelop adds the generated watch rules whose action and path no
+// existing rule has. Existing rules are never changed.
+func (m *composeMerger) mergeDevelop(existing *yaml.Node, generated *yaml.Node, base *yaml.Node) {
+	if existing.Kind != yaml.MappingNode || generated.Kind != yaml.MappingNode {
+		return
+	}
+	generatedWatch := mappingValue(generated, "watch")
+	if generatedWatch == nil {
+		return
 	}
-	return out
+	baseWatch := lookupKey(base, "watch")
+	existingWatch := mappingValue(existing, "watch")
+	if existingWatch == nil {
+		if !m.deleted(baseWatch != nil) {
+			m.insertKey(existing, generated, "watch", generatedWatch, false)
+		}
+		return
+	}
+	if existingWatch.Kind != yaml.SequenceNode {
+		return
+	}
+	for _, rule := range generatedWatch.Content {
+		key := watchRuleKey(rule)
+		if containsWatchRule(existingWatch, key) || m.deleted(containsWatchRule(baseWatch, key)) {
+			continue
+		}
+		m.appendItem(existingWatch, rule)
+	}
+}
+
+// watchRuleKey identifies a watch rule by its action and path.
+func watchRuleKey(rule *yaml.Node) string {
+	action, _ := scalarValue(lookupKey(resolveAlias(rule), "action"))
+	path, _ := scalarValue(lookupKey(resolveAlias(rule), "path"))
+	return action + " " + path
 }
 
-func isServiceField(path []string, field string) bool {
-	return len(path) == 3 && path[0] == "services" && path[2] == field
+func containsWatchRule(sequence *yaml.Node, key string) bool {
+	if sequence == nil || sequence.Kind != yaml.SequenceNode {
+		return false
+	}
+	for _, item := range sequence.Content {
+		if watchRuleKey(item) == key {
+			return true
+		}
+	}
+	return false
 }
 
-func mergeEnvironmentList(existing []any, generated []any) []any {
-	out := make([]any, 0, len(existing)+len(generated))
-	seenKeys := map[string]bool{}
+// mergeNamedEntries adds top-level volumes or networks that are missing.
+func (m *composeMerger) mergeNamedEntries(existing *yaml.Node, generated *yaml.Node, base *yaml.Node) {
+	if existing.Kind != yaml.MappingNode || generated.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(generated.Content); i += 2 {
+		key := generated.Content[i].Value
+		if mappingValue(existing, key) != nil || m.deleted(containsName(base, key)) {
+			continue
+		}
+		m.appendKey(existing, key, generated.Content[i+1], false)
+	}
+}
 
-	for _, value := range existing {
-		out = append(out, deepCopy(value))
-		if key, ok := environmentKey(value); ok {
-			seenKeys[key] = true
+// mergePorts matches ports by host port. Missing bindings are appended,
+// bindings the user has not changed follow the generator and stale ones are
+// removed.
+func (m *composeMerger) mergePorts(path string, existing *yaml.Node, generated *yaml.Node, base *yaml.Node) {
+	if existing.Kind != yaml.SequenceNode || generated.Kind != yaml.SequenceNode {
+		return
+	}
+	existingPorts := map[string]*yaml.Node{}
+	for _, item := range existing.Content {
+		if host, ok := nodePortHostKey(item); ok {
+			existingPorts[host] = item
+		}
+	}
+	basePorts := map[string]string{}
+	if base != nil && base.Kind == yaml.SequenceNode {
+		for _, item := range base.Content {
+			if host, ok := nodePortHostKey(item); ok {
+				basePorts[host] = item.Value
+			}
 		}
 	}
 
-	for _, generatedValue := range generated {
-		if key, ok := environmentKey(generatedValue); ok {
-			if seenKeys[key] {
-				continue
+	for _, item := range generated.Content {
+		host, ok := nodePortHostKey(item)
+		if !ok {
+			if !sequenceContainsScalar(existing, item.Value) && !m.deleted(containsName(base, item.Value)) {
+				m.appendItem(existing, item)
+			}
+			continue
+		}
+		baseValue, inBase := basePorts[host]
+		if existingItem, found := existingPorts[host]; found {
+			if existingItem.Kind == yaml.ScalarNode && m.useGenerated(path+"."+host, existingItem.Value, item.Value, baseValue, inBase) {
+				setScalar(existingItem, item)
+				m.changed = true
 			}
-			seenKeys[key] = true
-			out = append(out, deepCopy(generatedValue))
 			continue
 		}
-		if containsDeepValue(out, generatedValue) {
+		if m.deleted(inBase) {
 			continue
 		}
-		out = append(out, deepCopy(generatedValue))
+		existingPorts[host] = item
+		m.appendItem(existing, item)
 	}
 
-	return out
+	generatedPorts := map[string]bool{}
+	for _, item := range generated.Content {
+		if host, ok := nodePortHostKey(item); ok {
+			generatedPorts[host] = true
+		}
+	}
+	m.removeItems(existing, func(item *yaml.Node) bool {
+		if item.Kind != yaml.ScalarNode {
+			return false
+		}
+		host, ok := nodePortHostKey(item)
+		if !ok {
+			return m.stale(containsName(base, item.Value), true, sequenceContainsScalar(generated, item.Value))
+		}
+		baseValue, inBase := basePorts[host]
+		return m.stale(inBase, item.Value == baseValue, generatedPorts[host])
+	})
 }
 
-func mergePortsList(existing []any, generated []any) []any {
-	out := make([]any, 0, len(existing)+len(generated))
-	hostPorts := map[string]bool{}
+// mergeScalarSet appends generated list items that are not present yet and
+// removes stale ones.
+func (m *composeMerger) mergeScalarSet(existing *yaml.Node, generated *yaml.Node, base *yaml.Node) {
+	if existing.Kind != yaml.SequenceNode || generated.Kind != yaml.SequenceNode {
+		return
+	}
+	for _, item := range generated.Content {
+		if sequenceContainsScalar(existing, item.Value) || m.deleted(containsName(base, item.Value)) {
+			continue
+		}
+		m.appendItem(existing, item)
+	}
+	m.removeItems(existing, func(item *yaml.Node) bool {
+		return item.Kind == yaml.ScalarNode && m.stale(containsName(base, item.Value), true, sequenceContainsScalar(generated, item.Value))
+	})
+}
 
-	for _, value := range existing {
-		out = append(out, deepCopy(value))
-		if host, ok := portHostKey(value); ok {
-			hostPorts[host] = true
+// mergeNetworks handles both the list and the map form of service networks.
+func (m *composeMerger) mergeNetworks(existing *yaml.Node, generated *yaml.Node, base *yaml.Node) {
+	if existing.Kind == yaml.SequenceNode {
+		m.mergeScalarSet(existing, generated, base)
+		return
+	}
+	if existing.Kind != yaml.MappingNode || generated.Kind != yaml.SequenceNode {
+		return
+	}
+	for _, item := range generated.Content {
+		if mappingValue(existing, item.Value) != nil || m.deleted(containsName(base, item.Value)) {
+			continue
 		}
+		m.appendKey(existing, item.Value, nullNode(), false)
 	}
+	m.removeKeys(existing, func(key *yaml.Node, value *yaml.Node) bool {
+		return m.stale(containsName(base, key.Value), isNullNode(value), sequenceContainsScalar(generated, key.Value))
+	})
+}
+
+// mergeSecrets appends the generated secrets a service does not use yet and
+// removes stale short-syntax entries. Existing entries may use the long
+// syntax, which names the secret in source.
+func (m *composeMerger) mergeSecrets(existing *yaml.Node, generated *yaml.Node, base *yaml.Node) {
+	if existing.Kind != yaml.SequenceNode || generated.Kind != yaml.SequenceNode {
+		return
+	}
+	for _, item := range generated.Content {
+		inBase := base != nil && base.Kind == yaml.SequenceNode && containsSecret(base, item.Value)
+		if containsSecret(existing, item.Value) || m.deleted(inBase) {
+			continue
+		}
+		m.appendItem(existing, item)
+	}
+	m.removeItems(existing, func(item *yaml.Node) bool {
+		inBase := base != nil && base.Kind == yaml.SequenceNode && containsSecret(base, item.Value)
+		return item.Kind == yaml.ScalarNode && m.stale(inBase, true, containsSecret(generated, item.Value))
+	})
+}
+
+func containsSecret(sequence *yaml.Node, name string) bool {
+	for _, item := range sequence.Content {
+		if secretSource(item) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// secretSource returns the secret a short or long form secrets entry mounts.
+func secretSource(item *yaml.Node) string {
+	item = resolveAlias(item)
+	switch item.Kind {
+	case yaml.ScalarNode:
+		return item.Value
+	case yaml.MappingNode:
+		source, _ := scalarValue(mappingValue(item, "source"))
+		return source
+	}
+	return ""
+}
+
+// mergeEnvironment adds generated variables whose key is not set yet, in the
+// syntax (list or map) the existing block uses, updates values the user has
+// not changed and removes stale ones.
+func (m *composeMerger) mergeEnvironment(path string, existing *yaml.Node, generated compose.Environment, base *yaml.Node) {
+	mapForm := existing.Kind == yaml.MappingNode
+	entries := map[string]*yaml.Node{}
+	switch existing.Kind {
+	case yaml.SequenceNode:
+		for _, item := range existing.Content {
+			if item.Kind == yaml.ScalarNode {
+				entries[environmentKey(item.Value)] = item
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(existing.Content); i += 2 {
+			entries[existing.Content[i].Value] = existing.Content[i+1]
+		}
+	default:
+		return
+	}
+	baseValues := environmentValues(base)
+
+	for _, env := range generated.Vars {
+		rendered := compose.Environment{Vars: []compose.EnvVar{env}, MapForm: mapForm}.Node()
+		generatedNode := rendered.Content[len(rendered.Content)-1]
+		baseValue, inBase := baseValues[env.Key]
+
+		if entry, found := entries[env.Key]; found {
+			existingValue, hasValue := environmentEntryValue(entry, mapForm)
+			if hasValue && env.HasValue && m.useGenerated(path+"."+env.Key, existingValue, env.Value, baseValue, inBase) {
+				setScalar(entry, generatedNode)
+				m.changed = true
+			}
+			continue
+		}
+		if m.deleted(inBase) {
+			continue
+		}
+		entries[env.Key] = generatedNode
+		if mapForm {
+			m.appendKey(existing, env.Key, generatedNode, false)
+		} else {
+			m.appendItem(existing, generatedNode)
+		}
+	}
+	m.dropReplacedByFile(path, existing, generated, entries, baseValues)
 
-	for _, generatedValue := range generated {
-		if host, ok := portHostKey(generatedValue); ok {
-			if hostPorts[host] {
+	stale := func(key string, entry *yaml.Node) bool {
+		value, hasValue := environmentEntryValue(entry, mapForm)
+		baseValue, inBase := baseValues[key]
+		_, stillGenerated := generated.Get(key)
+		return hasValue && m.stale(inBase, value == baseValue, stillGenerated)
+	}
+	if mapForm {
+		m.removeKeys(existing, func(key *yaml.Node, value *yaml.Node) bool { return stale(key.Value, value) })
+	} else {
+		m.removeItems(existing, func(item *yaml.Node) bool {
+			return item.Kind == yaml.ScalarNode && stale(environmentKey(item.Value), item)
+		})
+	}
+}
+
+// dropReplacedByFile removes the variables the generator replaced with their
+// _FILE form, such as POSTGRES_PASSWORD once the password is a secret, when
+// the user left them as generated. Images refuse to start with both set.
+// Without a base, a variable whose value moves into the new secret file is
+// removed too, and any other one is kept and reported as a conflict.
+func (m *composeMerger) dropReplacedByFile(path string, existing *yaml.Node, generated compose.Environment, entries map[string]*yaml.Node, baseValues map[string]string) {
+	mapForm := existing.Kind == yaml.MappingNode
+	for _, env := range generated.Vars {
+		plain, ok := strings.CutSuffix(env.Key, "_FILE")
+		if !ok {
+			continue
+		}
+		if _, stillGenerated := generated.Get(plain); stillGenerated {
+			continue
+		}
+		entry, found := entries[plain]
+		if !found {
+			continue
+		}
+		value, hasValue := environmentEntryValue(entry, mapForm)
+		if !m.hasBase {
+			if !hasValue || !movesIntoSecretFile(value) {
+				m.report(path+"."+plain, value, env.Key+"="+env.Value)
 				continue
 			}
-			hostPorts[host] = true
-			out = append(out, deepCopy(generatedValue))
+		} else if baseValue, inBase := baseValues[plain]; !inBase || !hasValue || value != baseValue {
 			continue
 		}
-		if containsDeepValue(out, generatedValue) {
+		for i, item := range existing.Content {
+			if item != entry {
+				continue
+			}
+			if mapForm {
+				existing.Content = append(existing.Content[:i-1], existing.Content[i+1:]...)
+			} else {
+				existing.Content = append(existing.Content[:i], existing.Content[i+1:]...)
+			}
+			delete(entries, plain)
+			m.changed = true
+			break
+		}
+	}
+}
+
+// mergeDependsOn merges the short (list) and long (map) forms of depends_on.
+// Two lists stay a list; as soon as either side uses the long form the result
+// is a map. Short-form entries carry no condition, so a generated condition is
+// adopted for them, while explicit existing conditions win unless the user
+// left them as generated. Stale dependencies are removed.
+func (m *composeMerger) mergeDependsOn(path string, existing *yaml.Node, generated compose.DependsOn, base *yaml.Node) {
+	conditions := make(map[string]string, len(generated.Entries))
+	for _, dep := range generated.Entries {
+		conditions[dep.Name] = dep.Condition
+	}
+
+	if existing.Kind == yaml.SequenceNode && !generated.LongForm {
+		for _, dep := range generated.Entries {
+			if sequenceContainsScalar(existing, dep.Name) || m.deleted(containsName(base, dep.Name)) {
+				continue
+			}
+			m.appendItem(existing, scalarNode(dep.Name))
+		}
+		m.removeItems(existing, func(item *yaml.Node) bool {
+			_, stillGenerated := conditions[item.Value]
+			return item.Kind == yaml.ScalarNode && m.stale(containsName(base, item.Value), true, stillGenerated)
+		})
+		return
+	}
+
+	if existing.Kind == yaml.SequenceNode {
+		convertDependsOnToLongForm(existing, conditions)
+		m.changed = true
+	}
+	if existing.Kind != yaml.MappingNode {
+		return
+	}
+
+	for i := 0; i+1 < len(existing.Content); i += 2 {
+		name := existing.Content[i].Value
+		options := existing.Content[i+1]
+		if isNullNode(options) {
+			existing.Content[i+1] = conditionNode(conditions[name])
+			m.changed = true
+			continue
+		}
+		generatedCondition, ok := conditions[name]
+		if !ok || generatedCondition == "" || options.Kind != yaml.MappingNode {
+			continue
+		}
+		if condition := mappingValue(options, "condition"); condition != nil {
+			baseCondition := lookupKey(lookupKey(base, name), "condition")
+			m.mergeScalar(path+"."+name+".condition", condition, scalarNode(generatedCondition), baseCondition)
+		}
+	}
+	for _, dep := range generated.Entries {
+		if mappingValue(existing, dep.Name) != nil || m.deleted(containsName(base, dep.Name)) {
 			continue
 		}
-		out = append(out, deepCopy(generatedValue))
+		m.appendKey(existing, dep.Name, conditionNode(dep.Condition), false)
 	}
+	m.removeKeys(existing, func(key *yaml.Node, options *yaml.Node) bool {
+		_, stillGenerated := conditions[key.Value]
+		return m.stale(containsName(base, key.Value), dependencyCondition(options) == baseCondition(base, key.Value), stillGenerated)
+	})
+}
+
+// dependencyCondition returns the condition of a long-form depends_on entry,
+// service_started when none is set.
+func dependencyCondition(options *yaml.Node) string {
+	if condition, ok := scalarValue(lookupKey(resolveAlias(options), "condition")); ok {
+		return condition
+	}
+	return "service_started"
+}
+
+// baseCondition returns the condition base gave the dependency name; a
+// short-form entry has none.
+func baseCondition(base *yaml.Node, name string) string {
+	if base != nil && base.Kind == yaml.MappingNode {
+		return dependencyCondition(mappingValue(base, name))
+	}
+	return "service_started"
+}
+
+// convertDependsOnToLongForm rewrites a depends_on list in place as a map,
+// keeping the comments attached to each entry.
+func convertDependsOnToLongForm(node *yaml.Node, conditions map[string]string) {
+	content := make([]*yaml.Node, 0, len(node.Content)*2)
+	for _, item := range node.Content {
+		key := scalarNode(item.Value)
+		key.HeadComment = item.HeadComment
+		key.LineComment = item.LineComment
+		key.FootComment = item.FootComment
+		content = append(content, key, conditionNode(conditions[item.Value]))
+	}
+	node.Kind = yaml.MappingNode
+	node.Tag = "!!map"
+	node.Content = content
+}
 
-	return out
+func conditionNode(condition string) *yaml.Node {
+	if condition == "" {
+		condition = "service_started"
+	}
+	node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	node.Content = append(node.Content, scalarNode("condition"), scalarNode(condition))
+	return node
 }
 
-func mergeSetLikeList(existing []any, generated []any) []any {
-	out := make([]any, 0, len(existing)+len(generated))
-	for _, value := range existing {
-		out = append(out, deepCopy(value))
+// insertKey adds key to existing at the position it has in the generated
+// mapping: after the closest preceding generated key that already exists,
+// otherwise before the closest following one, otherwise at the end.
+func (m *composeMerger) insertKey(existing *yaml.Node, generated *yaml.Node, key string, value *yaml.Node, separate bool) {
+	position := -1
+	generatedIndex := mappingKeyIndex(generated, key)
+	for i := generatedIndex - 2; i >= 0 && position < 0; i -= 2 {
+		if idx := mappingKeyIndex(existing, generated.Content[i].Value); idx >= 0 {
+			position = idx + 2
+		}
 	}
-	for _, generatedValue := range generated {
-		if containsDeepValue(out, generatedValue) {
+	for i := generatedIndex + 2; i < len(generated.Content) && position < 0; i += 2 {
+		if idx := mappingKeyIndex(existing, generated.Content[i].Value); idx >= 0 {
+			position = idx
+		}
+	}
+	if position < 0 {
+		m.appendKey(existing, key, value, separate)
+		return
+	}
+
+	keyNode := scalarNode(key)
+	if separate && separatedByBlankLines(existing) && position > 0 {
+		keyNode.HeadComment = blankLineMarker
+	}
+	content := make([]*yaml.Node, 0, len(existing.Content)+2)
+	content = append(content, existing.Content[:position]...)
+	content = append(content, keyNode, value)
+	content = append(content, existing.Content[position:]...)
+	existing.Content = content
+	m.changed = true
+}
+
+// appendKey adds key at the end of the mapping. With separate set, the new
+// entry gets a blank line above it when its siblings are blank-separated.
+func (m *composeMerger) appendKey(existing *yaml.Node, key string, value *yaml.Node, separate bool) {
+	keyNode := scalarNode(key)
+	if separate && separatedByBlankLines(existing) {
+		keyNode.HeadComment = blankLineMarker
+	}
+	existing.Content = append(existing.Content, keyNode, value)
+	m.changed = true
+}
+
+// removeItems deletes the items of a sequence that stale reports.
+func (m *composeMerger) removeItems(sequence *yaml.Node, stale func(item *yaml.Node) bool) {
+	kept := sequence.Content[:0]
+	for _, item := range sequence.Content {
+		if stale(item) {
+			m.changed = true
+			continue
+		}
+		kept = append(kept, item)
+	}
+	sequence.Content = kept
+}
+
+// removeKeys deletes the entries of a mapping that stale reports.
+func (m *composeMerger) removeKeys(mapping *yaml.Node, stale func(key *yaml.Node, value *yaml.Node) bool) {
+	kept := mapping.Content[:0]
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if stale(mapping.Content[i], mapping.Content[i+1]) {
+			m.changed = true
 			continue
 		}
-		out = append(out, deepCopy(generatedValue))
+		kept = append(kept, mapping.Content[i], mapping.Content[i+1])
+	}
+	mapping.Content = kept
+}
+
+func (m *composeMerger) appendItem(existing *yaml.Node, item *yaml.Node) {
+	existing.Content = append(existing.Content, item)
+	m.changed = true
+}
+
+func (m *composeMerger) replaceValue(existing *yaml.Node, key string, value *yaml.Node) {
+	idx := mappingKeyIndex(existing, key)
+	if idx < 0 {
+		return
+	}
+	existing.Content[idx+1] = value
+	m.changed = true
+}
+
+// separatedByBlankLines reports whether entries after the first one in the
+// mapping start with a blank line.
+func separatedByBlankLines(mapping *yaml.Node) bool {
+	for i := 2; i < len(mapping.Content); i += 2 {
+		if strings.HasPrefix(mapping.Content[i].HeadComment, blankLineMarker) {
+			return true
+		}
 	}
-	return out
+	return false
 }
@@ -1,60 +1,338 @@
 package write
 
-import "strings"
+import (
+	"sort"
+	"strconv"
+	"strings"
 
+	"docker-wizard/internal/generator/dockerfile"
+)
+
+const appStartCmd = "APP_START_CMD"
+
+// MergeDockerfile merges a generated Dockerfile into an existing one without
+// a recorded base; see MergeDockerfileThreeWay.
 func MergeDockerfile(existing string, generated string) (string, error) {
-	merged := strings.TrimRight(existing, "\n")
-	if merged == "" {
-		merged = existing
+	merged, _, err := MergeDockerfileThreeWay("", existing, generated)
+	return merged, err
+}
+
+// MergeDockerfileThreeWay merges a generated Dockerfile into an existing one.
+// base is the previously generated Dockerfile (empty when unknown). An
+// untouched file is replaced by the new output. Otherwise the files are
+// parsed and merged stage by stage: stages are matched by their AS name,
+// unnamed ones by position, and the final stages, which build the image that
+// runs, are matched with each other. A generated stage the existing file
+// lacks is inserted before the stage it precedes in the generated file,
+// unless the user removed it. Without a base it is left out and reported as
+// a conflict instead, since a hand-written file builds the app its own way.
+//
+// Within a matched stage the FROM image, the EXPOSE port, the APP_START_CMD
+// variable and the CMD are merged like compose values: a directive the user
+// removed is not added back, one only the generator changed is updated, and
+// one changed on both sides is kept and reported as a conflict. Missing
+// directives are inserted inside the stage, EXPOSE and APP_START_CMD before
+// its CMD. A stage that starts through an ENTRYPOINT gets neither
+// APP_START_CMD nor CMD: nothing would read the variable, and a CMD would
+// become the entrypoint's arguments, which is reported as a conflict too.
+func MergeDockerfileThreeWay(base string, existing string, generated string) (string, []Conflict, error) {
+	if base != "" {
+		if existing == base {
+			return generated, nil, nil
+		}
+		if generated == base {
+			return existing, nil, nil
+		}
+	}
+
+	m := dockerfileMerger{
+		tracker:   threeWay{hasBase: base != ""},
+		existing:  dockerfile.ParseDocument(existing),
+		generated: dockerfile.ParseDocument(generated),
+		base:      dockerfile.ParseDocument(base),
+	}
+	m.merge()
+	return m.result(), m.tracker.conflicts, nil
+}
+
+type dockerfileMerger struct {
+	tracker   threeWay
+	existing  dockerfile.Document
+	generated dockerfile.Document
+	base      dockerfile.Document
+	edits     []lineEdit
+}
+
+// lineEdit replaces the existing lines [start, end) with lines; start == end
+// inserts. seq keeps insertions at the same line in the order they were made.
+type lineEdit struct {
+	start int
+	end   int
+	lines []string
+	seq   int
+}
+
+func (m *dockerfileMerger) merge() {
+	if len(m.existing.Stages) == 0 {
+		// nothing to match against, such as an empty or comment-only file
+		m.appendStages(m.generated.Stages)
+		return
+	}
+
+	matches := matchStages(m.existing.Stages, m.generated.Stages)
+	baseMatches := matchStages(m.base.Stages, m.generated.Stages)
+	last := len(m.generated.Stages) - 1
+	var pending []dockerfile.Stage
+	for g, stage := range m.generated.Stages {
+		var base *dockerfile.Stage
+		if b := baseMatches[g]; b >= 0 {
+			base = &m.base.Stages[b]
+		}
+		e := matches[g]
+		if e < 0 {
+			if !m.tracker.hasBase {
+				m.tracker.report("Dockerfile "+stageLabel(stage, g), "", stage.From.Text())
+				continue
+			}
+			if !m.tracker.deleted(base != nil) {
+				pending = append(pending, stage)
+			}
+			continue
+		}
+		m.insertStages(m.stageStart(m.existing.Stages[e]), pending)
+		pending = nil
+		label := ""
+		if g != last {
+			label = stageLabel(stage, g)
+		}
+		m.mergeStage(label, m.existing.Stages[e], stage, base)
+	}
+	m.appendStages(pending)
+}
+
+// mergeStage merges the directives of a generated stage into the matching
+// existing stage. label names the stage in conflicts; it is empty for the
+// final stage.
+func (m *dockerfileMerger) mergeStage(label string, existing dockerfile.Stage, generated dockerfile.Stage, base *dockerfile.Stage) {
+	prefix := "Dockerfile "
+	if label != "" {
+		prefix += label + " "
+	}
+
+	m.mergeFrom(prefix+"FROM", existing, generated, base)
+
+	if expose, ok := generated.Last("EXPOSE"); ok {
+		baseExpose, inBase := dockerfile.Instruction{}, false
+		if base != nil {
+			baseExpose, inBase = base.Last("EXPOSE")
+		}
+		if current, ok := existing.Last("EXPOSE"); ok {
+			m.mergeInstruction(prefix+"EXPOSE", current, expose, baseExpose, inBase)
+		} else if !m.tracker.deleted(inBase) {
+			m.insert(envPosition(existing), m.generatedSource(expose))
+		}
+	}
+
+	env, hasEnv := lastEnv(generated, appStartCmd)
+	if hasEnv {
+		baseEnv, inBase := dockerfile.Instruction{}, false
+		if base != nil {
+			baseEnv, inBase = lastEnv(*base, appStartCmd)
+		}
+		_, hasEntrypoint := existing.Last("ENTRYPOINT")
+		if current, ok := lastEnv(existing, appStartCmd); ok {
+			m.mergeInstruction(prefix+appStartCmd, current, env, baseEnv, inBase)
+		} else if !hasEntrypoint && !m.tracker.deleted(inBase) {
+			m.insert(envPosition(existing), m.generatedSource(env))
+		}
+	}
+
+	cmd, hasCmd := generated.Last("CMD")
+	if !hasCmd {
+		return
+	}
+	baseCmd, inBase := dockerfile.Instruction{}, false
+	if base != nil {
+		baseCmd, inBase = base.Last("CMD")
+	}
+	if current, ok := existing.Last("CMD"); ok {
+		m.mergeInstruction(prefix+"CMD", current, cmd, baseCmd, inBase)
+		return
 	}
+	if m.tracker.deleted(inBase) {
+		return
+	}
+	if entrypoint, ok := existing.Last("ENTRYPOINT"); ok {
+		m.tracker.report(prefix+"CMD", entrypoint.Text(), cmd.Text())
+		return
+	}
+	m.insert(existing.EndLine()+1, m.generatedSource(cmd))
+}
+
+// mergeFrom updates the image of a stage the user has not changed.
+func (m *dockerfileMerger) mergeFrom(path string, existing dockerfile.Stage, generated dockerfile.Stage, base *dockerfile.Stage) {
+	if existing.From.StartLine != existing.From.EndLine || generated.From.StartLine != generated.From.EndLine {
+		return
+	}
+	baseImage, inBase := "", base != nil
+	if inBase {
+		baseImage = base.BaseImage()
+	}
+	current, image := existing.BaseImage(), generated.BaseImage()
+	if current == "" || image == "" || !m.tracker.useGenerated(path, current, image, baseImage, inBase) {
+		return
+	}
+	line := m.existing.Lines[existing.From.StartLine]
+	m.replace(existing.From.StartLine, existing.From.EndLine, []string{strings.Replace(line, current, image, 1)})
+}
+
+// mergeInstruction updates an instruction the user has not changed since the
+// base with the generated one.
+func (m *dockerfileMerger) mergeInstruction(path string, existing dockerfile.Instruction, generated dockerfile.Instruction, base dockerfile.Instruction, inBase bool) {
+	if m.tracker.useGenerated(path, existing.Text(), generated.Text(), base.Text(), inBase) {
+		m.replace(existing.StartLine, existing.EndLine, m.indented(existing, m.generatedSource(generated)))
+	}
+}
 
-	envLine := firstLineWithPrefix(generated, "ENV APP_START_CMD=")
-	if envLine != "" && !containsLine(existing, envLine) {
-		if merged != "" {
-			merged += "\n"
+// lastEnv returns the last ENV instruction of the stage that sets key.
+func lastEnv(stage dockerfile.Stage, key string) (dockerfile.Instruction, bool) {
+	var found dockerfile.Instruction
+	ok := false
+	for _, env := range stage.Find("ENV") {
+		for _, name := range env.EnvKeys() {
+			if name == key {
+				found, ok = env, true
+			}
 		}
-		merged += envLine
 	}
+	return found, ok
+}
 
-	cmdLine := `CMD ["sh", "-lc", "$APP_START_CMD"]`
-	hasAnyCMD := hasDirective(existing, "CMD ")
-	if !hasAnyCMD && containsLine(generated, cmdLine) && !containsLine(merged, cmdLine) {
-		if merged != "" {
-			merged += "\n"
+// envPosition is where a missing EXPOSE or variable goes: before the stage's
+// CMD, so the start command reads the variable, else at the end of the stage.
+func envPosition(stage dockerfile.Stage) int {
+	for _, instruction := range stage.Instructions {
+		if instruction.Keyword == "CMD" {
+			return instruction.StartLine
 		}
-		merged += cmdLine
 	}
+	return stage.EndLine() + 1
+}
 
-	if !strings.HasSuffix(merged, "\n") {
-		merged += "\n"
+// stageStart is the first line of a stage, including the comments directly
+// above its FROM.
+func (m *dockerfileMerger) stageStart(stage dockerfile.Stage) int {
+	start := stage.From.StartLine
+	for start > 0 && strings.HasPrefix(strings.TrimSpace(m.existing.Lines[start-1]), "#") {
+		start--
 	}
-	return merged, nil
+	return start
 }
 
-func firstLineWithPrefix(content string, prefix string) string {
-	for _, line := range strings.Split(content, "\n") {
-		trimmed := strings.TrimSpace(line)
-		if strings.HasPrefix(trimmed, prefix) {
-			return trimmed
+func (m *dockerfileMerger) insertStages(at int, stages []dockerfile.Stage) {
+	for _, stage := range stages {
+		m.insert(at, append(m.generatedStageSource(stage), ""))
+	}
+}
+
+func (m *dockerfileMerger) appendStages(stages []dockerfile.Stage) {
+	for _, stage := range stages {
+		lines := m.generatedStageSource(stage)
+		if len(m.existing.Lines) > 0 && strings.TrimSpace(m.existing.Lines[len(m.existing.Lines)-1]) != "" {
+			lines = append([]string{""}, lines...)
 		}
+		m.insert(len(m.existing.Lines), lines)
+	}
+}
+
+func (m *dockerfileMerger) generatedSource(instruction dockerfile.Instruction) []string {
+	return m.generated.Source(instruction.StartLine, instruction.EndLine)
+}
+
+func (m *dockerfileMerger) generatedStageSource(stage dockerfile.Stage) []string {
+	return m.generated.Source(stage.From.StartLine, stage.EndLine())
+}
+
+// indented gives replacement lines the indentation of the line they replace.
+func (m *dockerfileMerger) indented(existing dockerfile.Instruction, lines []string) []string {
+	line := m.existing.Lines[existing.StartLine]
+	indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+	if indent == "" || len(lines) == 0 {
+		return lines
 	}
-	return ""
+	lines[0] = indent + strings.TrimLeft(lines[0], " \t")
+	return lines
+}
+
+func (m *dockerfileMerger) insert(at int, lines []string) {
+	m.edits = append(m.edits, lineEdit{start: at, end: at, lines: lines, seq: len(m.edits)})
+}
+
+func (m *dockerfileMerger) replace(start int, end int, lines []string) {
+	m.edits = append(m.edits, lineEdit{start: start, end: end + 1, lines: lines, seq: len(m.edits)})
 }
 
-func containsLine(content string, line string) bool {
-	for _, existing := range strings.Split(content, "\n") {
-		if strings.TrimSpace(existing) == line {
-			return true
+// result applies the edits from the bottom up so earlier line numbers stay
+// valid. An unedited file is returned as it was.
+func (m *dockerfileMerger) result() string {
+	if len(m.edits) == 0 {
+		if len(m.existing.Lines) == 0 {
+			return ""
 		}
+		return strings.Join(m.existing.Lines, "\n") + "\n"
 	}
-	return false
+	sort.SliceStable(m.edits, func(i, j int) bool {
+		if m.edits[i].start != m.edits[j].start {
+			return m.edits[i].start > m.edits[j].start
+		}
+		return m.edits[i].seq > m.edits[j].seq
+	})
+	lines := append([]string(nil), m.existing.Lines...)
+	for _, edit := range m.edits {
+		updated := append([]string(nil), lines[:edit.start]...)
+		updated = append(updated, edit.lines...)
+		lines = append(updated, lines[edit.end:]...)
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func stageLabel(stage dockerfile.Stage, index int) string {
+	if stage.Name != "" {
+		return stage.Name
+	}
+	return "stage " + strconv.Itoa(index)
 }
 
-func hasDirective(content string, directive string) bool {
-	for _, line := range strings.Split(content, "\n") {
-		if strings.HasPrefix(strings.TrimSpace(line), directive) {
-			return true
+// matchStages maps each stage of generated to the index of the matching
+// stage of existing, or -1.
+func matchStages(existing []dockerfile.Stage, generated []dockerfile.Stage) []int {
+	matches := make([]int, len(generated))
+	used := make([]bool, len(existing))
+	var unnamed []int
+	for e, stage := range existing {
+		if stage.Name == "" {
+			unnamed = append(unnamed, e)
 		}
 	}
-	return false
+	for g, stage := range generated {
+		matches[g] = -1
+		if stage.Name == "" {
+			if len(unnamed) > 0 {
+				matches[g], used[unnamed[0]] = unnamed[0], true
+				unnamed = unnamed[1:]
+			}
+			continue
+		}
+		for e, candidate := range existing {
+			if candidate.Name == stage.Name && !used[e] {
+				matches[g], used[e] = e, true
+				break
+			}
+		}
+	}
+	last := len(generated) - 1
+	if last >= 0 && matches[last] < 0 && len(existing) > 0 && !used[len(existing)-1] {
+		matches[last] = len(existing) - 1
+	}
+	return matches
 }
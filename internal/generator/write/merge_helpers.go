@@ -1,34 +1,20 @@
 package write
 
 import (
-	"reflect"
 	"strings"
+
+	"gopkg.in/yaml.v3"
 )
 
-func environmentKey(value any) (string, bool) {
-	entry, ok := value.(string)
-	if !ok {
-		return "", false
-	}
+func environmentKey(entry string) string {
 	entry = strings.TrimSpace(entry)
-	if entry == "" {
-		return "", false
-	}
 	if idx := strings.Index(entry, "="); idx >= 0 {
-		key := strings.TrimSpace(entry[:idx])
-		if key == "" {
-			return "", false
-		}
-		return key, true
+		return strings.TrimSpace(entry[:idx])
 	}
-	return entry, true
+	return entry
 }
 
-func portHostKey(value any) (string, bool) {
-	entry, ok := value.(string)
-	if !ok {
-		return "", false
-	}
+func portHostKey(entry string) (string, bool) {
 	entry = strings.TrimSpace(entry)
 	if entry == "" {
 		return "", false
@@ -46,30 +32,198 @@ func portHostKey(value any) (string, bool) {
 	return host, true
 }
 
-func containsDeepValue(values []any, target any) bool {
-	for _, value := range values {
-		if reflect.DeepEqual(value, target) {
-			return true
+// nodePortHostKey returns the host port of a short-syntax port string or a
+// long-syntax port mapping.
+func nodePortHostKey(node *yaml.Node) (string, bool) {
+	node = resolveAlias(node)
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return portHostKey(node.Value)
+	case yaml.MappingNode:
+		if published := mappingValue(node, "published"); published != nil && published.Kind == yaml.ScalarNode {
+			return published.Value, published.Value != ""
 		}
 	}
+	return "", false
+}
+
+func mappingKeyIndex(mapping *yaml.Node, key string) int {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	if idx := mappingKeyIndex(mapping, key); idx >= 0 {
+		return mapping.Content[idx+1]
+	}
+	return nil
+}
+
+// lookupKey is mappingValue for an optional node, such as a part of the merge
+// base that may not exist.
+func lookupKey(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	return mappingValue(node, key)
+}
+
+// containsName reports whether a list holds the scalar or a mapping has the
+// key, covering both forms of depends_on and networks.
+func containsName(node *yaml.Node, name string) bool {
+	if node == nil {
+		return false
+	}
+	switch node.Kind {
+	case yaml.SequenceNode:
+		return sequenceContainsScalar(node, name)
+	case yaml.MappingNode:
+		return mappingValue(node, name) != nil
+	}
 	return false
 }
 
-func deepCopy(value any) any {
-	switch typed := value.(type) {
-	case map[string]any:
-		out := make(map[string]any, len(typed))
-		for key, nested := range typed {
-			out[key] = deepCopy(nested)
+func scalarValue(node *yaml.Node) (string, bool) {
+	node = resolveAlias(node)
+	if node == nil || node.Kind != yaml.ScalarNode || isNullNode(node) {
+		return "", false
+	}
+	return node.Value, true
+}
+
+// joinedScalars flattens a list of scalars into one comparable value.
+func joinedScalars(node *yaml.Node) (string, bool) {
+	node = resolveAlias(node)
+	if node == nil || node.Kind != yaml.SequenceNode {
+		return "", false
+	}
+	values := make([]string, 0, len(node.Content))
+	for _, item := range node.Content {
+		value, ok := scalarValue(item)
+		if !ok {
+			return "", false
+		}
+		values = append(values, value)
+	}
+	return strings.Join(values, " "), true
+}
+
+// setScalar copies the value of src into dst, keeping the comments of dst.
+func setScalar(dst *yaml.Node, src *yaml.Node) {
+	dst.Value = src.Value
+	dst.Tag = src.Tag
+	dst.Style = src.Style
+}
+
+// environmentValues maps variable names to values for either environment
+// form. Bare entries without a value are left out.
+func environmentValues(node *yaml.Node) map[string]string {
+	values := map[string]string{}
+	if node == nil {
+		return values
+	}
+	switch node.Kind {
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			if item.Kind != yaml.ScalarNode {
+				continue
+			}
+			if value, ok := environmentEntryValue(item, false); ok {
+				values[environmentKey(item.Value)] = value
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if value, ok := environmentEntryValue(node.Content[i+1], true); ok {
+				values[node.Content[i].Value] = value
+			}
+		}
+	}
+	return values
+}
+
+// environmentEntryValue returns the value of a list entry (KEY=VALUE) or of a
+// map value node.
+func environmentEntryValue(node *yaml.Node, mapForm bool) (string, bool) {
+	if mapForm {
+		return scalarValue(node)
+	}
+	idx := strings.Index(node.Value, "=")
+	if idx < 0 {
+		return "", false
+	}
+	return node.Value[idx+1:], true
+}
+
+// mergedKeyValue looks key up in the mappings pulled in through "<<" merge
+// keys.
+func mergedKeyValue(mapping *yaml.Node, key string) *yaml.Node {
+	merged := mappingValue(mapping, "<<")
+	if merged == nil {
+		return nil
+	}
+	sources := []*yaml.Node{merged}
+	if resolved := resolveAlias(merged); resolved.Kind == yaml.SequenceNode {
+		sources = resolved.Content
+	}
+	for _, source := range sources {
+		source = resolveAlias(source)
+		if source.Kind != yaml.MappingNode {
+			continue
 		}
-		return out
-	case []any:
-		out := make([]any, len(typed))
-		for i := range typed {
-			out[i] = deepCopy(typed[i])
+		if value := mappingValue(source, key); value != nil {
+			return value
 		}
-		return out
+		if value := mergedKeyValue(source, key); value != nil {
+			return value
+		}
+	}
+	return nil
+}
+
+func sequenceContainsScalar(sequence *yaml.Node, value string) bool {
+	for _, item := range sequence.Content {
+		item = resolveAlias(item)
+		if item.Kind == yaml.ScalarNode && item.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node != nil && node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+func isNullNode(node *yaml.Node) bool {
+	return node == nil || (node.Kind == yaml.ScalarNode && node.Tag == "!!null")
+}
+
+// isEmptyNode reports whether a value carries nothing: null, an empty string
+// or an empty collection.
+func isEmptyNode(node *yaml.Node) bool {
+	node = resolveAlias(node)
+	switch {
+	case isNullNode(node):
+		return true
+	case node.Kind == yaml.ScalarNode:
+		return strings.TrimSpace(node.Value) == ""
 	default:
-		return typed
+		return len(node.Content) == 0
 	}
 }
+
+func scalarNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+func nullNode() *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null"}
+}
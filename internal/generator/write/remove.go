@@ -0,0 +1,203 @@
+package write
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"docker-wizard/internal/generator/compose"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RemoveComposeServices deletes the services, top-level named volumes and
+// secrets of a removal plan from compose YAML, strips the services from the
+// depends_on of the services that remain and deletes the variables and
+// secrets the plan lists from them. Like the merge it edits the existing node
+// tree, so comments and layout of everything else are kept. Nothing to
+// remove returns the content verbatim.
+func RemoveComposeServices(existing string, plan compose.Removal) (string, error) {
+	root, layout, err := parseYAMLPreservingLayout(existing)
+	if err != nil {
+		return "", err
+	}
+	if len(root.Content) == 0 || isNullNode(root.Content[0]) {
+		return existing, nil
+	}
+	mapping := root.Content[0]
+	if mapping.Kind != yaml.MappingNode {
+		return "", fmt.Errorf("compose file must be a mapping")
+	}
+
+	changed := false
+	if servicesNode := mappingValue(mapping, "services"); servicesNode != nil && servicesNode.Kind == yaml.MappingNode {
+		for _, name := range plan.Names {
+			changed = deleteKey(servicesNode, name) || changed
+		}
+		for i := 0; i+1 < len(servicesNode.Content); i += 2 {
+			service := servicesNode.Content[i+1]
+			if service.Kind != yaml.MappingNode {
+				continue
+			}
+			changed = removeDependencies(service, plan.Names) || changed
+			name := servicesNode.Content[i].Value
+			changed = removeEnvironment(service, plan.Env[name]) || changed
+			changed = removeSecrets(service, plan.ServiceSecrets[name]) || changed
+		}
+	}
+	changed = removeResources(mapping, "volumes", plan.Volumes) || changed
+	changed = removeResources(mapping, "secrets", plan.Secrets) || changed
+
+	if !changed {
+		return existing, nil
+	}
+	return layout.encode(root)
+}
+
+// removeDependencies drops names from the depends_on of a service, in list or
+// long form, and deletes depends_on once it is empty.
+func removeDependencies(service *yaml.Node, names []string) bool {
+	dependsOn := mappingValue(service, "depends_on")
+	if dependsOn == nil {
+		return false
+	}
+	changed := false
+	for _, name := range names {
+		switch dependsOn.Kind {
+		case yaml.SequenceNode:
+			for i := 0; i < len(dependsOn.Content); i++ {
+				if item := resolveAlias(dependsOn.Content[i]); item.Kind == yaml.ScalarNode && item.Value == name {
+					dependsOn.Content = append(dependsOn.Content[:i], dependsOn.Content[i+1:]...)
+					changed = true
+					i--
+				}
+			}
+		case yaml.MappingNode:
+			changed = deleteKey(dependsOn, name) || changed
+		}
+	}
+	if changed && len(dependsOn.Content) == 0 {
+		deleteKey(service, "depends_on")
+	}
+	return changed
+}
+
+// removeEnvironment drops keys from the environment of a service, in list or
+// map form, and deletes environment once it is empty.
+func removeEnvironment(service *yaml.Node, keys []string) bool {
+	environment := mappingValue(service, "environment")
+	if environment == nil || len(keys) == 0 {
+		return false
+	}
+	changed := false
+	for _, key := range keys {
+		switch environment.Kind {
+		case yaml.SequenceNode:
+			for i := 0; i < len(environment.Content); i++ {
+				if item := environment.Content[i]; item.Kind == yaml.ScalarNode && environmentKey(item.Value) == key {
+					environment.Content = append(environment.Content[:i], environment.Content[i+1:]...)
+					changed = true
+					i--
+				}
+			}
+		case yaml.MappingNode:
+			changed = deleteKey(environment, key) || changed
+		}
+	}
+	if changed && len(environment.Content) == 0 {
+		deleteKey(service, "environment")
+	}
+	return changed
+}
+
+// removeSecrets drops names from the secrets list of a service, in short or
+// long form, and deletes secrets once it is empty.
+func removeSecrets(service *yaml.Node, names []string) bool {
+	secrets := mappingValue(service, "secrets")
+	if secrets == nil || secrets.Kind != yaml.SequenceNode || len(names) == 0 {
+		return false
+	}
+	changed := false
+	for _, name := range names {
+		for i := 0; i < len(secrets.Content); i++ {
+			if secretSource(secrets.Content[i]) == name {
+				secrets.Content = append(secrets.Content[:i], secrets.Content[i+1:]...)
+				changed = true
+				i--
+			}
+		}
+	}
+	if changed && len(secrets.Content) == 0 {
+		deleteKey(service, "secrets")
+	}
+	return changed
+}
+
+// removeResources deletes top-level named volumes or secrets, and the whole
+// section once it is empty.
+func removeResources(mapping *yaml.Node, key string, names []string) bool {
+	resources := mappingValue(mapping, key)
+	if resources == nil || resources.Kind != yaml.MappingNode {
+		return false
+	}
+	changed := false
+	for _, name := range names {
+		changed = deleteKey(resources, name) || changed
+	}
+	if len(resources.Content) == 0 {
+		deleteKey(mapping, key)
+	}
+	return changed
+}
+
+// deleteKey removes key and its value from a mapping.
+func deleteKey(mapping *yaml.Node, key string) bool {
+	idx := mappingKeyIndex(mapping, key)
+	if idx < 0 {
+		return false
+	}
+	mapping.Content = append(mapping.Content[:idx], mapping.Content[idx+2:]...)
+	return true
+}
+
+// WriteComposeRemoval applies a removal plan to the compose file, keeping a
+// backup of the previous content. The same removal is applied to the recorded
+// state so a later add is not mistaken for a user deletion. Used by the remove
+// subcommand.
+func WriteComposeRemoval(root string, plan compose.Removal) (WriteStatus, string, error) {
+	if root == "" {
+		return "", "", fmt.Errorf("root directory is required")
+	}
+	composePath := filepath.Join(root, ComposeFileName)
+	if _, err := os.Stat(composePath); err != nil {
+		if os.IsNotExist(err) {
+			return "", "", fmt.Errorf("%s not found", ComposeFileName)
+		}
+		return "", "", fmt.Errorf("stat %s: %w", ComposeFileName, err)
+	}
+	state, err := ReadState(root)
+	if err != nil {
+		return "", "", err
+	}
+
+	remove := func(_ string, existing string, _ string) (string, []Conflict, error) {
+		content, err := RemoveComposeServices(existing, plan)
+		return content, nil, err
+	}
+	status, backup, _, err := writeManagedFile(root, composePath, "docker-compose-*.tmp", "", "", remove)
+	if err != nil {
+		return "", "", err
+	}
+
+	if base := state.Base(ComposeFileName); base != "" {
+		nextBase, err := RemoveComposeServices(base, plan)
+		if err != nil {
+			return "", "", fmt.Errorf("update state: %w", err)
+		}
+		state.Files[ComposeFileName] = nextBase
+		if err := writeState(root, state); err != nil {
+			return "", "", err
+		}
+	}
+	return status, backup, nil
+}
@@ -0,0 +1,190 @@
+package write
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"docker-wizard/internal/generator/catalog"
+	"docker-wizard/internal/generator/compose"
+	"docker-wizard/internal/utils"
+)
+
+// FileOutput reports the write status of one generated file.
+type FileOutput struct {
+	Path   string
+	Status WriteStatus
+}
+
+// SecretFilePaths returns the absolute paths of the secret files the compose
+// file declares under the secrets directory, sorted.
+func SecretFilePaths(root string, content string) ([]string, error) {
+	doc, err := compose.ParseDocument(content)
+	if err != nil {
+		return nil, fmt.Errorf("parse compose file: %w", err)
+	}
+	var paths []string
+	for _, file := range compose.SecretFiles(doc) {
+		paths = append(paths, filepath.Join(root, filepath.FromSlash(file)))
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// writeSecretFiles creates the missing secret files of the generated compose
+// file. Existing secret files are never changed, since services were
+// initialized with them, and their content is not recorded in the state file.
+// A secret the apps also receive through .env gets the .env value. Like .env,
+// new secret files are readable by the owner only.
+func writeSecretFiles(root string, existingCompose string, generated string, output *Output) error {
+	paths, err := SecretFilePaths(root, generated)
+	if err != nil || len(paths) == 0 {
+		return err
+	}
+	env, err := readOptional(filepath.Join(root, EnvFileName))
+	if err != nil {
+		return err
+	}
+	previous := previousSecretValues(existingCompose, env, generated)
+	envValues := envFileValues(env)
+	for name, variable := range secretVariables(generated) {
+		if value := envValues[variable]; value != "" {
+			previous[name] = value
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(root, compose.SecretsDirName), 0o755); err != nil {
+		return fmt.Errorf("create secrets directory: %w", err)
+	}
+
+	for _, path := range paths {
+		if utils.FileExists(path) {
+			output.Secrets = append(output.Secrets, FileOutput{Path: path, Status: WriteStatusUnchanged})
+			continue
+		}
+		secret, ok := previous[strings.TrimSuffix(filepath.Base(path), ".txt")]
+		if !ok {
+			if secret, err = RandomSecret(); err != nil {
+				return err
+			}
+		}
+		if err := writeByTemp(root, path, "secret-*.tmp", secret, 0o600); err != nil {
+			return err
+		}
+		output.Secrets = append(output.Secrets, FileOutput{Path: path, Status: WriteStatusCreated})
+	}
+	return nil
+}
+
+// secretVariables maps the secrets behind a _FILE variable of the generated
+// compose file to the .env variable that passes the same password to the
+// apps, POSTGRES_PASSWORD for the secret of POSTGRES_PASSWORD_FILE of
+// postgres, when the compose file references that variable.
+func secretVariables(generated string) map[string]string {
+	variables := map[string]string{}
+	doc, err := compose.ParseDocument(generated)
+	if err != nil {
+		return variables
+	}
+	referenced := map[string]bool{}
+	for _, name := range ComposeVariables(generated) {
+		referenced[name] = true
+	}
+	for _, svc := range doc.Services {
+		for _, variable := range svc.Environment.Vars {
+			plain, isFile := strings.CutSuffix(variable.Key, "_FILE")
+			name, isSecret := strings.CutPrefix(variable.Value, catalog.SecretMountPath(""))
+			if !isFile || !isSecret {
+				continue
+			}
+			if env := compose.EnvVariableName(svc.Name, plain); referenced[env] {
+				variables[name] = env
+			}
+		}
+	}
+	return variables
+}
+
+// sharedSecretValues returns the values of the .env variables of
+// secretVariables that a secret already fixes: the content of the existing
+// secret file, else the password the existing compose file passed before the
+// secret replaced it. The apps then connect with the password the database
+// was initialized with.
+func sharedSecretValues(root string, existingCompose string, env string, generated string) (map[string]string, error) {
+	variables := secretVariables(generated)
+	if len(variables) == 0 {
+		return nil, nil
+	}
+	previous := previousSecretValues(existingCompose, env, generated)
+	values := map[string]string{}
+	for name, variable := range variables {
+		content, err := readOptional(filepath.Join(root, filepath.FromSlash(compose.SecretFile(name))))
+		if err != nil {
+			return nil, err
+		}
+		if content = strings.TrimRight(content, "\r\n"); content != "" {
+			values[variable] = content
+		} else if value, ok := previous[name]; ok {
+			values[variable] = value
+		}
+	}
+	return values, nil
+}
+
+// previousSecretValues maps secrets to the value the existing compose file
+// still passes in the variable the secret replaces: POSTGRES_PASSWORD for
+// POSTGRES_PASSWORD_FILE, either literally or through a .env variable. A
+// secret file created from it keeps the password a database volume was
+// initialized with.
+func previousSecretValues(existingCompose string, env string, generated string) map[string]string {
+	values := map[string]string{}
+	if existingCompose == "" {
+		return values
+	}
+	existingDoc, err := compose.ParseDocument(existingCompose)
+	if err != nil {
+		return values
+	}
+	generatedDoc, err := compose.ParseDocument(generated)
+	if err != nil {
+		return values
+	}
+	envValues := envFileValues(env)
+
+	for _, svc := range generatedDoc.Services {
+		current, ok := existingDoc.Service(svc.Name)
+		if !ok {
+			continue
+		}
+		for _, variable := range svc.Environment.Vars {
+			plain, isFile := strings.CutSuffix(variable.Key, "_FILE")
+			name, isSecret := strings.CutPrefix(variable.Value, catalog.SecretMountPath(""))
+			if !isFile || !isSecret {
+				continue
+			}
+			old, ok := current.Environment.Get(plain)
+			if !ok || !old.HasValue || !movesIntoSecretFile(old.Value) {
+				continue
+			}
+			if match := variableReferencePattern.FindStringSubmatch(old.Value); match != nil {
+				if value := envValues[match[1]]; value != "" {
+					values[name] = value
+				}
+				continue
+			}
+			values[name] = old.Value
+		}
+	}
+	return values
+}
+
+// movesIntoSecretFile reports whether the value of a variable replaced by a
+// secret is carried into the secret file: a literal, or a reference to a .env
+// variable. Other interpolations are left to the user.
+func movesIntoSecretFile(value string) bool {
+	if value == "" {
+		return false
+	}
+	return variableReferencePattern.MatchString(value) || !strings.Contains(value, "${")
+}
@@ -0,0 +1,66 @@
+package write
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	StateDirName  = ".docker-wizard"
+	StateFileName = "state.json"
+
+	stateVersion = 1
+)
+
+// State records the last generated content of each managed file. It is the
+// base of the three-way merge, which lets the merge tell a value the user
+// deleted from one the catalog has just added.
+type State struct {
+	Version int               `json:"version"`
+	Files   map[string]string `json:"files"`
+}
+
+func StatePath(root string) string {
+	return filepath.Join(root, StateDirName, StateFileName)
+}
+
+// ReadState loads the state file. A missing file yields an empty state.
+func ReadState(root string) (State, error) {
+	state := State{Version: stateVersion, Files: map[string]string{}}
+	data, err := os.ReadFile(StatePath(root))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return State{}, fmt.Errorf("read state: %w", err)
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return State{}, fmt.Errorf("parse state: %w", err)
+	}
+	if state.Files == nil {
+		state.Files = map[string]string{}
+	}
+	return state, nil
+}
+
+// Base returns the last generated content recorded for a managed file.
+func (s State) Base(name string) string {
+	return s.Files[name]
+}
+
+func writeState(root string, state State) error {
+	state.Version = stateVersion
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, StateDirName), 0o755); err != nil {
+		return fmt.Errorf("create state directory: %w", err)
+	}
+	if err := os.WriteFile(StatePath(root), append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write state: %w", err)
+	}
+	return nil
+}
@@ -3,8 +3,10 @@ package write
 import (
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 
+	"docker-wizard/internal/generator/compose"
 	"docker-wizard/internal/utils"
 )
 
@@ -12,15 +14,60 @@ const (
 	ComposeFileName      = "docker-compose.yml"
 	DockerfileFileName   = "Dockerfile"
 	DockerignoreFileName = ".dockerignore"
+	GitignoreFileName    = ".gitignore"
+	// ComposeOverrideFileName holds the local development settings of a
+	// split compose output.
+	ComposeOverrideFileName = compose.ComposeOverrideFileName
 )
 
 type Output struct {
-	ComposePath          string
-	ComposeStatus        WriteStatus
-	ComposeBackupPath    string
+	ComposePath       string
+	ComposeStatus     WriteStatus
+	ComposeBackupPath string
+	ComposeConflicts  []Conflict
+	// ComposeOverridePath is empty when the compose output is not split.
+	ComposeOverridePath       string
+	ComposeOverrideStatus     WriteStatus
+	ComposeOverrideBackupPath string
+	ComposeOverrideConflicts  []Conflict
+	DockerfilePath            string
+	DockerfileStatus          WriteStatus
+	DockerfileBackupPath      string
+	DockerfileConflicts       []Conflict
+	DockerignorePath          string
+	DockerignoreStatus        WriteStatus
+	// EnvPath and EnvExamplePath are empty when the compose file references
+	// no variables.
+	EnvPath          string
+	EnvStatus        WriteStatus
+	EnvExamplePath   string
+	EnvExampleStatus WriteStatus
+	// Secrets lists the secret files the compose file declares.
+	Secrets []FileOutput
+	// GitignorePath is empty when neither .env nor secret files are
+	// written; .env and the secrets directory are added to .gitignore.
+	GitignorePath   string
+	GitignoreStatus WriteStatus
+	// Apps holds the files of the apps of a monorepo, which are written
+	// instead of the Dockerfile and .dockerignore of the root.
+	Apps []AppOutput
+}
+
+// AppFile is the generated Dockerfile of an app and its directory relative
+// to the project root, "." for the root.
+type AppFile struct {
+	Dir        string
+	Dockerfile string
+}
+
+// AppOutput is the result of writing the Dockerfile and .dockerignore of an
+// app.
+type AppOutput struct {
+	Dir                  string
 	DockerfilePath       string
 	DockerfileStatus     WriteStatus
 	DockerfileBackupPath string
+	DockerfileConflicts  []Conflict
 	DockerignorePath     string
 	DockerignoreStatus   WriteStatus
 }
@@ -34,54 +81,244 @@ const (
 )
 
 func WriteFiles(root string, compose string, dockerfile string) (Output, error) {
+	return WriteFilesWithComposeOverride(root, compose, "", dockerfile)
+}
+
+// WriteFilesWithComposeOverride writes the managed files of a split compose
+// output: override is merged into docker-compose.override.yml like compose
+// into docker-compose.yml. An empty override leaves that file alone.
+func WriteFilesWithComposeOverride(root string, compose string, override string, dockerfile string) (Output, error) {
+	output, err := writeFiles(root, compose, override, []AppFile{{Dir: ".", Dockerfile: dockerfile}})
+	if err != nil {
+		return Output{}, err
+	}
+	app := output.Apps[0]
+	output.Apps = nil
+	output.DockerfilePath = app.DockerfilePath
+	output.DockerfileStatus = app.DockerfileStatus
+	output.DockerfileBackupPath = app.DockerfileBackupPath
+	output.DockerfileConflicts = app.DockerfileConflicts
+	output.DockerignorePath = app.DockerignorePath
+	output.DockerignoreStatus = app.DockerignoreStatus
+	return output, nil
+}
+
+// WriteAppFiles writes the managed files of a monorepo: the compose files at
+// the root and a Dockerfile and .dockerignore in the directory of every app,
+// since each app is built with its directory as the context.
+func WriteAppFiles(root string, compose string, override string, apps []AppFile) (Output, error) {
+	if len(apps) == 0 {
+		return Output{}, fmt.Errorf("no apps to write")
+	}
+	return writeFiles(root, compose, override, apps)
+}
+
+func writeFiles(root string, compose string, override string, apps []AppFile) (Output, error) {
 	if root == "" {
 		return Output{}, fmt.Errorf("root directory is required")
 	}
 
 	composePath := filepath.Join(root, ComposeFileName)
-	dockerfilePath := filepath.Join(root, DockerfileFileName)
-	dockerignorePath := filepath.Join(root, DockerignoreFileName)
+	output := Output{ComposePath: composePath}
 
-	output := Output{
-		ComposePath:      composePath,
-		DockerfilePath:   dockerfilePath,
-		DockerignorePath: dockerignorePath,
+	state, err := ReadState(root)
+	if err != nil {
+		return Output{}, err
 	}
 
-	composeStatus, composeBackup, err := writeManagedFile(root, composePath, "docker-compose-*.tmp", compose, MergeCompose)
+	// read before the merge so .env can keep the values being moved into it
+	existingCompose, err := readOptional(composePath)
+	if err != nil {
+		return Output{}, err
+	}
+
+	composeStatus, composeBackup, composeConflicts, err := writeManagedFile(root, composePath, "docker-compose-*.tmp", state.Base(ComposeFileName), compose, MergeComposeThreeWay)
 	if err != nil {
 		return Output{}, err
 	}
 	output.ComposeStatus = composeStatus
 	output.ComposeBackupPath = composeBackup
+	output.ComposeConflicts = composeConflicts
 
-	dockerfileStatus, dockerfileBackup, err := writeManagedFile(root, dockerfilePath, "dockerfile-*.tmp", dockerfile, MergeDockerfile)
+	if override != "" {
+		output.ComposeOverridePath = filepath.Join(root, ComposeOverrideFileName)
+		status, backup, conflicts, err := writeManagedFile(root, output.ComposeOverridePath, "docker-compose-override-*.tmp", state.Base(ComposeOverrideFileName), override, MergeComposeThreeWay)
+		if err != nil {
+			return Output{}, err
+		}
+		output.ComposeOverrideStatus = status
+		output.ComposeOverrideBackupPath = backup
+		output.ComposeOverrideConflicts = conflicts
+		state.Files[ComposeOverrideFileName] = override
+	}
+
+	if err := writeEnvFiles(root, existingCompose, compose, &output); err != nil {
+		return Output{}, err
+	}
+	if err := writeSecretFiles(root, existingCompose, compose, &output); err != nil {
+		return Output{}, err
+	}
+
+	for _, app := range apps {
+		appOutput, err := writeAppFile(root, app, state)
+		if err != nil {
+			return Output{}, err
+		}
+		output.Apps = append(output.Apps, appOutput)
+	}
+	dockerignoreStatus, err := ignoreSecretFiles(root, &output)
 	if err != nil {
 		return Output{}, err
 	}
-	output.DockerfileStatus = dockerfileStatus
-	output.DockerfileBackupPath = dockerfileBackup
+	for i, app := range output.Apps {
+		if app.Dir == "." && dockerignoreStatus == WriteStatusUpdated {
+			output.Apps[i].DockerignoreStatus = WriteStatusUpdated
+		}
+	}
+
+	state.Files[ComposeFileName] = compose
+	if err := writeState(root, state); err != nil {
+		return Output{}, err
+	}
+
+	return output, nil
+}
+
+// writeAppFile merges the Dockerfile of an app and creates its
+// .dockerignore, recording the Dockerfile in state.
+func writeAppFile(root string, app AppFile, state State) (AppOutput, error) {
+	dir := filepath.Join(root, filepath.FromSlash(app.Dir))
+	output := AppOutput{
+		Dir:              app.Dir,
+		DockerfilePath:   filepath.Join(dir, DockerfileFileName),
+		DockerignorePath: filepath.Join(dir, DockerignoreFileName),
+	}
+
+	name := AppFileName(app.Dir, DockerfileFileName)
+	status, backup, conflicts, err := writeManagedFile(root, output.DockerfilePath, "dockerfile-*.tmp", state.Base(name), app.Dockerfile, MergeDockerfileThreeWay)
+	if err != nil {
+		return AppOutput{}, err
+	}
+	output.DockerfileStatus = status
+	output.DockerfileBackupPath = backup
+	output.DockerfileConflicts = conflicts
 
-	if !utils.FileExists(dockerignorePath) {
-		if err := os.WriteFile(dockerignorePath, []byte(DefaultDockerignore()), 0644); err != nil {
-			return Output{}, fmt.Errorf("write dockerignore: %w", err)
+	if !utils.FileExists(output.DockerignorePath) {
+		if err := os.WriteFile(output.DockerignorePath, []byte(DefaultDockerignore()), 0644); err != nil {
+			return AppOutput{}, fmt.Errorf("write dockerignore: %w", err)
 		}
 		output.DockerignoreStatus = WriteStatusCreated
 	} else {
 		output.DockerignoreStatus = WriteStatusUnchanged
 	}
 
+	state.Files[name] = app.Dockerfile
 	return output, nil
 }
 
-type mergeFunc func(existing string, generated string) (string, error)
+// MergedDockerfile returns the Dockerfile the app in dir ends up with once
+// generated is merged into it, generated itself when there is none yet. A
+// merge without base does not insert generated stages, so the stages to
+// build are read from this result rather than from generated.
+func MergedDockerfile(root string, dir string, generated string) (string, error) {
+	existing, err := readOptional(filepath.Join(root, filepath.FromSlash(dir), DockerfileFileName))
+	if err != nil {
+		return "", err
+	}
+	if existing == "" {
+		return generated, nil
+	}
+	state, err := ReadState(root)
+	if err != nil {
+		return "", err
+	}
+	merged, _, err := MergeDockerfileThreeWay(state.Base(AppFileName(dir, DockerfileFileName)), existing, generated)
+	if err != nil {
+		return "", fmt.Errorf("merge %s: %w", DockerfileFileName, err)
+	}
+	return merged, nil
+}
+
+// AppFileName names a file of an app relative to the project root, such as
+// apps/web/Dockerfile. It is also the key of the file in the state.
+func AppFileName(dir string, name string) string {
+	return path.Join(dir, name)
+}
+
+// WriteSupportFiles writes the files a compose fragment needs besides the
+// compose file: .env, .env.example and the secret files, which are added to
+// .gitignore and the .dockerignore of the root. Used by the add subcommand
+// before the fragment is merged. Only the fields of those files are set in
+// the result, .dockerignore only when it changed.
+func WriteSupportFiles(root string, compose string) (Output, error) {
+	if root == "" {
+		return Output{}, fmt.Errorf("root directory is required")
+	}
+	existingCompose, err := readOptional(filepath.Join(root, ComposeFileName))
+	if err != nil {
+		return Output{}, err
+	}
+	var output Output
+	if err := writeEnvFiles(root, existingCompose, compose, &output); err != nil {
+		return Output{}, err
+	}
+	if err := writeSecretFiles(root, existingCompose, compose, &output); err != nil {
+		return Output{}, err
+	}
+	status, err := ignoreSecretFiles(root, &output)
+	if err != nil {
+		return Output{}, err
+	}
+	if status == WriteStatusUpdated {
+		output.DockerignorePath = filepath.Join(root, DockerignoreFileName)
+		output.DockerignoreStatus = status
+	}
+	return output, nil
+}
+
+// ComposeWithFragment folds a compose fragment into the recorded base,
+// giving the full output the generator stands for after the add. The
+// fragment only holds the added services and a partial app service, so it
+// cannot be merged against the base on its own: the base entries it lacks
+// would look stale. Without a base the fragment is returned as is.
+func ComposeWithFragment(base string, fragment string) (string, error) {
+	if base == "" {
+		return fragment, nil
+	}
+	merged, err := MergeCompose(base, fragment)
+	if err != nil {
+		return "", fmt.Errorf("merge fragment into base: %w", err)
+	}
+	return merged, nil
+}
+
+type mergeFunc func(base string, existing string, generated string) (string, []Conflict, error)
 
 // WriteComposeFile writes only the compose file, leaving Dockerfile and
 // .dockerignore untouched. Used by the add subcommand.
-func WriteComposeFile(root string, compose string) (WriteStatus, string, error) {
+func WriteComposeFile(root string, compose string) (WriteStatus, string, []Conflict, error) {
 	if root == "" {
-		return "", "", fmt.Errorf("root directory is required")
+		return "", "", nil, fmt.Errorf("root directory is required")
+	}
+	state, err := ReadState(root)
+	if err != nil {
+		return "", "", nil, err
 	}
+
 	composePath := filepath.Join(root, ComposeFileName)
-	return writeManagedFile(root, composePath, "docker-compose-*.tmp", compose, MergeCompose)
+	base := state.Base(ComposeFileName)
+	generated, err := ComposeWithFragment(base, compose)
+	if err != nil {
+		return "", "", nil, err
+	}
+	status, backup, conflicts, err := writeManagedFile(root, composePath, "docker-compose-*.tmp", base, generated, MergeComposeThreeWay)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	state.Files[ComposeFileName] = generated
+	if err := writeState(root, state); err != nil {
+		return "", "", nil, err
+	}
+	return status, backup, conflicts, nil
 }
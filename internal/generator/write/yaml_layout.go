@@ -0,0 +1,128 @@
+package write
+
+import (
+	"bytes"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// blankLineMarker stands in for blank lines while a file is held as a
+// yaml.Node tree. yaml.v3 keeps comments but drops blank lines, so blank lines
+// are turned into marker comments before parsing and back after encoding.
+const blankLineMarker = "#docker-wizard:blank-line"
+
+// yamlLayout records the formatting of an existing file that yaml.v3 does not
+// keep on its own.
+type yamlLayout struct {
+	indent int
+}
+
+// parseYAMLPreservingLayout parses content into a node tree that keeps
+// comments, key order, anchors and blank-line grouping.
+func parseYAMLPreservingLayout(content string) (*yaml.Node, yamlLayout, error) {
+	layout := yamlLayout{indent: detectIndent(content)}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(markBlankLines(content)), &root); err == nil && restoreBlankScalars(&root) {
+		fixMergeKeyTags(&root)
+		return &root, layout, nil
+	}
+
+	// The marker comments could not be placed safely (for example inside a
+	// multi-line quoted scalar); fall back to a plain parse.
+	root = yaml.Node{}
+	if err := yaml.Unmarshal([]byte(content), &root); err != nil {
+		return nil, layout, err
+	}
+	fixMergeKeyTags(&root)
+	return &root, layout, nil
+}
+
+func (l yamlLayout) encode(root *yaml.Node) (string, error) {
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(l.indent)
+	if err := encoder.Encode(root); err != nil {
+		return "", err
+	}
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+
+	lines := strings.Split(buffer.String(), "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) == blankLineMarker {
+			lines[i] = ""
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// markBlankLines replaces blank lines with marker comments indented like the
+// next non-blank line, which is where yaml.v3 attaches the comment.
+func markBlankLines(content string) string {
+	lines := strings.Split(content, "\n")
+	indent := ""
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := lines[i]
+		if strings.TrimSpace(line) != "" {
+			indent = line[:len(line)-len(strings.TrimLeft(line, " "))]
+			continue
+		}
+		if i < len(lines)-1 {
+			lines[i] = indent + blankLineMarker
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// restoreBlankScalars turns marker lines captured inside block scalars back
+// into blank lines. It reports false when a marker ended up inside a flow
+// scalar, where it cannot be told apart from content.
+func restoreBlankScalars(node *yaml.Node) bool {
+	if node.Kind == yaml.ScalarNode && strings.Contains(node.Value, blankLineMarker) {
+		if node.Style&(yaml.LiteralStyle|yaml.FoldedStyle) == 0 {
+			return false
+		}
+		lines := strings.Split(node.Value, "\n")
+		for i, line := range lines {
+			if strings.TrimSpace(line) == blankLineMarker {
+				lines[i] = ""
+			}
+		}
+		node.Value = strings.Join(lines, "\n")
+	}
+	for _, child := range node.Content {
+		if !restoreBlankScalars(child) {
+			return false
+		}
+	}
+	return true
+}
+
+// fixMergeKeyTags clears the explicit tag yaml.v3 puts on "<<" merge keys,
+// which it would otherwise emit as "!!merge <<".
+func fixMergeKeyTags(node *yaml.Node) {
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!merge" {
+		node.Tag = ""
+	}
+	for _, child := range node.Content {
+		fixMergeKeyTags(child)
+	}
+}
+
+// detectIndent returns the indentation of the first nested line, defaulting
+// to two spaces when it is missing or outside what yaml.v3 can emit.
+func detectIndent(content string) int {
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "-") {
+			continue
+		}
+		if indent := len(line) - len(trimmed); indent >= 2 && indent <= 8 {
+			return indent
+		}
+	}
+	return 2
+}
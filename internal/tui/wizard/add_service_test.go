@@ -3,8 +3,11 @@ package wizard
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"docker-wizard/internal/generator"
+
 	tea "github.com/charmbracelet/bubbletea"
 )
 
@@ -159,3 +162,184 @@ func TestAddService_EmptyImageShowsError(t *testing.T) {
 		t.Fatal("expected formError to be set for empty image")
 	}
 }
+
+func TestEditService_EnterStoresOverride(t *testing.T) {
+	m, _ := makeModelWithCatalog(t)
+	m.step = stepCache
+	m.services[0].Image = "redis:7-alpine"
+
+	m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'e'}})
+	if m.step != stepEditService || m.editServiceID != "redis" {
+		t.Fatalf("expected edit form for redis, got step %v id %q", m.step, m.editServiceID)
+	}
+	if m.editServiceInputs[0].Placeholder != "redis:7-alpine" {
+		t.Fatalf("expected catalog image placeholder, got %q", m.editServiceInputs[0].Placeholder)
+	}
+
+	for _, r := range "redis:7.2" {
+		m.handleEditServiceMsg(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	m.handleEditServiceMsg(tea.KeyMsg{Type: tea.KeyTab})
+	for _, r := range "6380:6379" {
+		m.handleEditServiceMsg(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	m.handleEditServiceMsg(tea.KeyMsg{Type: tea.KeyEnter})
+
+	if m.step != stepCache {
+		t.Fatalf("expected to return to stepCache, got %v (formError: %q)", m.step, m.editServiceFormError)
+	}
+	override := m.project.Overrides["redis"]
+	if override.Image != "redis:7.2" || len(override.Ports) != 1 || override.Ports[0] != "6380:6379" {
+		t.Fatalf("unexpected override: %+v", override)
+	}
+	if got := m.generationRequest().overrides["redis"].Image; got != "redis:7.2" {
+		t.Fatalf("expected generation request to carry the override, got %q", got)
+	}
+
+	// clearing every field removes the override
+	m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'e'}})
+	for i := range m.editServiceInputs {
+		m.editServiceInputs[i].SetValue("")
+	}
+	m.editServiceEnv.SetValue("")
+	m.handleEditServiceMsg(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.isServiceEdited("redis") {
+		t.Fatalf("expected override to be removed, got %+v", m.project.Overrides)
+	}
+}
+
+func TestEditService_InvalidEnvShowsError(t *testing.T) {
+	m, _ := makeModelWithCatalog(t)
+	m.step = stepCache
+
+	m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'e'}})
+	m.editServiceEnv.SetValue("MAXMEMORY")
+	m.handleEditServiceMsg(tea.KeyMsg{Type: tea.KeyEnter})
+
+	if m.step != stepEditService {
+		t.Fatalf("expected to stay on the form, got %v", m.step)
+	}
+	if m.editServiceFormError == "" {
+		t.Fatal("expected a form error for env without a value")
+	}
+}
+
+func TestEditService_InvalidPortShowsError(t *testing.T) {
+	m, _ := makeModelWithCatalog(t)
+	m.step = stepCache
+
+	m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'e'}})
+	m.editServiceInputs[1].SetValue("6379:redis")
+	m.handleEditServiceMsg(tea.KeyMsg{Type: tea.KeyEnter})
+
+	if m.step != stepEditService {
+		t.Fatalf("expected to stay on the form, got %v", m.step)
+	}
+	if !strings.Contains(m.editServiceFormError, "6379:redis") {
+		t.Fatalf("expected a form error naming the port, got %q", m.editServiceFormError)
+	}
+}
+
+func TestEditService_EnvVarsKeepCommas(t *testing.T) {
+	m, _ := makeModelWithCatalog(t)
+	m.step = stepCache
+
+	m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'e'}})
+	m.handleEditServiceMsg(tea.KeyMsg{Type: tea.KeyTab})
+	m.handleEditServiceMsg(tea.KeyMsg{Type: tea.KeyTab})
+	for _, r := range "REDIS_ARGS=--save 60,1" {
+		m.handleEditServiceMsg(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	m.handleEditServiceMsg(tea.KeyMsg{Type: tea.KeyEnter, Alt: true})
+	for _, r := range "HOSTS=a,b" {
+		m.handleEditServiceMsg(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	m.handleEditServiceMsg(tea.KeyMsg{Type: tea.KeyEnter})
+
+	if m.step != stepCache {
+		t.Fatalf("expected to return to stepCache, got %v (formError: %q)", m.step, m.editServiceFormError)
+	}
+	env := m.project.Overrides["redis"].Env
+	if len(env) != 2 || env[0] != "REDIS_ARGS=--save 60,1" || env[1] != "HOSTS=a,b" {
+		t.Fatalf("expected one variable per line, got %q", env)
+	}
+}
+
+func TestAppSettings_ReviewKeyOpensFormAndSaves(t *testing.T) {
+	m, root := makeModelWithCatalog(t)
+	m.step = stepReview
+	if err := os.WriteFile(filepath.Join(root, "config", "dockerfiles.json"), []byte(minimalDockerfileCatalogJSON), 0o644); err != nil {
+		t.Fatalf("write dockerfile catalog: %v", err)
+	}
+
+	m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+	if m.step != stepAppSettings {
+		t.Fatalf("expected stepAppSettings after a, got %v", m.step)
+	}
+
+	for _, r := range "web" {
+		m.handleAppSettingsMsg(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	m.handleAppSettingsMsg(tea.KeyMsg{Type: tea.KeyTab})
+	for _, r := range "3000" {
+		m.handleAppSettingsMsg(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	m.handleAppSettingsMsg(tea.KeyMsg{Type: tea.KeyEnter})
+
+	if m.step != stepReview {
+		t.Fatalf("expected to return to stepReview, got %v (formError: %q)", m.step, m.appSettingsFormError)
+	}
+	if got := m.generationRequest().app; got.Name != "web" || got.Port != "3000" {
+		t.Fatalf("unexpected app settings: %+v", got)
+	}
+}
+
+func TestAppSettings_InvalidPortShowsError(t *testing.T) {
+	m, _ := makeModelWithCatalog(t)
+	m.step = stepReview
+
+	m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+	m.appSettingsInputs[1].SetValue("http")
+	m.handleAppSettingsMsg(tea.KeyMsg{Type: tea.KeyEnter})
+
+	if m.step != stepAppSettings || m.appSettingsFormError == "" {
+		t.Fatalf("expected form error, got step %v error %q", m.step, m.appSettingsFormError)
+	}
+	if m.project.App.Port != "" {
+		t.Fatalf("expected app settings to be unchanged, got %+v", m.project.App)
+	}
+}
+
+func TestProfiles_ReviewKeyStoresProfilesAndKeepsOverrides(t *testing.T) {
+	m, root := makeModelWithCatalog(t)
+	m.step = stepReview
+	m.selected["redis"] = true
+	m.project.Overrides = map[string]generator.ServiceOverride{"redis": {Image: "redis:7.2"}}
+	if err := os.WriteFile(filepath.Join(root, "config", "dockerfiles.json"), []byte(minimalDockerfileCatalogJSON), 0o644); err != nil {
+		t.Fatalf("write dockerfile catalog: %v", err)
+	}
+
+	m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'o'}})
+	if m.step != stepProfiles || len(m.profilesInputs) != 1 {
+		t.Fatalf("expected profiles form for redis, got step %v with %d inputs", m.step, len(m.profilesInputs))
+	}
+
+	m.profilesInputs[0].SetValue("bad name")
+	m.handleProfilesMsg(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.step != stepProfiles || m.profilesFormError == "" {
+		t.Fatalf("expected form error for an invalid profile, got step %v error %q", m.step, m.profilesFormError)
+	}
+
+	m.profilesInputs[0].SetValue("debug")
+	m.handleProfilesMsg(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.step != stepReview {
+		t.Fatalf("expected to return to stepReview, got %v (formError: %q)", m.step, m.profilesFormError)
+	}
+	override := m.generationRequest().overrides["redis"]
+	if override.Image != "redis:7.2" || len(override.Profiles) != 1 || override.Profiles[0] != "debug" {
+		t.Fatalf("unexpected override: %+v", override)
+	}
+	if got := m.selectedByCategory()["cache"]; len(got) != 1 || got[0] != "Redis [debug]" {
+		t.Fatalf("expected the review to show the profile, got %v", got)
+	}
+}
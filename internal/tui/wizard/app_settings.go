@@ -0,0 +1,130 @@
+package wizard
+
+import (
+	"strings"
+
+	"docker-wizard/internal/generator"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// appSettingsFieldCount is the number of fields in the app service form:
+// service name, port, start command, env vars, build target and platforms.
+const appSettingsFieldCount = 6
+
+func initAppSettingsInputs() [appSettingsFieldCount]textinput.Model {
+	placeholders := [appSettingsFieldCount]string{
+		"app",
+		"8080 or 3000:8080",
+		"template default",
+		"e.g. FOO=bar,BAR=baz",
+		"final stage",
+		"builder default, e.g. linux/amd64,linux/arm64",
+	}
+	var inputs [appSettingsFieldCount]textinput.Model
+	for i := range inputs {
+		inputs[i] = textinput.New()
+		inputs[i].Placeholder = placeholders[i]
+	}
+	return inputs
+}
+
+// appSettings returns the app settings for generation: the configured ones,
+// with the detected port unless a port is set.
+func (m model) appSettings() generator.AppSettings {
+	app := m.project.App
+	if app.Port == "" {
+		app.Port = m.effectiveDetails().AppPort
+	}
+	return app
+}
+
+// openAppSettings opens the app service form filled with the current
+// settings.
+func (m *model) openAppSettings() {
+	app := m.project.App
+	m.appSettingsFocusedField = 0
+	m.appSettingsFormError = ""
+	m.appSettingsInputs = initAppSettingsInputs()
+	if port := m.effectiveDetails().AppPort; port != "" {
+		m.appSettingsInputs[1].Placeholder = port + " (detected)"
+	}
+	m.appSettingsInputs[0].SetValue(app.Name)
+	m.appSettingsInputs[1].SetValue(app.Port)
+	m.appSettingsInputs[2].SetValue(app.Command)
+	m.appSettingsInputs[3].SetValue(strings.Join(app.Env, ","))
+	m.appSettingsInputs[4].SetValue(app.Target)
+	m.appSettingsInputs[5].SetValue(strings.Join(app.Platforms, ","))
+	m.syncAppSettingsFocus()
+
+	m.previousStep = m.step
+	m.step = stepAppSettings
+	m.animateHeader()
+}
+
+func (m *model) syncAppSettingsFocus() {
+	for i := range m.appSettingsInputs {
+		m.appSettingsInputs[i].Blur()
+	}
+	m.appSettingsInputs[m.appSettingsFocusedField].Focus()
+}
+
+// handleAppSettingsMsg handles all messages for stepAppSettings. Only ctrl+c
+// quits so that commands and env values can contain any letter.
+func (m *model) handleAppSettingsMsg(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "ctrl+c":
+		return tea.Quit
+	case "esc":
+		m.step = m.previousStep
+		m.animateHeader()
+		return nil
+	case "tab", "down":
+		m.appSettingsFocusedField = (m.appSettingsFocusedField + 1) % appSettingsFieldCount
+		m.syncAppSettingsFocus()
+		return nil
+	case "shift+tab", "up":
+		m.appSettingsFocusedField = (m.appSettingsFocusedField + appSettingsFieldCount - 1) % appSettingsFieldCount
+		m.syncAppSettingsFocus()
+		return nil
+	case "enter":
+		m.confirmAppSettings()
+		return nil
+	}
+
+	var cmd tea.Cmd
+	idx := m.appSettingsFocusedField
+	m.appSettingsInputs[idx], cmd = m.appSettingsInputs[idx].Update(msg)
+	return cmd
+}
+
+// confirmAppSettings applies the form and refreshes the review. Settings the
+// generators reject stay in the form with the error.
+func (m *model) confirmAppSettings() {
+	app := generator.AppSettings{
+		Name:      strings.TrimSpace(m.appSettingsInputs[0].Value()),
+		Port:      strings.TrimSpace(m.appSettingsInputs[1].Value()),
+		Command:   strings.TrimSpace(m.appSettingsInputs[2].Value()),
+		Env:       splitCommaValues(m.appSettingsInputs[3].Value()),
+		Target:    strings.TrimSpace(m.appSettingsInputs[4].Value()),
+		Platforms: splitCommaValues(m.appSettingsInputs[5].Value()),
+	}
+	if err := app.Validate(); err != nil {
+		m.appSettingsFormError = err.Error()
+		return
+	}
+
+	previous := m.project.App
+	m.project.App = app
+	if m.previousStep == stepReview {
+		if err := m.prepareReview(); err != nil {
+			m.project.App = previous
+			m.appSettingsFormError = err.Error()
+			return
+		}
+	}
+
+	m.step = m.previousStep
+	m.animateHeader()
+}
@@ -0,0 +1,99 @@
+package wizard
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"docker-wizard/internal/generator"
+	"docker-wizard/internal/tui/wizard/ui"
+)
+
+// includedApps returns the detected apps of a monorepo that are not
+// excluded; none for a single-app project.
+func (m model) includedApps() []generator.App {
+	return generator.IncludedApps(m.apps, m.project.ExcludeApps)
+}
+
+func (m model) isAppExcluded(name string) bool {
+	for _, excluded := range m.project.ExcludeApps {
+		if excluded == name {
+			return true
+		}
+	}
+	return false
+}
+
+// toggleCurrentApp excludes the app under the cursor or includes it again.
+// The last included app cannot be excluded.
+func (m *model) toggleCurrentApp() {
+	if m.appCursor < 0 || m.appCursor >= len(m.apps) {
+		return
+	}
+	name := m.apps[m.appCursor].Name
+	if !m.isAppExcluded(name) {
+		if len(m.includedApps()) == 1 {
+			return
+		}
+		m.project.ExcludeApps = append(m.project.ExcludeApps, name)
+		sort.Strings(m.project.ExcludeApps)
+		return
+	}
+	excluded := make([]string, 0, len(m.project.ExcludeApps))
+	for _, other := range m.project.ExcludeApps {
+		if other != name {
+			excluded = append(excluded, other)
+		}
+	}
+	m.project.ExcludeApps = excluded
+}
+
+// appOptions lists the detected apps for the detect step, selected when
+// included.
+func (m model) appOptions() []ui.OptionItem {
+	options := make([]ui.OptionItem, 0, len(m.apps))
+	for i, app := range m.apps {
+		description := app.Dir + " · " + languageLabelWithVersion(app.Details)
+		if app.Details.AppPort != "" {
+			description += " · port " + app.Details.AppPort
+		}
+		options = append(options, ui.OptionItem{
+			Label:       app.Name,
+			Description: description,
+			Active:      i == m.appCursor,
+			Selected:    !m.isAppExcluded(app.Name),
+		})
+	}
+	return options
+}
+
+// appsLanguageLabel describes the languages of the apps, for example
+// "web (Node 20), api (Go 1.22)".
+func appsLanguageLabel(apps []generator.App) string {
+	labels := make([]string, 0, len(apps))
+	for _, app := range apps {
+		labels = append(labels, app.Name+" ("+languageLabelWithVersion(app.Details)+")")
+	}
+	return strings.Join(labels, ", ")
+}
+
+// appFileName names a managed file of an app relative to the project root.
+func appFileName(dir string, name string) string {
+	return generator.AppFileName(dir, name)
+}
+
+// appTabShortName shortens the name of an app file for the preview tab bar,
+// for example "web/dockerfile" for apps/web/Dockerfile.
+func appTabShortName(name string) string {
+	dir, file := path.Split(name)
+	return path.Base(dir) + "/" + previewTabShortName(file)
+}
+
+// languageLabel describes the language of the project, or of each included
+// app of a monorepo.
+func (m model) languageLabel() string {
+	if len(m.apps) > 0 {
+		return appsLanguageLabel(m.includedApps())
+	}
+	return languageLabelWithVersion(m.effectiveDetails())
+}
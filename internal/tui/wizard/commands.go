@@ -14,10 +14,14 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 )
 
-func detectCmd(root string) tea.Cmd {
+func detectCmd(root string, workspaces []string) tea.Cmd {
 	return func() tea.Msg {
 		details, err := generator.DetectLanguage(root)
-		return detectDoneMsg{details: details, err: err}
+		if err != nil {
+			return detectDoneMsg{err: err}
+		}
+		apps, err := generator.DetectApps(root, workspaces)
+		return detectDoneMsg{details: details, apps: apps, err: err}
 	}
 }
 
@@ -27,45 +31,126 @@ func resolveLanguage(root string, overrideLang bool, overrideType generator.Lang
 		return generator.LanguageDetails{}, err
 	}
 	if overrideLang {
-		details.Type = overrideType
+		details = generator.OverrideLanguage(root, details, overrideType)
 	}
 	return details, nil
 }
 
-func previewCmd(root string, services []string, overrideLang bool, overrideType generator.Language) tea.Cmd {
+// generationRequest carries the wizard choices the generators need.
+type generationRequest struct {
+	services     []string
+	overrideLang bool
+	overrideType generator.Language
+	app          generator.AppSettings
+	overrides    map[string]generator.ServiceOverride
+	// splitOverride writes dev settings to docker-compose.override.yml
+	splitOverride bool
+	// apps are the included apps of a monorepo, built instead of the root
+	apps []generator.App
+}
+
+func (m model) generationRequest() generationRequest {
+	return generationRequest{
+		services:      selectedServiceIDs(m.services, m.selected),
+		overrideLang:  m.overrideLang,
+		overrideType:  m.overrideType,
+		app:           m.appSettings(),
+		overrides:     m.project.Overrides,
+		splitOverride: m.project.ComposeOverride,
+		apps:          m.includedApps(),
+	}
+}
+
+func (r generationRequest) selection() generator.ComposeSelection {
+	return generator.ComposeSelection{Services: r.services, App: r.app, Overrides: r.overrides, SplitOverride: r.splitOverride}
+}
+
+// renderedFiles is the generated content of the managed files. override is
+// empty unless the compose output is split. A monorepo has apps instead of
+// dockerfile. selection is the compose selection the files were built from.
+type renderedFiles struct {
+	compose    string
+	override   string
+	dockerfile string
+	apps       []generator.AppFile
+	selection  generator.ComposeSelection
+}
+
+// render generates the compose files and the Dockerfiles for the request.
+func (r generationRequest) render(root string) (renderedFiles, error) {
+	if len(r.apps) > 0 {
+		return r.renderApps(root)
+	}
+	details, err := resolveLanguage(root, r.overrideLang, r.overrideType)
+	if err != nil {
+		return renderedFiles{}, err
+	}
+	dockerfile, err := generator.DockerfileWithApp(root, details, r.app)
+	if err != nil {
+		return renderedFiles{}, err
+	}
+	selection := r.selection()
+	if selection.Watch, err = generator.WatchRules(root, details); err != nil {
+		return renderedFiles{}, err
+	}
+	if selection.DevTarget, err = generator.MergedDevTarget(root, "", dockerfile); err != nil {
+		return renderedFiles{}, err
+	}
+	compose, override, err := generator.ComposeFiles(root, selection)
+	if err != nil {
+		return renderedFiles{}, err
+	}
+	return renderedFiles{compose: compose, override: override, dockerfile: dockerfile, selection: selection}, nil
+}
+
+// renderApps generates the compose files and a Dockerfile per app of a
+// monorepo.
+func (r generationRequest) renderApps(root string) (renderedFiles, error) {
+	selection := r.selection()
+	apps, services, err := generator.RenderApps(root, r.apps, r.app)
+	if err != nil {
+		return renderedFiles{}, err
+	}
+	selection.Apps = services
+	compose, override, err := generator.ComposeFiles(root, selection)
+	if err != nil {
+		return renderedFiles{}, err
+	}
+	return renderedFiles{compose: compose, override: override, apps: apps, selection: selection}, nil
+}
+
+func (f renderedFiles) preview(root string) (generator.Preview, error) {
+	if len(f.apps) > 0 {
+		return generator.PreviewAppFiles(root, f.compose, f.override, f.apps)
+	}
+	return generator.PreviewFilesWithComposeOverride(root, f.compose, f.override, f.dockerfile)
+}
+
+func (f renderedFiles) write(root string) (generator.Output, error) {
+	if len(f.apps) > 0 {
+		return generator.WriteAppFiles(root, f.compose, f.override, f.apps)
+	}
+	return generator.WriteFilesWithComposeOverride(root, f.compose, f.override, f.dockerfile)
+}
+
+func previewCmd(root string, request generationRequest) tea.Cmd {
 	return func() tea.Msg {
-		details, err := resolveLanguage(root, overrideLang, overrideType)
-		if err != nil {
-			return previewDoneMsg{err: err}
-		}
-		dockerfile, err := generator.Dockerfile(root, details)
-		if err != nil {
-			return previewDoneMsg{err: err}
-		}
-		compose, err := generator.Compose(root, generator.ComposeSelection{Services: services})
+		files, err := request.render(root)
 		if err != nil {
 			return previewDoneMsg{err: err}
 		}
-		preview, err := generator.PreviewFiles(root, compose, dockerfile)
+		preview, err := files.preview(root)
 		return previewDoneMsg{preview: preview, err: err}
 	}
 }
 
-func generateCmd(root string, services []string, overrideLang bool, overrideType generator.Language) tea.Cmd {
+func generateCmd(root string, request generationRequest) tea.Cmd {
 	return func() tea.Msg {
-		details, err := resolveLanguage(root, overrideLang, overrideType)
+		files, err := request.render(root)
 		if err != nil {
 			return generateDoneMsg{err: err}
 		}
-		dockerfile, err := generator.Dockerfile(root, details)
-		if err != nil {
-			return generateDoneMsg{err: err}
-		}
-		compose, err := generator.Compose(root, generator.ComposeSelection{Services: services})
-		if err != nil {
-			return generateDoneMsg{err: err}
-		}
-		output, err := generator.WriteFiles(root, compose, dockerfile)
+		output, err := files.write(root)
 		return generateDoneMsg{output: output, err: err}
 	}
 }
@@ -76,29 +161,30 @@ func tickCmd() tea.Cmd {
 	})
 }
 
-func (m *model) prepareReview() error {
-	selection := generator.ComposeSelection{Services: selectedServiceIDs(m.services, m.selected)}
-	warnings, err := generator.SelectionWarnings(m.root, selection)
-	if err != nil {
-		return err
-	}
-
-	details, err := resolveLanguage(m.root, m.overrideLang, m.overrideType)
-	if err != nil {
-		return err
+// toggleComposeOverride switches between a single compose file and one with
+// the local development settings split into docker-compose.override.yml.
+func (m *model) toggleComposeOverride() {
+	m.project.ComposeOverride = !m.project.ComposeOverride
+	if err := m.prepareReview(); err != nil {
+		m.err = err
+		m.previousStep = stepReview
+		m.step = stepError
+		m.animateHeader()
 	}
+}
 
-	dockerfile, err := generator.Dockerfile(m.root, details)
+func (m *model) prepareReview() error {
+	files, err := m.generationRequest().render(m.root)
 	if err != nil {
 		return err
 	}
 
-	compose, err := generator.Compose(m.root, selection)
+	warnings, err := generator.SelectionWarnings(m.root, files.selection)
 	if err != nil {
 		return err
 	}
 
-	preview, err := generator.PreviewFiles(m.root, compose, dockerfile)
+	preview, err := files.preview(m.root)
 	if err != nil {
 		return err
 	}
@@ -106,14 +192,37 @@ func (m *model) prepareReview() error {
 	if preview.Compose.Status == generator.FileStatusDifferent {
 		warnings = append(warnings, "docker-compose.yml differs from generated output and will be merged (backup: docker-compose.yml.bak)")
 	}
+	if preview.ComposeOverride.Status == generator.FileStatusDifferent {
+		warnings = append(warnings, "docker-compose.override.yml differs from generated output and will be merged (backup: docker-compose.override.yml.bak)")
+	}
 	if preview.Dockerfile.Status == generator.FileStatusDifferent {
 		warnings = append(warnings, "Dockerfile differs from generated output and will be merged (backup: Dockerfile.bak)")
 	}
+	m.appFiles = nil
+	for _, app := range preview.Apps {
+		name := appFileName(app.Dir, generator.DockerfileFileName)
+		if app.Dockerfile.Status == generator.FileStatusDifferent {
+			warnings = append(warnings, name+" differs from generated output and will be merged (backup: "+name+".bak)")
+		}
+		m.appFiles = append(m.appFiles, name)
+		if app.Dockerignore.Status == generator.FileStatusNew {
+			m.appFiles = append(m.appFiles, appFileName(app.Dir, generator.DockerignoreFileName))
+		}
+	}
+	warnings = append(warnings, preview.ConflictWarnings()...)
 
 	sort.Strings(warnings)
 	m.warnings = warnings
 	m.blockers = nil
 	m.createDockerignore = preview.Dockerignore.Status == generator.FileStatusNew
+	m.manageEnv = preview.Env.Path != ""
+	m.manageOverride = preview.ComposeOverride.Path != ""
+	m.secretFiles = nil
+	for _, secret := range preview.Secrets {
+		if secret.Status == generator.FileStatusNew {
+			m.secretFiles = append(m.secretFiles, secretFileName(secret.Path))
+		}
+	}
 	m.previewReady = false
 	m.preview = generator.Preview{}
 	m.previewContent = ""
@@ -173,6 +282,11 @@ func baseName(path string) string {
 	return filepath.Base(path)
 }
 
+// secretFileName names a secret file relative to the project root.
+func secretFileName(path string) string {
+	return filepath.Join(generator.SecretsDirName, filepath.Base(path))
+}
+
 func outputLine(path string, status generator.WriteStatus) string {
 	name := baseName(path)
 	if name == "" {
@@ -0,0 +1,196 @@
+package wizard
+
+import (
+	"strings"
+
+	"docker-wizard/internal/generator"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// editServiceFieldCount is the number of fields in the edit form: image,
+// ports and env vars. The env vars are the last field, a text area with one
+// variable per line, since values may contain commas.
+const (
+	editServiceFieldCount = 3
+	editServiceEnvField   = editServiceFieldCount - 1
+)
+
+func initEditServiceInputs() [editServiceEnvField]textinput.Model {
+	var inputs [editServiceEnvField]textinput.Model
+	for i := range inputs {
+		inputs[i] = textinput.New()
+	}
+	return inputs
+}
+
+// initEditServiceEnv returns the env vars text area. Enter saves the form as
+// in every other field, so alt+enter or ctrl+j starts a new line.
+func initEditServiceEnv() textarea.Model {
+	env := textarea.New()
+	env.Prompt = ""
+	env.ShowLineNumbers = false
+	env.SetWidth(48)
+	env.SetHeight(3)
+	env.KeyMap.InsertNewline = key.NewBinding(key.WithKeys("alt+enter", "ctrl+j"))
+	return env
+}
+
+// openEditService opens the override form for the service under the cursor.
+// The inputs hold the current overrides; the placeholders show the catalog
+// values they replace.
+func (m *model) openEditService() {
+	services := m.filteredServices(m.step)
+	if m.cursor < 0 || m.cursor >= len(services) {
+		return
+	}
+	svc := services[m.cursor]
+	override := m.project.Overrides[svc.ID]
+
+	m.editServiceID = svc.ID
+	m.editServiceFocusedField = 0
+	m.editServiceFormError = ""
+	m.editServiceInputs = initEditServiceInputs()
+	m.editServiceInputs[0].Placeholder = placeholderValue(svc.Image)
+	m.editServiceInputs[0].SetValue(override.Image)
+	m.editServiceInputs[1].Placeholder = placeholderValue(strings.Join(svc.Ports, ","))
+	m.editServiceInputs[1].SetValue(strings.Join(override.Ports, ","))
+	m.editServiceEnv = initEditServiceEnv()
+	m.editServiceEnv.Placeholder = placeholderValue(strings.Join(svc.Env, "\n"))
+	m.editServiceEnv.SetValue(strings.Join(override.Env, "\n"))
+	m.syncEditServiceFocus()
+
+	m.previousStep = m.step
+	m.step = stepEditService
+	m.animateHeader()
+}
+
+func placeholderValue(value string) string {
+	if value == "" {
+		return "catalog default"
+	}
+	return value
+}
+
+func (m *model) syncEditServiceFocus() {
+	for i := range m.editServiceInputs {
+		m.editServiceInputs[i].Blur()
+	}
+	m.editServiceEnv.Blur()
+	if m.editServiceFocusedField == editServiceEnvField {
+		m.editServiceEnv.Focus()
+		return
+	}
+	m.editServiceInputs[m.editServiceFocusedField].Focus()
+}
+
+// handleEditServiceMsg handles all messages for stepEditService. Only ctrl+c
+// quits so that image names and env values can contain any letter.
+func (m *model) handleEditServiceMsg(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "ctrl+c":
+		return tea.Quit
+	case "esc":
+		m.step = m.previousStep
+		m.animateHeader()
+		return nil
+	case "tab", "down":
+		if msg.String() == "down" && m.editServiceFocusedField == editServiceEnvField && m.editServiceEnv.Line() < m.editServiceEnv.LineCount()-1 {
+			break
+		}
+		m.editServiceFocusedField = (m.editServiceFocusedField + 1) % editServiceFieldCount
+		m.syncEditServiceFocus()
+		return nil
+	case "shift+tab", "up":
+		if msg.String() == "up" && m.editServiceFocusedField == editServiceEnvField && m.editServiceEnv.Line() > 0 {
+			break
+		}
+		m.editServiceFocusedField = (m.editServiceFocusedField + editServiceFieldCount - 1) % editServiceFieldCount
+		m.syncEditServiceFocus()
+		return nil
+	case "enter":
+		m.confirmEditService()
+		return nil
+	}
+
+	var cmd tea.Cmd
+	idx := m.editServiceFocusedField
+	if idx == editServiceEnvField {
+		// up and down move between the lines of the env vars first
+		m.editServiceEnv, cmd = m.editServiceEnv.Update(msg)
+		return cmd
+	}
+	m.editServiceInputs[idx], cmd = m.editServiceInputs[idx].Update(msg)
+	return cmd
+}
+
+// confirmEditService stores the form as the service's override. Clearing
+// every field removes the override; profiles set in the review step are kept.
+func (m *model) confirmEditService() {
+	override := generator.ServiceOverride{
+		Image: strings.TrimSpace(m.editServiceInputs[0].Value()),
+		Ports: splitCommaValues(m.editServiceInputs[1].Value()),
+		Env:   splitLineValues(m.editServiceEnv.Value()),
+		// set from the review step
+		Profiles: m.project.Overrides[m.editServiceID].Profiles,
+	}
+	if err := generator.ValidatePorts(override.Ports); err != nil {
+		m.editServiceFormError = err.Error()
+		return
+	}
+	for _, entry := range override.Env {
+		if !strings.Contains(entry, "=") {
+			m.editServiceFormError = "Env vars must be KEY=value pairs"
+			return
+		}
+	}
+
+	overrides := make(map[string]generator.ServiceOverride, len(m.project.Overrides)+1)
+	for id, existing := range m.project.Overrides {
+		overrides[id] = existing
+	}
+	if isEmptyOverride(override) {
+		delete(overrides, m.editServiceID)
+	} else {
+		overrides[m.editServiceID] = override
+	}
+	if len(overrides) == 0 {
+		overrides = nil
+	}
+	m.project.Overrides = overrides
+
+	m.step = m.previousStep
+	m.animateHeader()
+}
+
+// splitLineValues splits a text area into its non-empty, trimmed lines.
+func splitLineValues(s string) []string {
+	var result []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			result = append(result, line)
+		}
+	}
+	return result
+}
+
+func isEmptyOverride(override generator.ServiceOverride) bool {
+	return override.Image == "" && len(override.Ports) == 0 && len(override.Env) == 0 && len(override.Profiles) == 0
+}
+
+func (m model) isServiceEdited(id string) bool {
+	_, ok := m.project.Overrides[id]
+	return ok
+}
+
+func (m model) editServiceLabel() string {
+	for _, svc := range m.services {
+		if svc.ID == m.editServiceID {
+			return svc.Label
+		}
+	}
+	return m.editServiceID
+}
@@ -1,14 +1,18 @@
 package wizard
 
-import "docker-wizard/internal/generator"
+import (
+	"strings"
 
-import tea "github.com/charmbracelet/bubbletea"
+	"docker-wizard/internal/generator"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
 
 func (m *model) retryFromError() tea.Cmd {
 	if m.previousStep == stepDetect {
 		m.step = stepDetect
 		m.animateHeader()
-		return detectCmd(m.root)
+		return detectCmd(m.root, m.project.Workspaces)
 	}
 	if m.previousStep == stepProxy {
 		if err := m.prepareReview(); err != nil {
@@ -24,12 +28,12 @@ func (m *model) retryFromError() tea.Cmd {
 	if m.previousStep == stepGenerate {
 		m.step = stepGenerate
 		m.animateHeader()
-		return generateCmd(m.root, selectedServiceIDs(m.services, m.selected), m.overrideLang, m.overrideType)
+		return generateCmd(m.root, m.generationRequest())
 	}
 	if m.previousStep == stepPreview {
 		m.step = stepPreview
 		m.animateHeader()
-		return previewCmd(m.root, selectedServiceIDs(m.services, m.selected), m.overrideLang, m.overrideType)
+		return previewCmd(m.root, m.generationRequest())
 	}
 	return nil
 }
@@ -60,9 +64,19 @@ func (m model) effectiveDetails() generator.LanguageDetails {
 	}
 	updated := m.langDetails
 	updated.Type = m.overrideType
+	updated.AppPort = m.overridePort
 	return updated
 }
 
+// detectOverridePort detects the app port again for the overridden language,
+// since the port is read from the sources of the language.
+func (m *model) detectOverridePort() {
+	m.overridePort = ""
+	if m.overrideLang {
+		m.overridePort = generator.OverrideLanguage(m.root, m.langDetails, m.overrideType).AppPort
+	}
+}
+
 func (m *model) applyLanguageChoice() {
 	if m.langCursor < 0 || m.langCursor >= len(m.langOptions) {
 		return
@@ -75,6 +89,7 @@ func (m *model) applyLanguageChoice() {
 	}
 	m.overrideLang = true
 	m.overrideType = choice.Language
+	m.detectOverridePort()
 }
 
 func (m model) isLanguageSelected(option languageChoice) bool {
@@ -86,3 +101,39 @@ func (m model) isLanguageSelected(option languageChoice) bool {
 	}
 	return m.overrideType == option.Language
 }
+
+// applyProjectConfig preselects the services and language declared in the
+// project config file.
+func (m *model) applyProjectConfig(config generator.ProjectConfig) {
+	m.project = config
+	for _, id := range config.Services {
+		m.selected[id] = true
+	}
+	language := strings.ToLower(config.Language)
+	for i, option := range m.langOptions {
+		if option.ID != "auto" && option.ID == language {
+			m.langCursor = i
+			m.overrideLang = true
+			m.overrideType = option.Language
+		}
+	}
+}
+
+// saveProjectConfig writes the current selection to the project config file,
+// together with the app settings and the service overrides, including the
+// ones edited in the wizard.
+func (m *model) saveProjectConfig() {
+	config := m.project
+	config.Services = selectedServiceIDs(m.services, m.selected)
+	config.Language = ""
+	if m.overrideLang {
+		config.Language = string(m.overrideType)
+	}
+	path, err := generator.SaveProjectConfig(m.root, config)
+	if err != nil {
+		m.projectNote = "could not save selection: " + err.Error()
+		return
+	}
+	m.project = config
+	m.projectNote = "selection saved to " + baseName(path)
+}
@@ -32,8 +32,9 @@ func (m *model) handleKey(msg tea.KeyMsg) tea.Cmd {
 		return m.handleResultKey(key)
 	case stepError:
 		return m.handleErrorKey(key)
-	case stepAddService:
-		// handled via handleAddServiceMsg in update.go; should not reach here
+	case stepAddService, stepEditService, stepAppSettings, stepProfiles:
+		// handled via the form message handlers in update.go; should not
+		// reach here
 	}
 
 	return nil
@@ -45,7 +46,7 @@ func (m *model) handleWelcomeKey(key string) tea.Cmd {
 	}
 	m.step = stepDetect
 	m.animateHeader()
-	return detectCmd(m.root)
+	return detectCmd(m.root, m.project.Workspaces)
 }
 
 func (m *model) handleDetectKey(key string) tea.Cmd {
@@ -53,6 +54,27 @@ func (m *model) handleDetectKey(key string) tea.Cmd {
 		return nil
 	}
 
+	if len(m.apps) > 0 {
+		// a monorepo lists its apps; each uses its detected language
+		switch key {
+		case "up", "k":
+			if m.appCursor > 0 {
+				m.appCursor--
+			}
+			return nil
+		case "down", "j":
+			if m.appCursor < len(m.apps)-1 {
+				m.appCursor++
+			}
+			return nil
+		case " ":
+			m.toggleCurrentApp()
+			return nil
+		case "l":
+			return nil
+		}
+	}
+
 	switch key {
 	case "enter":
 		m.step = stepDatabase
@@ -114,6 +136,8 @@ func (m *model) handleServiceStepKey(key string) tea.Cmd {
 		m.resetAddServiceForm()
 		m.step = stepAddService
 		m.animateHeader()
+	case "e":
+		m.openEditService()
 	case "enter":
 		if m.step == stepProxy {
 			if err := m.prepareReview(); err != nil {
@@ -144,7 +168,7 @@ func (m *model) handleReviewKey(key string) tea.Cmd {
 		}
 		m.step = stepGenerate
 		m.animateHeader()
-		return generateCmd(m.root, selectedServiceIDs(m.services, m.selected), m.overrideLang, m.overrideType)
+		return generateCmd(m.root, m.generationRequest())
 	case "p":
 		m.previewReady = false
 		m.preview = generator.Preview{}
@@ -153,7 +177,13 @@ func (m *model) handleReviewKey(key string) tea.Cmd {
 		m.setPreviewViewportContent("")
 		m.step = stepPreview
 		m.animateHeader()
-		return previewCmd(m.root, selectedServiceIDs(m.services, m.selected), m.overrideLang, m.overrideType)
+		return previewCmd(m.root, m.generationRequest())
+	case "a":
+		m.openAppSettings()
+	case "o":
+		m.openProfiles()
+	case "c":
+		m.toggleComposeOverride()
 	case "b":
 		m.step = stepProxy
 		m.animateHeader()
@@ -167,8 +197,11 @@ func (m *model) handleGenerateKey(_ string) tea.Cmd {
 }
 
 func (m *model) handleResultKey(key string) tea.Cmd {
-	if key == "enter" {
+	switch key {
+	case "enter":
 		return tea.Quit
+	case "s":
+		m.saveProjectConfig()
 	}
 	return nil
 }
@@ -6,6 +6,7 @@ import (
 	"docker-wizard/internal/generator"
 
 	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	"github.com/charmbracelet/harmonica"
@@ -28,6 +29,9 @@ const (
 	stepResult
 	stepError
 	stepAddService
+	stepEditService
+	stepAppSettings
+	stepProfiles
 )
 
 const totalSteps = 11
@@ -36,6 +40,7 @@ type tickMsg time.Time
 
 type detectDoneMsg struct {
 	details generator.LanguageDetails
+	apps    []generator.App
 	err     error
 }
 
@@ -55,6 +60,12 @@ type serviceChoice struct {
 	Selected    bool
 	Description string
 	Category    string
+
+	// catalog values, shown as placeholders when editing overrides
+	Image    string
+	Ports    []string
+	Env      []string
+	Profiles []string
 }
 
 type languageChoice struct {
@@ -81,18 +92,30 @@ type model struct {
 	langCursor   int
 	overrideLang bool
 	overrideType generator.Language
+	// overridePort is the app port detected for overrideType
+	overridePort string
 	detectDone   bool
 	langVisited  bool
 
+	// apps of a monorepo, empty for a single app; the excluded ones are
+	// kept in project.ExcludeApps
+	apps      []generator.App
+	appCursor int
+
 	services           []serviceChoice
 	cursor             int
 	selected           map[string]bool
 	warnings           []string
 	blockers           []string
 	createDockerignore bool
+	manageEnv          bool
+	manageOverride     bool
+	secretFiles        []string
+	appFiles           []string
 	previewContent     string
 	previewViewport    viewport.Model
 	previewTab         int
+	previewDiff        bool
 	frame              int
 
 	output       generator.Output
@@ -100,6 +123,11 @@ type model struct {
 	previewReady bool
 	err          error
 
+	// project config loaded at start; the result step can save the
+	// selection back to it
+	project     generator.ProjectConfig
+	projectNote string
+
 	headerSpring harmonica.Spring
 	headerPos    float64
 	headerVel    float64
@@ -111,4 +139,23 @@ type model struct {
 	addServiceInputs       [5]textinput.Model // name, image, ports, env vars, volume mounts
 	addServiceCategoryIdx  int
 	addServiceFormError    string
+
+	// edit-service form state; the result is kept in project.Overrides
+	editServiceID           string
+	editServiceFocusedField int
+	editServiceInputs       [editServiceEnvField]textinput.Model
+	editServiceEnv          textarea.Model
+	editServiceFormError    string
+
+	// app-settings form state; the result is kept in project.App
+	appSettingsFocusedField int
+	appSettingsInputs       [appSettingsFieldCount]textinput.Model
+	appSettingsFormError    string
+
+	// profiles form state, one input per selected service; the result is
+	// kept in project.Overrides
+	profilesIDs          []string
+	profilesFocusedField int
+	profilesInputs       []textinput.Model
+	profilesFormError    string
 }
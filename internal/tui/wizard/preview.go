@@ -2,6 +2,7 @@ package wizard
 
 import (
 	"docker-wizard/internal/generator"
+	"docker-wizard/internal/tui/wizard/ui"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -32,6 +33,10 @@ func (m *model) handlePreviewKey(msg tea.KeyMsg, key string) tea.Cmd {
 	case "3":
 		m.setPreviewTab(2)
 		return nil
+	case "d":
+		m.previewDiff = !m.previewDiff
+		m.refreshPreviewTabContent()
+		return nil
 	case "home":
 		m.previewViewport.GotoTop()
 		return nil
@@ -44,7 +49,7 @@ func (m *model) handlePreviewKey(msg tea.KeyMsg, key string) tea.Cmd {
 		}
 		m.step = stepGenerate
 		m.animateHeader()
-		return generateCmd(m.root, selectedServiceIDs(m.services, m.selected), m.overrideLang, m.overrideType)
+		return generateCmd(m.root, m.generationRequest())
 	}
 
 	var cmd tea.Cmd
@@ -57,12 +62,29 @@ type previewTabItem struct {
 	File generator.FilePreview
 }
 
+// previewTabItems lists the previewed files. .env has no tab so that its
+// secrets stay off screen; .env.example shows the same variables.
 func (m model) previewTabItems() []previewTabItem {
-	return []previewTabItem{
-		{Name: generator.ComposeFileName, File: m.preview.Compose},
-		{Name: generator.DockerfileFileName, File: m.preview.Dockerfile},
-		{Name: generator.DockerignoreFileName, File: m.preview.Dockerignore},
-	}
+	items := []previewTabItem{{Name: generator.ComposeFileName, File: m.preview.Compose}}
+	if len(m.preview.Apps) == 0 {
+		items = append(items,
+			previewTabItem{Name: generator.DockerfileFileName, File: m.preview.Dockerfile},
+			previewTabItem{Name: generator.DockerignoreFileName, File: m.preview.Dockerignore},
+		)
+	}
+	for _, app := range m.preview.Apps {
+		items = append(items,
+			previewTabItem{Name: appFileName(app.Dir, generator.DockerfileFileName), File: app.Dockerfile},
+			previewTabItem{Name: appFileName(app.Dir, generator.DockerignoreFileName), File: app.Dockerignore},
+		)
+	}
+	if m.preview.EnvExample.Path != "" {
+		items = append(items, previewTabItem{Name: generator.EnvExampleFileName, File: m.preview.EnvExample})
+	}
+	if m.preview.ComposeOverride.Path != "" {
+		items = append(items, previewTabItem{Name: generator.ComposeOverrideFileName, File: m.preview.ComposeOverride})
+	}
+	return items
 }
 
 func (m model) activePreviewTab() previewTabItem {
@@ -88,6 +110,13 @@ func (m model) activePreviewTabContent() string {
 	if tab.File.Status == generator.FileStatusExists {
 		return "existing file will be kept"
 	}
+	if m.previewDiff {
+		diff := tab.File.DiffAs(tab.Name)
+		if diff == "" {
+			return "no changes"
+		}
+		return ui.RenderDiff(diff)
+	}
 	if tab.File.Content == "" {
 		return "no preview available"
 	}
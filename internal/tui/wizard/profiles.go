@@ -0,0 +1,137 @@
+package wizard
+
+import (
+	"strings"
+
+	"docker-wizard/internal/generator"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// serviceProfiles returns the compose profiles a service starts with: the
+// configured ones, else the catalog ones.
+func (m model) serviceProfiles(svc serviceChoice) []string {
+	if profiles := m.project.Overrides[svc.ID].Profiles; len(profiles) > 0 {
+		return profiles
+	}
+	return svc.Profiles
+}
+
+// openProfiles opens the profiles form with one field per selected service.
+// The inputs hold the configured profiles; the placeholders show the catalog
+// ones.
+func (m *model) openProfiles() {
+	m.profilesIDs = nil
+	m.profilesInputs = nil
+	for _, svc := range m.services {
+		if !m.selected[svc.ID] {
+			continue
+		}
+		input := textinput.New()
+		input.Placeholder = "always started"
+		if len(svc.Profiles) > 0 {
+			input.Placeholder = strings.Join(svc.Profiles, ",")
+		}
+		input.SetValue(strings.Join(m.project.Overrides[svc.ID].Profiles, ","))
+		m.profilesIDs = append(m.profilesIDs, svc.ID)
+		m.profilesInputs = append(m.profilesInputs, input)
+	}
+	if len(m.profilesInputs) == 0 {
+		return
+	}
+	m.profilesFocusedField = 0
+	m.profilesFormError = ""
+	m.syncProfilesFocus()
+
+	m.previousStep = m.step
+	m.step = stepProfiles
+	m.animateHeader()
+}
+
+func (m *model) syncProfilesFocus() {
+	for i := range m.profilesInputs {
+		m.profilesInputs[i].Blur()
+	}
+	m.profilesInputs[m.profilesFocusedField].Focus()
+}
+
+// handleProfilesMsg handles all messages for stepProfiles. Only ctrl+c quits
+// so that profile names can contain any letter.
+func (m *model) handleProfilesMsg(msg tea.KeyMsg) tea.Cmd {
+	count := len(m.profilesInputs)
+	switch msg.String() {
+	case "ctrl+c":
+		return tea.Quit
+	case "esc":
+		m.step = m.previousStep
+		m.animateHeader()
+		return nil
+	case "tab", "down":
+		m.profilesFocusedField = (m.profilesFocusedField + 1) % count
+		m.syncProfilesFocus()
+		return nil
+	case "shift+tab", "up":
+		m.profilesFocusedField = (m.profilesFocusedField + count - 1) % count
+		m.syncProfilesFocus()
+		return nil
+	case "enter":
+		m.confirmProfiles()
+		return nil
+	}
+
+	var cmd tea.Cmd
+	idx := m.profilesFocusedField
+	m.profilesInputs[idx], cmd = m.profilesInputs[idx].Update(msg)
+	return cmd
+}
+
+// confirmProfiles stores the profiles in the service overrides, keeping their
+// other fields, and refreshes the review so profile warnings show up.
+func (m *model) confirmProfiles() {
+	overrides := make(map[string]generator.ServiceOverride, len(m.project.Overrides)+len(m.profilesIDs))
+	for id, existing := range m.project.Overrides {
+		overrides[id] = existing
+	}
+	for i, id := range m.profilesIDs {
+		profiles := splitCommaValues(m.profilesInputs[i].Value())
+		if err := generator.ValidateProfiles(profiles); err != nil {
+			m.profilesFocusedField = i
+			m.syncProfilesFocus()
+			m.profilesFormError = err.Error()
+			return
+		}
+		override := overrides[id]
+		override.Profiles = profiles
+		if isEmptyOverride(override) {
+			delete(overrides, id)
+		} else {
+			overrides[id] = override
+		}
+	}
+	if len(overrides) == 0 {
+		overrides = nil
+	}
+
+	previous := m.project.Overrides
+	m.project.Overrides = overrides
+	if m.previousStep == stepReview {
+		if err := m.prepareReview(); err != nil {
+			m.project.Overrides = previous
+			m.profilesFormError = err.Error()
+			return
+		}
+	}
+
+	m.step = m.previousStep
+	m.animateHeader()
+}
+
+func (m model) profilesLabel(id string) string {
+	for _, svc := range m.services {
+		if svc.ID == id {
+			return svc.Label
+		}
+	}
+	return id
+}
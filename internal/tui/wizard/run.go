@@ -38,6 +38,12 @@ func Run(root string) error {
 	}
 	ui.ConfigureSpinner(&m.spinner)
 
+	project, _, err := generator.LoadProjectConfig(root)
+	if err != nil {
+		return err
+	}
+	m.applyProjectConfig(project)
+
 	program := tea.NewProgram(m, tea.WithAltScreen())
 	_, err = program.Run()
 	return err
@@ -1,5 +1,7 @@
 package wizard
 
+import "strings"
+
 import "docker-wizard/internal/generator"
 
 import "docker-wizard/internal/utils"
@@ -40,7 +42,11 @@ func (m model) selectedByCategory() map[string][]string {
 		if !m.selected[svc.ID] {
 			continue
 		}
-		grouped[svc.Category] = append(grouped[svc.Category], svc.Label)
+		label := svc.Label
+		if profiles := m.serviceProfiles(svc); len(profiles) > 0 {
+			label += " [" + strings.Join(profiles, ", ") + "]"
+		}
+		grouped[svc.Category] = append(grouped[svc.Category], label)
 	}
 	return grouped
 }
@@ -76,6 +82,12 @@ func stepTitle(current step) string {
 		return "Webservers / Proxies"
 	case stepAddService:
 		return "Add Service"
+	case stepEditService:
+		return "Edit Service"
+	case stepAppSettings:
+		return "App Service"
+	case stepProfiles:
+		return "Profiles"
 	default:
 		return "Services"
 	}
@@ -86,11 +98,15 @@ func defaultLanguageOptions() []languageChoice {
 		{ID: "auto", Label: "Auto-detect", Description: "use detected language"},
 		{ID: "go", Label: "Go", Language: generator.LanguageGo},
 		{ID: "node", Label: "Node", Language: generator.LanguageNode},
+		{ID: "bun", Label: "Bun", Language: generator.LanguageBun},
+		{ID: "deno", Label: "Deno", Language: generator.LanguageDeno},
 		{ID: "python", Label: "Python", Language: generator.LanguagePython},
 		{ID: "ruby", Label: "Ruby", Language: generator.LanguageRuby},
 		{ID: "php", Label: "PHP", Language: generator.LanguagePHP},
 		{ID: "java", Label: "Java", Language: generator.LanguageJava},
 		{ID: "dotnet", Label: ".NET", Language: generator.LanguageDotNet},
+		{ID: "rust", Label: "Rust", Language: generator.LanguageRust},
+		{ID: "elixir", Label: "Elixir", Language: generator.LanguageElixir},
 	}
 }
 
@@ -186,7 +202,7 @@ func (m model) stepIndex() int {
 		return 11
 	case stepError:
 		return 11
-	case stepAddService:
+	case stepAddService, stepEditService, stepAppSettings, stepProfiles:
 		return 0
 	default:
 		return 1
@@ -201,21 +217,35 @@ func serviceChoicesFromCatalog(services []generator.ServiceSpec) []serviceChoice
 			Label:       svc.Label,
 			Description: svc.Description,
 			Category:    svc.Category,
+			Image:       svc.Image,
+			Ports:       svc.Ports,
+			Env:         svc.Env,
+			Profiles:    svc.Profiles,
 		})
 	}
 	return choices
 }
 
+// languageLabelWithVersion describes the language, for example "Node 20", with
+// the detected framework appended, as in "Node 20, Next.js".
 func languageLabelWithVersion(details generator.LanguageDetails) string {
-	return utils.LanguageLabelWithVersion(string(details.Type), utils.LanguageVersions{
+	label := utils.LanguageLabelWithVersion(string(details.Type), utils.LanguageVersions{
 		Go:     details.GoVersion,
 		Node:   details.NodeVersion,
+		Bun:    details.BunVersion,
+		Deno:   details.DenoVersion,
 		Python: details.PythonVersion,
 		Ruby:   details.RubyVersion,
 		PHP:    details.PHPVersion,
 		Java:   details.JavaVersion,
 		DotNet: details.DotNetVersion,
+		Rust:   details.RustVersion,
+		Elixir: details.ElixirVersion,
 	})
+	if framework := details.ActiveFramework(); framework != "" {
+		label += ", " + utils.FrameworkLabel(string(framework))
+	}
+	return label
 }
 
 func selectedServiceIDs(services []serviceChoice, selected map[string]bool) []string {
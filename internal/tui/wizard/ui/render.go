@@ -55,6 +55,12 @@ func renderContent(s State) string {
 		return viewServices(s)
 	case StepAddService:
 		return viewAddService(s)
+	case StepEditService:
+		return viewEditService(s)
+	case StepAppSettings:
+		return viewAppSettings(s)
+	case StepProfiles:
+		return viewProfiles(s)
 	case StepReview:
 		return viewReview(s)
 	case StepPreview:
@@ -169,12 +175,22 @@ func viewDetect(s State) string {
 		line := fmt.Sprintf("%s Detecting project language", s.SpinnerText)
 		return renderCard(s.Width, "Detect", line)
 	}
+	if len(s.DetectedApps) > 0 {
+		body := []string{"Detected apps:"}
+		for _, option := range s.DetectedApps {
+			body = append(body, renderOptionRow(option))
+		}
+		body = append(body, "", "Space excludes an app; each app gets its own Dockerfile.")
+		return renderCard(s.Width, "Detect", strings.Join(body, "\n"))
+	}
 	body := []string{
 		"Detected language:",
 		s.DetectedLanguage,
-		"",
-		"Press l to choose a different language.",
 	}
+	if s.DetectedPort != "" {
+		body = append(body, "", "Detected app port:", s.DetectedPort)
+	}
+	body = append(body, "", "Press l to choose a different language.")
 	return renderCard(s.Width, "Detect", strings.Join(body, "\n"))
 }
 
@@ -306,7 +322,7 @@ func viewPreview(s State) string {
 		return renderCard(s.Width, "Preview", line)
 	}
 	body := []string{renderPreviewTabs(s.PreviewTabs), ""}
-	body = append(body, mutedStyle().Render("tab: left/right or 1/2/3"), "")
+	body = append(body, mutedStyle().Render("tab: left/right or 1/2/3 | d: toggle diff"), "")
 	if s.PreviewFileLine != "" {
 		body = append(body, s.PreviewFileLine)
 	}
@@ -322,6 +338,25 @@ func viewPreview(s State) string {
 	return renderCompactCard(s.Width, "Preview", strings.Join(body, "\n"))
 }
 
+// RenderDiff colours the lines of a unified diff: additions green, removals
+// red and hunk headers cyan. Plain mode returns the diff unchanged.
+func RenderDiff(diff string) string {
+	lines := strings.Split(diff, "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			lines[i] = mutedStyle().Render(line)
+		case strings.HasPrefix(line, "+"):
+			lines[i] = diffAddedStyle().Render(line)
+		case strings.HasPrefix(line, "-"):
+			lines[i] = diffRemovedStyle().Render(line)
+		case strings.HasPrefix(line, "@@"):
+			lines[i] = diffHunkStyle().Render(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
 func renderPreviewTabs(tabs []PreviewTab) string {
 	parts := make([]string, 0, len(tabs))
 	for i, tab := range tabs {
@@ -354,6 +389,9 @@ func viewResult(s State) string {
 		if len(s.ResultNextSteps) > 0 {
 			body = append(body, "", "Next steps:", strings.Join(s.ResultNextSteps, "\n"))
 		}
+		if s.ResultNote != "" {
+			body = append(body, "", s.ResultNote)
+		}
 		return renderCard(s.Width, "Result", strings.Join(body, "\n"))
 	}
 
@@ -378,6 +416,9 @@ func viewResult(s State) string {
 			body = append(body, "  "+step)
 		}
 	}
+	if s.ResultNote != "" {
+		body = append(body, "", mutedStyle().Render(s.ResultNote))
+	}
 	return renderSuccessCard(s.Width, "✓ All set.", strings.Join(body, "\n"))
 }
 
@@ -390,6 +431,18 @@ func viewAddService(s State) string {
 	return renderCard(s.Width, "Add Custom Service", s.AddServiceBody)
 }
 
+func viewEditService(s State) string {
+	return renderCard(s.Width, s.EditServiceTitle, s.EditServiceBody)
+}
+
+func viewAppSettings(s State) string {
+	return renderCard(s.Width, "App Service", s.AppSettingsBody)
+}
+
+func viewProfiles(s State) string {
+	return renderCard(s.Width, "Profiles", s.ProfilesBody)
+}
+
 func renderCard(width int, title string, body string) string {
 	if isPlainMode() {
 		return cardStyle(width).Render(sectionTitle(title) + "\n\n" + body)
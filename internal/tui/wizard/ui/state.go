@@ -24,6 +24,9 @@ const (
 	StepResult       Step = "result"
 	StepError        Step = "error"
 	StepAddService   Step = "add-service"
+	StepEditService  Step = "edit-service"
+	StepAppSettings  Step = "app-settings"
+	StepProfiles     Step = "profiles"
 )
 
 type OptionItem struct {
@@ -61,6 +64,9 @@ type State struct {
 	SpinnerText      string
 	DetectDone       bool
 	DetectedLanguage string
+	DetectedPort     string
+	// DetectedApps lists the apps of a monorepo; empty for a single app
+	DetectedApps []OptionItem
 
 	LanguageOptions []OptionItem
 	ServiceTitle    string
@@ -82,6 +88,7 @@ type State struct {
 	ResultFiles     []string
 	ResultBackups   []string
 	ResultNextSteps []string
+	ResultNote      string
 
 	ErrorMessage string
 
@@ -89,4 +96,11 @@ type State struct {
 	SideLines []string
 
 	AddServiceBody string
+
+	EditServiceTitle string
+	EditServiceBody  string
+
+	AppSettingsBody string
+
+	ProfilesBody string
 }
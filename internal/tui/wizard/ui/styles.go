@@ -201,6 +201,27 @@ func inactivePreviewTabStyle() lipgloss.Style {
 		Padding(0, 1)
 }
 
+func diffAddedStyle() lipgloss.Style {
+	if isPlainMode() {
+		return lipgloss.NewStyle()
+	}
+	return lipgloss.NewStyle().Foreground(paletteGreen)
+}
+
+func diffRemovedStyle() lipgloss.Style {
+	if isPlainMode() {
+		return lipgloss.NewStyle()
+	}
+	return lipgloss.NewStyle().Foreground(paletteRed)
+}
+
+func diffHunkStyle() lipgloss.Style {
+	if isPlainMode() {
+		return lipgloss.NewStyle()
+	}
+	return lipgloss.NewStyle().Foreground(paletteCyan)
+}
+
 func blockerTitle() lipgloss.Style {
 	if isPlainMode() {
 		return lipgloss.NewStyle().Bold(true)
@@ -23,6 +23,8 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case detectDoneMsg:
 		m.langDetected = msg.err == nil
 		m.langDetails = msg.details
+		m.apps = msg.apps
+		m.appCursor = clampCursor(m.appCursor, len(m.apps))
 		if msg.err != nil {
 			m.err = msg.err
 			m.previousStep = stepDetect
@@ -30,6 +32,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 		m.langOptions = languageOptionsForDetected(m.langDetails)
+		m.detectOverridePort()
 		m.detectDone = true
 		m.step = stepDetect
 		m.animateHeader()
@@ -65,6 +68,15 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.step == stepAddService {
 			return m, m.handleAddServiceMsg(msg)
 		}
+		if m.step == stepEditService {
+			return m, m.handleEditServiceMsg(msg)
+		}
+		if m.step == stepAppSettings {
+			return m, m.handleAppSettingsMsg(msg)
+		}
+		if m.step == stepProfiles {
+			return m, m.handleProfilesMsg(msg)
+		}
 		return m, m.handleKey(msg)
 	}
 
@@ -19,7 +19,7 @@ func (m model) buildViewState() ui.State {
 
 	languageText := "language: detecting"
 	if m.langDetected {
-		languageText = "language: " + languageLabelWithVersion(m.effectiveDetails())
+		languageText = "language: " + m.languageLabel()
 	}
 
 	s := ui.State{
@@ -35,7 +35,9 @@ func (m model) buildViewState() ui.State {
 		FooterRaw:        m.footerKeys(),
 		SpinnerText:      m.spinner.View(),
 		DetectDone:       m.detectDone,
-		DetectedLanguage: languageLabelWithVersion(m.effectiveDetails()),
+		DetectedLanguage: m.languageLabel(),
+		DetectedApps:     m.appOptions(),
+		DetectedPort:     m.effectiveDetails().AppPort,
 		Warnings:         m.warnings,
 		Blockers:         m.blockers,
 		PreviewReady:     m.previewReady,
@@ -56,8 +58,12 @@ func (m model) buildViewState() ui.State {
 		s.ServiceTitle = stepTitle(m.step)
 		s.ServiceOptions = make([]ui.OptionItem, 0, len(filtered))
 		for i, svc := range filtered {
+			label := svc.Label
+			if m.isServiceEdited(svc.ID) {
+				label += " (edited)"
+			}
 			s.ServiceOptions = append(s.ServiceOptions, ui.OptionItem{
-				Label:       svc.Label,
+				Label:       label,
 				Description: svc.Description,
 				Active:      i == m.cursor,
 				Selected:    m.selected[svc.ID],
@@ -74,10 +80,25 @@ func (m model) buildViewState() ui.State {
 		})
 	}
 
-	s.ManagedFiles = []string{"- docker-compose.yml", "- Dockerfile"}
-	if m.createDockerignore {
+	s.ManagedFiles = []string{"- docker-compose.yml"}
+	if len(m.appFiles) == 0 {
+		s.ManagedFiles = append(s.ManagedFiles, "- Dockerfile")
+	}
+	if m.manageOverride {
+		s.ManagedFiles = append(s.ManagedFiles, "- "+generator.ComposeOverrideFileName)
+	}
+	if m.createDockerignore && len(m.appFiles) == 0 {
 		s.ManagedFiles = append(s.ManagedFiles, "- .dockerignore")
 	}
+	for _, name := range m.appFiles {
+		s.ManagedFiles = append(s.ManagedFiles, "- "+name)
+	}
+	if m.manageEnv {
+		s.ManagedFiles = append(s.ManagedFiles, "- .env", "- .env.example")
+	}
+	for _, name := range m.secretFiles {
+		s.ManagedFiles = append(s.ManagedFiles, "- "+name)
+	}
 
 	if m.previewReady {
 		tab := m.activePreviewTab()
@@ -91,7 +112,11 @@ func (m model) buildViewState() ui.State {
 			})
 		}
 		if tab.Name != "" {
-			s.PreviewFileLine = fmt.Sprintf("file: %s    status: %s", tab.Name, previewStatusLabel(tab.File.Status))
+			view := "content"
+			if m.previewDiff {
+				view = "diff"
+			}
+			s.PreviewFileLine = fmt.Sprintf("file: %s    status: %s    view: %s", tab.Name, previewStatusLabel(tab.File.Status), view)
 		}
 
 		content := m.previewContent
@@ -118,16 +143,50 @@ func (m model) buildViewState() ui.State {
 
 	s.ResultFiles = []string{
 		outputLine(m.output.ComposePath, m.output.ComposeStatus),
-		outputLine(m.output.DockerfilePath, m.output.DockerfileStatus),
-		outputLine(m.output.DockerignorePath, m.output.DockerignoreStatus),
+	}
+	if m.output.ComposeOverridePath != "" {
+		s.ResultFiles = append(s.ResultFiles, outputLine(m.output.ComposeOverridePath, m.output.ComposeOverrideStatus))
+	}
+	if len(m.output.Apps) == 0 {
+		s.ResultFiles = append(s.ResultFiles,
+			outputLine(m.output.DockerfilePath, m.output.DockerfileStatus),
+			outputLine(m.output.DockerignorePath, m.output.DockerignoreStatus),
+		)
+	}
+	for _, app := range m.output.Apps {
+		s.ResultFiles = append(s.ResultFiles,
+			fmt.Sprintf("- %s (%s)", appFileName(app.Dir, generator.DockerfileFileName), app.DockerfileStatus),
+			fmt.Sprintf("- %s (%s)", appFileName(app.Dir, generator.DockerignoreFileName), app.DockerignoreStatus),
+		)
+	}
+	if m.output.EnvPath != "" {
+		s.ResultFiles = append(s.ResultFiles,
+			outputLine(m.output.EnvPath, m.output.EnvStatus),
+			outputLine(m.output.EnvExamplePath, m.output.EnvExampleStatus),
+		)
+	}
+	for _, secret := range m.output.Secrets {
+		s.ResultFiles = append(s.ResultFiles, fmt.Sprintf("- %s (%s)", secretFileName(secret.Path), secret.Status))
+	}
+	if m.output.GitignorePath != "" {
+		s.ResultFiles = append(s.ResultFiles, outputLine(m.output.GitignorePath, m.output.GitignoreStatus))
 	}
 	if m.output.ComposeBackupPath != "" {
 		s.ResultBackups = append(s.ResultBackups, "- "+baseName(m.output.ComposeBackupPath))
 	}
+	if m.output.ComposeOverrideBackupPath != "" {
+		s.ResultBackups = append(s.ResultBackups, "- "+baseName(m.output.ComposeOverrideBackupPath))
+	}
 	if m.output.DockerfileBackupPath != "" {
 		s.ResultBackups = append(s.ResultBackups, "- "+baseName(m.output.DockerfileBackupPath))
 	}
+	for _, app := range m.output.Apps {
+		if app.DockerfileBackupPath != "" {
+			s.ResultBackups = append(s.ResultBackups, "- "+appFileName(app.Dir, baseName(app.DockerfileBackupPath)))
+		}
+	}
 	s.ResultNextSteps = []string{"- docker compose up"}
+	s.ResultNote = m.projectNote
 
 	s.ErrorMessage = "Unknown error"
 	if m.err != nil {
@@ -140,6 +199,16 @@ func (m model) buildViewState() ui.State {
 	if m.step == stepAddService {
 		s.AddServiceBody = m.buildAddServiceBody()
 	}
+	if m.step == stepAppSettings {
+		s.AppSettingsBody = m.buildAppSettingsBody()
+	}
+	if m.step == stepProfiles {
+		s.ProfilesBody = m.buildProfilesBody()
+	}
+	if m.step == stepEditService {
+		s.EditServiceTitle = "Edit " + m.editServiceLabel()
+		s.EditServiceBody = m.buildEditServiceBody()
+	}
 
 	return s
 }
@@ -150,11 +219,19 @@ func (m model) sideViewLines() []string {
 		fmt.Sprintf("Stage: %s", stepTitle(m.step)),
 		"",
 	}
-	if m.langDetected {
+	if len(m.apps) > 0 {
+		lines = append(lines, fmt.Sprintf("Apps (%d of %d):", len(m.includedApps()), len(m.apps)))
+		for _, app := range m.includedApps() {
+			lines = append(lines, "  · "+app.Name+" ("+languageLabelWithVersion(app.Details)+")")
+		}
+	} else if m.langDetected {
 		lines = append(lines, "Language: "+languageLabelWithVersion(m.effectiveDetails()))
 	} else {
 		lines = append(lines, "Language: detecting...")
 	}
+	if app := m.appSettings(); len(m.apps) == 0 && (app.Name != "" || app.Port != "") {
+		lines = append(lines, "App: "+strings.TrimSpace(app.Name+" "+app.Port))
+	}
 	lines = append(lines, "")
 
 	grouped := m.selectedByCategory()
@@ -178,6 +255,9 @@ func (m model) sideViewLines() []string {
 		}
 	}
 
+	if len(m.project.Overrides) > 0 {
+		lines = append(lines, fmt.Sprintf("Edited services: %d", len(m.project.Overrides)))
+	}
 	if len(m.warnings) > 0 {
 		lines = append(lines, fmt.Sprintf("Warnings: %d", len(m.warnings)))
 	}
@@ -200,6 +280,12 @@ func tipForStep(s step) string {
 		return "Space to toggle, enter to continue"
 	case stepAddService:
 		return "Fill in fields and press Enter"
+	case stepEditService:
+		return "Empty fields keep the catalog value"
+	case stepAppSettings:
+		return "Empty fields keep the defaults"
+	case stepProfiles:
+		return "Profiled services start only when enabled"
 	case stepReview:
 		return "Review before generating"
 	case stepPreview:
@@ -220,6 +306,9 @@ func (m model) footerKeys() string {
 	case stepWelcome:
 		return "enter next | q quit"
 	case stepDetect:
+		if m.detectDone && len(m.apps) > 0 {
+			return "up/down move | space toggle | enter next | b back | q quit"
+		}
 		if m.detectDone {
 			return "enter next | l choose language | b back | q quit"
 		}
@@ -227,26 +316,28 @@ func (m model) footerKeys() string {
 	case stepLanguage:
 		return "up/down move | enter select | b back | q quit"
 	case stepDatabase, stepMessageQueue, stepCache, stepAnalytics, stepProxy:
-		return "up/down move | space toggle | enter next | n add service | b back | q quit"
+		return "up/down move | space toggle | enter next | e edit | n add service | b back | q quit"
 	case stepAddService:
 		return "tab next field | shift+tab prev field | up/down category | enter save | esc cancel | q quit"
+	case stepEditService, stepAppSettings, stepProfiles:
+		return "tab/up/down field | enter save | esc cancel | ctrl+c quit"
 	case stepReview:
 		if len(m.blockers) > 0 {
-			return "resolve blockers to continue | p preview | b back | q quit"
+			return "resolve blockers to continue | p preview | a app service | o profiles | c override file | b back | q quit"
 		}
-		return "enter generate | p preview | b back | q quit"
+		return "enter generate | p preview | a app service | o profiles | c override file | b back | q quit"
 	case stepPreview:
 		if !m.previewReady {
 			return "preparing preview..."
 		}
 		if len(m.blockers) > 0 {
-			return "left/right tab | 1/2/3 file | up/down scroll | b back | q quit"
+			return "left/right tab | 1/2/3 file | d diff | up/down scroll | b back | q quit"
 		}
-		return "left/right tab | 1/2/3 file | up/down scroll | enter generate | b back | q quit"
+		return "left/right tab | 1/2/3 file | d diff | up/down scroll | enter generate | b back | q quit"
 	case stepGenerate:
 		return "generating..."
 	case stepResult:
-		return "enter finish | q quit"
+		return "enter finish | s save selection | q quit"
 	case stepError:
 		return "r retry | b back | q quit"
 	default:
@@ -284,12 +375,21 @@ func mapStep(current step) ui.Step {
 		return ui.StepError
 	case stepAddService:
 		return ui.StepAddService
+	case stepEditService:
+		return ui.StepEditService
+	case stepAppSettings:
+		return ui.StepAppSettings
+	case stepProfiles:
+		return ui.StepProfiles
 	default:
 		return ui.StepWelcome
 	}
 }
 
 func previewTabShortName(name string) string {
+	if strings.Contains(name, "/") {
+		return appTabShortName(name)
+	}
 	switch name {
 	case generator.ComposeFileName:
 		return "compose"
@@ -297,6 +397,8 @@ func previewTabShortName(name string) string {
 		return "dockerfile"
 	case generator.DockerignoreFileName:
 		return "dockerignore"
+	case generator.EnvExampleFileName:
+		return "env.example"
 	default:
 		return name
 	}
@@ -362,6 +464,77 @@ func (m model) buildAddServiceBody() string {
 	return strings.Join(lines, "\n")
 }
 
+// buildEditServiceBody renders the edit-service form content as a string.
+func (m model) buildEditServiceBody() string {
+	labels := [editServiceFieldCount]string{"Docker Image", "Ports", "Env Vars"}
+	lines := make([]string, 0, editServiceFieldCount+4)
+	for i, label := range labels {
+		prefix := "  "
+		if m.editServiceFocusedField == i {
+			prefix = "> "
+		}
+		if i == editServiceEnvField {
+			// continuation lines of the text area line up with its first line
+			view := strings.ReplaceAll(m.editServiceEnv.View(), "\n", "\n"+strings.Repeat(" ", 18))
+			lines = append(lines, fmt.Sprintf("%s%-15s %s", prefix, label+":", view))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s%-15s %s", prefix, label+":", m.editServiceInputs[i].View()))
+	}
+
+	lines = append(lines, "", "  empty fields keep the catalog value; ports are comma separated,", "  env vars one per line (alt+enter or ctrl+j starts a new line)")
+
+	if m.editServiceFormError != "" {
+		errLine := lipgloss.NewStyle().Foreground(lipgloss.Color("#f7768e")).Render("  Error: " + m.editServiceFormError)
+		lines = append(lines, "", errLine)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// buildAppSettingsBody renders the app-settings form content as a string.
+func (m model) buildAppSettingsBody() string {
+	labels := [appSettingsFieldCount]string{"Service Name", "Port", "Start Command", "Env Vars", "Build Target", "Platforms"}
+	lines := make([]string, 0, appSettingsFieldCount+4)
+	for i, label := range labels {
+		prefix := "  "
+		if m.appSettingsFocusedField == i {
+			prefix = "> "
+		}
+		lines = append(lines, fmt.Sprintf("%s%-15s %s", prefix, label+":", m.appSettingsInputs[i].View()))
+	}
+
+	lines = append(lines, "", "  port and start command also set the Dockerfile EXPOSE and APP_START_CMD")
+
+	if m.appSettingsFormError != "" {
+		errLine := lipgloss.NewStyle().Foreground(lipgloss.Color("#f7768e")).Render("  Error: " + m.appSettingsFormError)
+		lines = append(lines, "", errLine)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// buildProfilesBody renders the profiles form content as a string.
+func (m model) buildProfilesBody() string {
+	lines := make([]string, 0, len(m.profilesInputs)+4)
+	for i, input := range m.profilesInputs {
+		prefix := "  "
+		if m.profilesFocusedField == i {
+			prefix = "> "
+		}
+		lines = append(lines, fmt.Sprintf("%s%-15s %s", prefix, m.profilesLabel(m.profilesIDs[i])+":", input.View()))
+	}
+
+	lines = append(lines, "", "  comma separated; start them with docker compose --profile <name> up")
+
+	if m.profilesFormError != "" {
+		errLine := lipgloss.NewStyle().Foreground(lipgloss.Color("#f7768e")).Render("  Error: " + m.profilesFormError)
+		lines = append(lines, "", errLine)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
 func previewDivider(width int) string {
 	w := ui.ContentWidth(width) - 10
 	if w < 24 {
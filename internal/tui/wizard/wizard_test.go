@@ -171,6 +171,44 @@ func TestActivePreviewTabContentExistsStatus(t *testing.T) {
 	}
 }
 
+func TestHandleKey_PreviewDiffToggle(t *testing.T) {
+	m := model{
+		step:         stepPreview,
+		previewReady: true,
+		preview: generator.Preview{
+			Compose: generator.FilePreview{
+				Path:     "docker-compose.yml",
+				Status:   generator.FileStatusDifferent,
+				Existing: "services:\n  db:\n",
+				Content:  "services:\n  db:\n  cache:\n",
+			},
+			Dockerfile:   generator.FilePreview{Status: generator.FileStatusSame, Content: "FROM alpine\n"},
+			Dockerignore: generator.FilePreview{Status: generator.FileStatusExists},
+		},
+		width:  100,
+		height: 40,
+	}
+	m.refreshPreviewTabContent()
+
+	m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+	if !m.previewDiff {
+		t.Fatal("expected diff view to be enabled")
+	}
+	if !strings.Contains(m.previewContent, "+  cache:") || !strings.Contains(m.previewContent, "--- a/docker-compose.yml") {
+		t.Fatalf("expected compose diff, got %q", m.previewContent)
+	}
+
+	m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'2'}})
+	if m.previewContent != "no changes" {
+		t.Fatalf("expected no changes for unchanged dockerfile, got %q", m.previewContent)
+	}
+
+	m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+	if m.previewContent != "FROM alpine\n" {
+		t.Fatalf("expected full content after toggling back, got %q", m.previewContent)
+	}
+}
+
 func TestHandleKey_ReviewEnterBlockedByBlockers(t *testing.T) {
 	m := model{
 		step:     stepReview,
@@ -187,3 +225,67 @@ func TestHandleKey_ReviewEnterBlockedByBlockers(t *testing.T) {
 		t.Fatalf("expected step to remain review, got %v", m.step)
 	}
 }
+
+func TestHandleKey_DetectTogglesMonorepoApps(t *testing.T) {
+	m := model{
+		step:       stepDetect,
+		detectDone: true,
+		apps: []generator.App{
+			{Name: "web", Dir: "apps/web"},
+			{Name: "api", Dir: "services/api"},
+		},
+		selected: map[string]bool{},
+	}
+
+	m.handleKey(tea.KeyMsg{Type: tea.KeySpace})
+	if got := m.includedApps(); len(got) != 1 || got[0].Name != "api" {
+		t.Fatalf("expected web to be excluded, got %+v", got)
+	}
+
+	m.handleKey(tea.KeyMsg{Type: tea.KeyDown})
+	m.handleKey(tea.KeyMsg{Type: tea.KeySpace})
+	if got := m.includedApps(); len(got) != 1 || got[0].Name != "api" {
+		t.Fatalf("expected the last included app to stay, got %+v", got)
+	}
+
+	m.handleKey(tea.KeyMsg{Type: tea.KeyUp})
+	m.handleKey(tea.KeyMsg{Type: tea.KeySpace})
+	if len(m.includedApps()) != 2 || len(m.project.ExcludeApps) != 0 {
+		t.Fatalf("expected web to be included again, got %v", m.project.ExcludeApps)
+	}
+
+	m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'l'}})
+	if m.step != stepDetect {
+		t.Fatalf("expected no language step for a monorepo, got %v", m.step)
+	}
+}
+
+func TestHandleKey_LanguageOverrideDetectsPort(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "package.json"), []byte(`{"scripts": {"start": "PORT=4000 node server.js"}}`), 0o644); err != nil {
+		t.Fatalf("write package.json: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "app.py"), []byte("app.run(host=\"0.0.0.0\", port=5000)\n"), 0o644); err != nil {
+		t.Fatalf("write app.py: %v", err)
+	}
+	m := model{
+		step:        stepLanguage,
+		root:        root,
+		langDetails: generator.LanguageDetails{Type: generator.LanguageNode, AppPort: "4000"},
+		langOptions: []languageChoice{
+			{ID: "auto"},
+			{ID: "python", Language: generator.LanguagePython},
+		},
+		langCursor: 1,
+		selected:   map[string]bool{},
+	}
+
+	m.handleKey(tea.KeyMsg{Type: tea.KeyEnter})
+
+	if got := m.appSettings().Port; got != "5000" {
+		t.Fatalf("expected the port detected for python, got %q", got)
+	}
+	if got := m.effectiveDetails().Type; got != generator.LanguagePython {
+		t.Fatalf("expected python, got %q", got)
+	}
+}
@@ -9,3 +9,11 @@ func FileExists(path string) bool {
 	}
 	return !info.IsDir()
 }
+
+func DirExists(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return info.IsDir()
+}
@@ -5,11 +5,15 @@ import "strings"
 type LanguageVersions struct {
 	Go     string
 	Node   string
+	Bun    string
+	Deno   string
 	Python string
 	Ruby   string
 	PHP    string
 	Java   string
 	DotNet string
+	Rust   string
+	Elixir string
 }
 
 func LanguageLabel(language string) string {
@@ -18,6 +22,10 @@ func LanguageLabel(language string) string {
 		return "Go"
 	case "node":
 		return "Node"
+	case "bun":
+		return "Bun"
+	case "deno":
+		return "Deno"
 	case "python":
 		return "Python"
 	case "ruby":
@@ -28,17 +36,45 @@ func LanguageLabel(language string) string {
 		return "Java"
 	case "dotnet", ".net":
 		return ".NET"
+	case "rust":
+		return "Rust"
+	case "elixir":
+		return "Elixir"
 	default:
 		return "Unknown"
 	}
 }
 
+// FrameworkLabel returns the display name of a framework, or "" for none.
+func FrameworkLabel(framework string) string {
+	switch framework {
+	case "next":
+		return "Next.js"
+	case "django":
+		return "Django"
+	case "fastapi":
+		return "FastAPI"
+	case "rails":
+		return "Rails"
+	case "laravel":
+		return "Laravel"
+	case "spring-boot":
+		return "Spring Boot"
+	default:
+		return framework
+	}
+}
+
 func LanguageVersion(language string, versions LanguageVersions) string {
 	switch strings.ToLower(strings.TrimSpace(language)) {
 	case "go":
 		return versions.Go
 	case "node":
 		return versions.Node
+	case "bun":
+		return versions.Bun
+	case "deno":
+		return versions.Deno
 	case "python":
 		return versions.Python
 	case "ruby":
@@ -49,6 +85,10 @@ func LanguageVersion(language string, versions LanguageVersions) string {
 		return versions.Java
 	case "dotnet", ".net":
 		return versions.DotNet
+	case "rust":
+		return versions.Rust
+	case "elixir":
+		return versions.Elixir
 	default:
 		return ""
 	}
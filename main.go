@@ -18,6 +18,9 @@ func main() {
 		case "add":
 			runAdd(os.Args[2:])
 			return
+		case "remove":
+			runRemove(os.Args[2:])
+			return
 		case "list":
 			runList()
 			return
@@ -51,9 +54,25 @@ func parseArgs(args []string) (bool, app.Options, error) {
 
 	modeFlag := fs.String("mode", string(app.ModeStyled), "run mode: styled, plain, cli, batch")
 	servicesFlag := fs.String("services", "", "comma-separated service IDs (batch mode)")
-	languageFlag := fs.String("language", "", "language override: go, node, python, ruby, php, java, dotnet (batch mode)")
+	languageFlag := fs.String("language", "", "language override: go, node, bun, deno, python, ruby, php, java, dotnet, rust, elixir (batch mode)")
 	writeFlag := fs.Bool("write", false, "write generated files (batch mode)")
 	dryRunFlag := fs.Bool("dry-run", false, "preview only; do not write files (batch mode)")
+	diffFlag := fs.Bool("diff", false, "print a unified diff of each changed file (batch mode)")
+	composeOverrideFlag := fs.Bool("compose-override", false, "write local development settings to docker-compose.override.yml (batch mode)")
+	var setFlags stringList
+	fs.Var(&setFlags, "set", "override a service field, e.g. postgres.ports=5544:5432 (batch mode, repeatable)")
+	var profileFlags stringList
+	fs.Var(&profileFlags, "profile", "assign a service to a compose profile, e.g. metabase=debug (batch mode, repeatable)")
+	appNameFlag := fs.String("app-name", "", "app service name (batch mode)")
+	appPortFlag := fs.String("app-port", "", "app port: container or host:container (batch mode)")
+	appCommandFlag := fs.String("app-command", "", "app start command (batch mode)")
+	var appEnvFlags stringList
+	fs.Var(&appEnvFlags, "app-env", "app environment variable KEY=value (batch mode, repeatable)")
+	appTargetFlag := fs.String("app-target", "", "Dockerfile build target of the app (batch mode)")
+	var appPlatformFlags stringList
+	fs.Var(&appPlatformFlags, "app-platform", "platform to build the app image for, e.g. linux/arm64 (batch mode, repeatable or comma-separated)")
+	var excludeAppFlags stringList
+	fs.Var(&excludeAppFlags, "exclude-app", "leave a monorepo app out of the generated files, e.g. docs (batch mode, repeatable)")
 	versionFlag := fs.Bool("version", false, "print version")
 	versionShortFlag := fs.Bool("v", false, "print version")
 
@@ -72,9 +91,18 @@ func parseArgs(args []string) (bool, app.Options, error) {
 		return false, app.Options{}, err
 	}
 
-	usesAutomationFlags := strings.TrimSpace(*servicesFlag) != "" || strings.TrimSpace(*languageFlag) != "" || *writeFlag || *dryRunFlag
-	if mode != app.ModeBatch && usesAutomationFlags {
-		return false, app.Options{}, fmt.Errorf("--services, --language, --write, and --dry-run require --mode batch")
+	usesAutomationFlags := strings.TrimSpace(*servicesFlag) != "" || strings.TrimSpace(*languageFlag) != "" || *writeFlag || *dryRunFlag || *diffFlag || *composeOverrideFlag || len(setFlags) > 0 || len(profileFlags) > 0 || len(excludeAppFlags) > 0
+	appSettings := app.AppSettings{
+		Name:      strings.TrimSpace(*appNameFlag),
+		Port:      strings.TrimSpace(*appPortFlag),
+		Command:   strings.TrimSpace(*appCommandFlag),
+		Env:       appEnvFlags,
+		Target:    strings.TrimSpace(*appTargetFlag),
+		Platforms: splitCommaList(appPlatformFlags),
+	}
+	usesAppFlags := appSettings.Name != "" || appSettings.Port != "" || appSettings.Command != "" || len(appSettings.Env) > 0 || appSettings.Target != "" || len(appSettings.Platforms) > 0
+	if mode != app.ModeBatch && (usesAutomationFlags || usesAppFlags) {
+		return false, app.Options{}, fmt.Errorf("--services, --language, --write, --dry-run, --diff, --compose-override, --set, --profile, --exclude-app, and --app-* require --mode batch")
 	}
 	if mode == app.ModeBatch && *writeFlag && *dryRunFlag {
 		return false, app.Options{}, fmt.Errorf("--write and --dry-run cannot be used together")
@@ -83,14 +111,45 @@ func parseArgs(args []string) (bool, app.Options, error) {
 	return false, app.Options{
 		Mode: mode,
 		Automation: app.AutomationOptions{
-			Services: parseServicesFlag(*servicesFlag),
-			Language: strings.TrimSpace(*languageFlag),
-			Write:    *writeFlag,
-			DryRun:   *dryRunFlag,
+			Services:        parseServicesFlag(*servicesFlag),
+			Language:        strings.TrimSpace(*languageFlag),
+			Write:           *writeFlag,
+			DryRun:          *dryRunFlag,
+			Diff:            *diffFlag,
+			ComposeOverride: *composeOverrideFlag,
+			Set:             setFlags,
+			Profiles:        profileFlags,
+			App:             appSettings,
+			ExcludeApps:     excludeAppFlags,
 		},
 	}, nil
 }
 
+// stringList collects the values of a repeatable flag.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// splitCommaList splits comma-separated flag values into trimmed items.
+func splitCommaList(values []string) []string {
+	var items []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
+
 func parseServicesFlag(value string) []string {
 	if strings.TrimSpace(value) == "" {
 		return nil
@@ -112,27 +171,103 @@ func parseServicesFlag(value string) []string {
 }
 
 func runAdd(args []string) {
+	options, err := parseAddArgs(args)
+	if err != nil {
+		if err == flag.ErrHelp {
+			os.Exit(0)
+		}
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		printAddUsage()
+		os.Exit(2)
+	}
+
+	if err := app.RunAdd(options); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func parseAddArgs(args []string) (app.AddOptions, error) {
 	fs := flag.NewFlagSet("docker-wizard add", flag.ContinueOnError)
 	fs.SetOutput(os.Stderr)
 	writeFlag := fs.Bool("write", false, "apply changes (default is dry-run)")
+	diffFlag := fs.Bool("diff", false, "print a unified diff instead of the merged file")
 
-	if err := fs.Parse(args); err != nil {
+	serviceIDs, err := parseInterspersed(fs, args)
+	if err != nil {
+		return app.AddOptions{}, err
+	}
+	if len(serviceIDs) == 0 {
+		return app.AddOptions{}, fmt.Errorf("at least one service ID is required")
+	}
+
+	return app.AddOptions{
+		Services: normalizeServiceIDs(serviceIDs),
+		Write:    *writeFlag,
+		Diff:     *diffFlag,
+	}, nil
+}
+
+func runRemove(args []string) {
+	options, err := parseRemoveArgs(args)
+	if err != nil {
 		if err == flag.ErrHelp {
 			os.Exit(0)
 		}
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
-		printAddUsage()
+		printRemoveUsage()
 		os.Exit(2)
 	}
 
-	serviceIDs := fs.Args()
+	if err := app.RunRemove(options); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func parseRemoveArgs(args []string) (app.RemoveOptions, error) {
+	fs := flag.NewFlagSet("docker-wizard remove", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	writeFlag := fs.Bool("write", false, "apply changes (default is dry-run)")
+
+	serviceIDs, err := parseInterspersed(fs, args)
+	if err != nil {
+		return app.RemoveOptions{}, err
+	}
 	if len(serviceIDs) == 0 {
-		fmt.Fprintln(os.Stderr, "error: at least one service ID is required")
-		printAddUsage()
-		os.Exit(2)
+		return app.RemoveOptions{}, fmt.Errorf("at least one service ID is required")
 	}
 
-	// normalize service IDs
+	return app.RemoveOptions{
+		Services: normalizeServiceIDs(serviceIDs),
+		Write:    *writeFlag,
+	}, nil
+}
+
+// parseInterspersed parses flags that may follow the positional arguments, as
+// in `remove kafka --write`, and returns the positional arguments. The flag
+// package stops at the first positional argument, so parsing resumes after
+// each one. Everything after "--" is positional.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		if len(rest) < len(args) && args[len(args)-len(rest)-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// normalizeServiceIDs lowercases, trims and deduplicates positional service IDs.
+func normalizeServiceIDs(serviceIDs []string) []string {
 	normalized := make([]string, 0, len(serviceIDs))
 	seen := map[string]bool{}
 	for _, id := range serviceIDs {
@@ -143,14 +278,7 @@ func runAdd(args []string) {
 		seen[id] = true
 		normalized = append(normalized, id)
 	}
-
-	if err := app.RunAdd(app.AddOptions{
-		Services: normalized,
-		Write:    *writeFlag,
-	}); err != nil {
-		fmt.Fprintf(os.Stderr, "error: %v\n", err)
-		os.Exit(1)
-	}
+	return normalized
 }
 
 func runList() {
@@ -164,18 +292,30 @@ func printUsage() {
 	fmt.Fprintln(os.Stderr, "usage: docker-wizard [command] [options]")
 	fmt.Fprintln(os.Stderr, "")
 	fmt.Fprintln(os.Stderr, "commands:")
-	fmt.Fprintln(os.Stderr, "  add <service...>  add services to existing compose file")
-	fmt.Fprintln(os.Stderr, "  list              show available services")
+	fmt.Fprintln(os.Stderr, "  add <service...>     add services to existing compose file")
+	fmt.Fprintln(os.Stderr, "  remove <service...>  remove services from existing compose file")
+	fmt.Fprintln(os.Stderr, "  list                 show available services")
 	fmt.Fprintln(os.Stderr, "")
 	fmt.Fprintln(os.Stderr, "wizard flags:")
 	fmt.Fprintln(os.Stderr, "  --mode styled|plain|cli|batch")
 	fmt.Fprintln(os.Stderr, "  --version")
 	fmt.Fprintln(os.Stderr, "")
 	fmt.Fprintln(os.Stderr, "batch mode flags:")
-	fmt.Fprintln(os.Stderr, "  --services mysql,redis --language go [--write|--dry-run]")
+	fmt.Fprintln(os.Stderr, "  --services mysql,redis --language go [--write|--dry-run] [--diff] [--compose-override]")
+	fmt.Fprintln(os.Stderr, "  --set postgres.ports=5544:5432 --set redis.image=redis:7.2 (repeatable)")
+	fmt.Fprintln(os.Stderr, "  --profile metabase=debug --profile traefik=proxy (repeatable)")
+	fmt.Fprintln(os.Stderr, "  --app-name web --app-port 3000 --app-command \"bin/rails server\" --app-env KEY=value --app-target build")
+	fmt.Fprintln(os.Stderr, "  --app-platform linux/amd64,linux/arm64 (repeatable)")
+	fmt.Fprintln(os.Stderr, "  --exclude-app docs (monorepos, repeatable)")
 }
 
 func printAddUsage() {
-	fmt.Fprintln(os.Stderr, "usage: docker-wizard add [--write] <service...>")
+	fmt.Fprintln(os.Stderr, "usage: docker-wizard add [--write] [--diff] <service...>")
+	fmt.Fprintln(os.Stderr, "  preview by default; pass --write to apply changes")
+	fmt.Fprintln(os.Stderr, "  --diff prints a unified diff instead of the merged file")
+}
+
+func printRemoveUsage() {
+	fmt.Fprintln(os.Stderr, "usage: docker-wizard remove [--write] <service...>")
 	fmt.Fprintln(os.Stderr, "  preview by default; pass --write to apply changes")
 }
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"strings"
 	"testing"
 
 	"docker-wizard/internal/app"
@@ -46,6 +47,72 @@ func TestParseArgsRejectsAutomationFlagsOutsideBatch(t *testing.T) {
 	}
 }
 
+func TestParseArgsDiffRequiresBatch(t *testing.T) {
+	if _, _, err := parseArgs([]string{"--mode", "cli", "--diff"}); err == nil {
+		t.Fatal("expected error")
+	}
+	_, options, err := parseArgs([]string{"--mode", "batch", "--diff"})
+	if err != nil {
+		t.Fatalf("parse args: %v", err)
+	}
+	if !options.Automation.Diff {
+		t.Fatal("expected diff true")
+	}
+}
+
+func TestParseArgsRepeatableSet(t *testing.T) {
+	if _, _, err := parseArgs([]string{"--set", "redis.image=redis:7.2"}); err == nil {
+		t.Fatal("expected error outside batch mode")
+	}
+	_, options, err := parseArgs([]string{"--mode", "batch", "--set", "postgres.ports=5544:5432", "--set", "redis.image=redis:7.2"})
+	if err != nil {
+		t.Fatalf("parse args: %v", err)
+	}
+	if len(options.Automation.Set) != 2 || options.Automation.Set[1] != "redis.image=redis:7.2" {
+		t.Fatalf("unexpected set values: %v", options.Automation.Set)
+	}
+}
+
+func TestParseArgsRepeatableProfile(t *testing.T) {
+	if _, _, err := parseArgs([]string{"--profile", "metabase=debug"}); err == nil {
+		t.Fatal("expected error outside batch mode")
+	}
+	_, options, err := parseArgs([]string{"--mode", "batch", "--profile", "metabase=debug", "--profile", "traefik=proxy"})
+	if err != nil {
+		t.Fatalf("parse args: %v", err)
+	}
+	if len(options.Automation.Profiles) != 2 || options.Automation.Profiles[1] != "traefik=proxy" {
+		t.Fatalf("unexpected profile values: %v", options.Automation.Profiles)
+	}
+}
+
+func TestParseArgsComposeOverride(t *testing.T) {
+	if _, _, err := parseArgs([]string{"--compose-override"}); err == nil {
+		t.Fatal("expected error outside batch mode")
+	}
+	_, options, err := parseArgs([]string{"--mode", "batch", "--compose-override"})
+	if err != nil {
+		t.Fatalf("parse args: %v", err)
+	}
+	if !options.Automation.ComposeOverride {
+		t.Fatal("expected compose override to be enabled")
+	}
+}
+
+func TestParseArgsAppFlags(t *testing.T) {
+	if _, _, err := parseArgs([]string{"--app-port", "3000"}); err == nil {
+		t.Fatal("expected error outside batch mode")
+	}
+	_, options, err := parseArgs([]string{"--mode", "batch", "--app-name", "web", "--app-port", "3001:3000", "--app-env", "A=1", "--app-env", "B=2", "--app-target", "build", "--app-platform", "linux/amd64, linux/arm64", "--app-platform", "linux/arm/v7"})
+	if err != nil {
+		t.Fatalf("parse args: %v", err)
+	}
+	got := options.Automation.App
+	if got.Name != "web" || got.Port != "3001:3000" || len(got.Env) != 2 || got.Target != "build" || strings.Join(got.Platforms, " ") != "linux/amd64 linux/arm64 linux/arm/v7" {
+		t.Fatalf("unexpected app settings: %+v", got)
+	}
+}
+
 func TestParseArgsRejectsWriteAndDryRun(t *testing.T) {
 	_, _, err := parseArgs([]string{"--mode", "batch", "--write", "--dry-run"})
 	if err == nil {
@@ -134,3 +201,41 @@ func TestParseServicesFlag(t *testing.T) {
 		})
 	}
 }
+
+func TestParseRemoveArgsAcceptsFlagsAfterServices(t *testing.T) {
+	options, err := parseRemoveArgs([]string{"kafka", "--write"})
+	if err != nil {
+		t.Fatalf("parse remove args: %v", err)
+	}
+	if !options.Write {
+		t.Fatal("expected write true")
+	}
+	if got := strings.Join(options.Services, ","); got != "kafka" {
+		t.Fatalf("expected services [kafka], got %v", options.Services)
+	}
+
+	options, err = parseRemoveArgs([]string{"--write", "Kafka", "redis", "--", "--write"})
+	if err != nil {
+		t.Fatalf("parse remove args: %v", err)
+	}
+	if got := strings.Join(options.Services, ","); got != "kafka,redis,--write" {
+		t.Fatalf("expected everything after -- to be a service, got %v", options.Services)
+	}
+
+	if _, err := parseRemoveArgs([]string{"--write"}); err == nil {
+		t.Fatal("expected error without services")
+	}
+}
+
+func TestParseAddArgsAcceptsFlagsAfterServices(t *testing.T) {
+	options, err := parseAddArgs([]string{"redis", "--diff", "postgres", "--write"})
+	if err != nil {
+		t.Fatalf("parse add args: %v", err)
+	}
+	if !options.Write || !options.Diff {
+		t.Fatalf("expected write and diff, got %+v", options)
+	}
+	if got := strings.Join(options.Services, ","); got != "redis,postgres" {
+		t.Fatalf("expected services [redis postgres], got %v", options.Services)
+	}
+}